@@ -12,6 +12,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/superfly/flyctl/agent/internal/mux"
 	"github.com/superfly/flyctl/agent/internal/proto"
 	"github.com/superfly/flyctl/gql"
 	"github.com/superfly/flyctl/internal/buildinfo"
@@ -167,6 +169,10 @@ type Client struct {
 	address            string
 	dialer             net.Dialer
 	agentRefusedTokens bool
+
+	muxMu   sync.Mutex
+	session *mux.Session
+	noMux   bool
 }
 
 var errDone = errors.New("done")
@@ -7,6 +7,6 @@ import (
 	"net"
 )
 
-func (c *Client) dialContext(ctx context.Context) (conn net.Conn, err error) {
+func (c *Client) dialAgent(ctx context.Context) (conn net.Conn, err error) {
 	return c.dialer.DialContext(ctx, c.network, c.address)
 }
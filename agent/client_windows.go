@@ -9,7 +9,7 @@ import (
 	"github.com/Microsoft/go-winio"
 )
 
-func (c *Client) dialContext(ctx context.Context) (conn net.Conn, err error) {
+func (c *Client) dialAgent(ctx context.Context) (conn net.Conn, err error) {
 	if UseUnixSockets() {
 		return c.dialer.DialContext(ctx, c.network, c.address)
 	}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/superfly/flyctl/agent/internal/mux"
+	"github.com/superfly/flyctl/agent/internal/proto"
+)
+
+const (
+	// ProtocolVersion is the latest version of the agent protocol. Version 1
+	// is the original protocol of one connection per command, spoken by
+	// agents that predate the hello handshake.
+	ProtocolVersion = 2
+
+	// ProtocolVersionMux is the first version that supports multiplexing
+	// commands over a single connection.
+	ProtocolVersionMux = 2
+)
+
+// noMuxEnv disables multiplexing when set, falling back to one connection
+// per command.
+const noMuxEnv = "FLY_AGENT_NO_MUX"
+
+// HelloResponse is the agent's reply to the hello handshake.
+type HelloResponse struct {
+	// ProtocolVersion is the version both sides agreed on.
+	ProtocolVersion int
+	Version         string
+}
+
+// dialContext returns a connection to the agent over which a command may be
+// run. Agents that support it multiplex these connections over a single one,
+// which is set up by the first call.
+func (c *Client) dialContext(ctx context.Context) (net.Conn, error) {
+	session, err := c.muxSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if session != nil {
+		if stream, err := session.Open(); err == nil {
+			return stream, nil
+		}
+		// The agent went away; the next call sets up a new session.
+	}
+
+	return c.dialAgent(ctx)
+}
+
+// muxSession returns the client's multiplexed session, setting it up if
+// necessary. It returns a nil session when the agent doesn't support
+// multiplexing.
+func (c *Client) muxSession(ctx context.Context) (*mux.Session, error) {
+	c.muxMu.Lock()
+	defer c.muxMu.Unlock()
+
+	if c.noMux || os.Getenv(noMuxEnv) != "" {
+		return nil, nil
+	}
+	if c.session != nil && c.session.Err() == nil {
+		return c.session, nil
+	}
+
+	conn, err := c.dialAgent(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ok, err := handshake(ctx, conn); {
+	case err != nil:
+		// Fall back to a plain connection this time around.
+		_ = conn.Close()
+
+		return nil, nil
+	case !ok:
+		_ = conn.Close()
+		c.noMux = true
+
+		return nil, nil
+	}
+
+	c.session = mux.Client(conn)
+
+	return c.session, nil
+}
+
+// handshake negotiates the protocol version over conn and switches it to
+// multiplexing, reporting whether the agent agreed to.
+func handshake(ctx context.Context, conn net.Conn) (ok bool, err error) {
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err = proto.Write(conn, "hello", strconv.Itoa(ProtocolVersion)); err != nil {
+		return
+	}
+
+	var data []byte
+	if data, err = proto.Read(conn); err != nil {
+		return
+	}
+
+	switch {
+	case isError(data):
+		// Agents that predate the handshake don't know the command.
+		return false, nil
+	case !isOK(data):
+		return false, errInvalidResponse(data)
+	}
+
+	var res HelloResponse
+	if err = unmarshal(&res, data); err != nil {
+		return
+	}
+	if res.ProtocolVersion < ProtocolVersionMux {
+		return false, nil
+	}
+
+	if err = proto.Write(conn, "mux"); err != nil {
+		return
+	}
+	if data, err = proto.Read(conn); err != nil {
+		return
+	}
+
+	switch {
+	case string(data) == "ok":
+	case isError(data):
+		return false, nil
+	default:
+		return false, errInvalidResponse(data)
+	}
+
+	if !stop() {
+		return false, ctx.Err()
+	}
+
+	return true, nil
+}
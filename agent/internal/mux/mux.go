@@ -0,0 +1,282 @@
+// Package mux multiplexes logical streams over a single connection to the
+// agent.
+//
+// Every frame starts with a 9 byte header: the frame type, the stream ID and
+// a length, the last two as big endian uint32s. Only data frames carry a
+// payload; window frames use the length as the number of bytes the receiver
+// is ready to accept. Clients open odd numbered streams and servers even
+// numbered ones.
+package mux
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+const (
+	frameOpen byte = iota + 1
+	frameData
+	frameWindow
+	frameClose
+	frameReset
+)
+
+const (
+	headerSize = 9
+	// maxFrameSize is the largest payload of a data frame.
+	maxFrameSize = 32 << 10
+	// initialWindow is the number of bytes a stream may receive before it's
+	// read by the application.
+	initialWindow = 256 << 10
+	// acceptBacklog is the number of opened streams waiting to be accepted.
+	// Streams opened past it are reset.
+	acceptBacklog = 256
+)
+
+var (
+	// ErrSessionClosed is returned when using a closed session. It wraps
+	// net.ErrClosed, like the errors of a closed connection.
+	ErrSessionClosed = fmt.Errorf("mux: session closed: %w", net.ErrClosed)
+	// ErrStreamReset is returned when using a stream reset by the peer.
+	ErrStreamReset = errors.New("mux: stream reset")
+
+	errProtocol = errors.New("mux: protocol error")
+)
+
+// Session multiplexes streams over a connection.
+type Session struct {
+	conn net.Conn
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	streams map[uint32]*Stream
+	nextID  uint32
+	err     error
+
+	accept chan *Stream
+	done   chan struct{}
+}
+
+// Client returns the client side of a session over conn.
+func Client(conn net.Conn) *Session {
+	return newSession(conn, 1)
+}
+
+// Server returns the server side of a session over conn.
+func Server(conn net.Conn) *Session {
+	return newSession(conn, 2)
+}
+
+func newSession(conn net.Conn, firstID uint32) *Session {
+	s := &Session{
+		conn:    conn,
+		streams: map[uint32]*Stream{},
+		nextID:  firstID,
+		accept:  make(chan *Stream, acceptBacklog),
+		done:    make(chan struct{}),
+	}
+
+	go s.recvLoop()
+
+	return s
+}
+
+// Open opens a new stream. The peer is notified without waiting for a reply,
+// so the stream may be written to right away.
+func (s *Session) Open() (*Stream, error) {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+
+		return nil, s.err
+	}
+
+	id := s.nextID
+	s.nextID += 2
+	st := newStream(s, id)
+	s.streams[id] = st
+	s.mu.Unlock()
+
+	if err := s.writeFrame(frameOpen, id, 0, nil); err != nil {
+		s.remove(id)
+
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// Accept waits for the peer to open a stream.
+func (s *Session) Accept() (*Stream, error) {
+	select {
+	case st := <-s.accept:
+		return st, nil
+	case <-s.done:
+		return nil, s.Err()
+	}
+}
+
+// Done is closed once the session is closed.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason the session was closed, or nil while it's open.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Close closes the session, along with its connection and streams.
+func (s *Session) Close() error {
+	return s.closeWithError(ErrSessionClosed)
+}
+
+func (s *Session) closeWithError(err error) error {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	s.err = err
+	streams := s.streams
+	s.streams = map[uint32]*Stream{}
+	close(s.done)
+	s.mu.Unlock()
+
+	for _, st := range streams {
+		st.setReset(err)
+	}
+
+	return s.conn.Close()
+}
+
+func (s *Session) remove(id uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.streams, id)
+}
+
+func (s *Session) stream(id uint32) *Stream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.streams[id]
+}
+
+func (s *Session) writeFrame(typ byte, id, length uint32, payload []byte) error {
+	var hdr [headerSize]byte
+	hdr[0] = typ
+	binary.BigEndian.PutUint32(hdr[1:5], id)
+	binary.BigEndian.PutUint32(hdr[5:9], length)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	bufs := net.Buffers{hdr[:], payload}
+	if _, err := bufs.WriteTo(s.conn); err != nil {
+		s.closeWithError(err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *Session) recvLoop() {
+	err := s.recv()
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		err = ErrSessionClosed
+	}
+
+	s.closeWithError(err)
+}
+
+func (s *Session) recv() error {
+	var hdr [headerSize]byte
+
+	for {
+		if _, err := io.ReadFull(s.conn, hdr[:]); err != nil {
+			return err
+		}
+
+		typ := hdr[0]
+		id := binary.BigEndian.Uint32(hdr[1:5])
+		length := binary.BigEndian.Uint32(hdr[5:9])
+
+		switch typ {
+		case frameOpen:
+			if err := s.handleOpen(id); err != nil {
+				return err
+			}
+		case frameData:
+			if length > maxFrameSize {
+				return fmt.Errorf("%w: frame of %d bytes", errProtocol, length)
+			}
+
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(s.conn, payload); err != nil {
+				return err
+			}
+
+			// Data for streams that were closed locally is dropped.
+			if st := s.stream(id); st != nil {
+				if err := st.push(payload); err != nil {
+					return err
+				}
+			}
+		case frameWindow:
+			if st := s.stream(id); st != nil {
+				st.addCredit(length)
+			}
+		case frameClose:
+			if st := s.stream(id); st != nil {
+				st.setRemoteClosed()
+			}
+		case frameReset:
+			if st := s.stream(id); st != nil {
+				s.remove(id)
+				st.setReset(ErrStreamReset)
+			}
+		default:
+			return fmt.Errorf("%w: unknown frame type %d", errProtocol, typ)
+		}
+	}
+}
+
+func (s *Session) handleOpen(id uint32) error {
+	s.mu.Lock()
+	if id%2 == s.nextID%2 || s.streams[id] != nil {
+		s.mu.Unlock()
+
+		return fmt.Errorf("%w: invalid stream %d opened", errProtocol, id)
+	}
+
+	st := newStream(s, id)
+	s.streams[id] = st
+	s.mu.Unlock()
+
+	select {
+	case s.accept <- st:
+	default:
+		s.remove(id)
+		// Don't block receiving on a congested connection.
+		go s.writeFrame(frameReset, id, 0, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package mux
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPair(t *testing.T) (client, server *Session) {
+	t.Helper()
+
+	c, s := net.Pipe()
+	client, server = Client(c), Server(s)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	return client, server
+}
+
+// echo accepts streams and writes back whatever they read until EOF.
+func echo(server *Session) {
+	for {
+		st, err := server.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer st.Close()
+
+			_, _ = io.Copy(st, st)
+		}()
+	}
+}
+
+func TestConcurrentStreams(t *testing.T) {
+	client, server := newPair(t)
+	go echo(server)
+
+	var wg sync.WaitGroup
+	for range 32 {
+		wg.Go(func() {
+			st, err := client.Open()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer st.Close()
+
+			// Larger than the window, so that writes wait for credit.
+			sent := make([]byte, 3*initialWindow+123)
+			_, _ = rand.Read(sent)
+
+			go func() {
+				_, _ = st.Write(sent)
+			}()
+
+			received := make([]byte, len(sent))
+			_, err = io.ReadFull(st, received)
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(sent, received))
+		})
+	}
+	wg.Wait()
+}
+
+func TestStreamClose(t *testing.T) {
+	client, server := newPair(t)
+
+	st, err := client.Open()
+	require.NoError(t, err)
+
+	_, err = st.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
+
+	accepted, err := server.Accept()
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(accepted)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = accepted.Write([]byte("late"))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+
+	_, err = st.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestReadDeadline(t *testing.T) {
+	client, server := newPair(t)
+	go echo(server)
+
+	st, err := client.Open()
+	require.NoError(t, err)
+	defer st.Close()
+
+	require.NoError(t, st.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+
+	_, err = st.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	var ne net.Error
+	require.True(t, errors.As(err, &ne))
+	assert.True(t, ne.Timeout())
+
+	// The stream is still usable once the deadline is lifted.
+	require.NoError(t, st.SetReadDeadline(time.Time{}))
+
+	_, err = st.Write([]byte("x"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 1)
+	_, err = io.ReadFull(st, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "x", string(buf))
+}
+
+func TestSessionClose(t *testing.T) {
+	client, server := newPair(t)
+
+	st, err := client.Open()
+	require.NoError(t, err)
+
+	accepted, err := server.Accept()
+	require.NoError(t, err)
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := accepted.Read(make([]byte, 1))
+		readErr <- err
+	}()
+
+	require.NoError(t, client.Close())
+
+	select {
+	case err := <-readErr:
+		assert.ErrorIs(t, err, net.ErrClosed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("read wasn't interrupted by the session closing")
+	}
+
+	_, err = st.Write([]byte("x"))
+	assert.ErrorIs(t, err, ErrSessionClosed)
+
+	_, err = client.Open()
+	assert.ErrorIs(t, err, ErrSessionClosed)
+
+	<-server.Done()
+	_, err = server.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestProtocolErrors(t *testing.T) {
+	c, s := net.Pipe()
+	server := Server(s)
+	defer server.Close()
+
+	// Servers open even numbered streams, so the client may not.
+	go func() {
+		_, _ = c.Write([]byte{frameOpen, 0, 0, 0, 2, 0, 0, 0, 0})
+	}()
+
+	select {
+	case <-server.Done():
+		assert.ErrorIs(t, server.Err(), errProtocol)
+	case <-time.After(5 * time.Second):
+		t.Fatal("session wasn't closed")
+	}
+}
@@ -0,0 +1,287 @@
+package mux
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Stream is a logical connection of a session. Closing a stream closes it in
+// both directions: the peer reads io.EOF once it has drained what was sent,
+// and its writes fail with io.ErrClosedPipe.
+type Stream struct {
+	session *Session
+	id      uint32
+
+	mu            sync.Mutex
+	buf           bytes.Buffer
+	recvWindow    uint32 // bytes the peer may still send
+	consumed      uint32 // bytes read since the last window update
+	sendWindow    uint32 // bytes that may still be sent to the peer
+	closed        bool
+	remoteClosed  bool
+	err           error // set once the stream is reset
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	readReady  chan struct{}
+	writeReady chan struct{}
+}
+
+var _ net.Conn = (*Stream)(nil)
+
+func newStream(s *Session, id uint32) *Stream {
+	return &Stream{
+		session:    s,
+		id:         id,
+		recvWindow: initialWindow,
+		sendWindow: initialWindow,
+		readReady:  make(chan struct{}, 1),
+		writeReady: make(chan struct{}, 1),
+	}
+}
+
+// ID returns the ID of the stream.
+func (st *Stream) ID() uint32 {
+	return st.id
+}
+
+func (st *Stream) Read(p []byte) (int, error) {
+	for {
+		st.mu.Lock()
+		if st.closed {
+			st.mu.Unlock()
+
+			return 0, net.ErrClosed
+		}
+
+		if st.buf.Len() > 0 {
+			n, _ := st.buf.Read(p)
+
+			var update uint32
+			if st.consumed += uint32(n); st.consumed >= initialWindow/2 && !st.remoteClosed {
+				update, st.consumed = st.consumed, 0
+				st.recvWindow += update
+			}
+			st.mu.Unlock()
+
+			if update > 0 {
+				// A failure here surfaces on the next read.
+				_ = st.session.writeFrame(frameWindow, st.id, update, nil)
+			}
+
+			return n, nil
+		}
+
+		var err error
+		switch {
+		case st.err != nil:
+			err = st.err
+		case st.remoteClosed:
+			err = io.EOF
+		}
+		deadline := st.readDeadline
+		st.mu.Unlock()
+
+		if err != nil {
+			return 0, err
+		}
+		if err := wait(st.readReady, deadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (st *Stream) Write(p []byte) (written int, err error) {
+	for len(p) > 0 {
+		st.mu.Lock()
+		switch {
+		case st.closed:
+			err = net.ErrClosed
+		case st.err != nil:
+			err = st.err
+		case st.remoteClosed:
+			err = io.ErrClosedPipe
+		}
+		if err != nil {
+			st.mu.Unlock()
+
+			return
+		}
+
+		if st.sendWindow == 0 {
+			deadline := st.writeDeadline
+			st.mu.Unlock()
+
+			if err = wait(st.writeReady, deadline); err != nil {
+				return
+			}
+
+			continue
+		}
+
+		n := min(len(p), int(st.sendWindow), maxFrameSize)
+		st.sendWindow -= uint32(n)
+		st.mu.Unlock()
+
+		if err = st.session.writeFrame(frameData, st.id, uint32(n), p[:n]); err != nil {
+			return
+		}
+
+		written += n
+		p = p[n:]
+	}
+
+	return
+}
+
+// Close closes the stream in both directions.
+func (st *Stream) Close() error {
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+
+		return nil
+	}
+
+	st.closed = true
+	reset := st.err != nil
+	st.mu.Unlock()
+
+	st.notify()
+	st.session.remove(st.id)
+
+	if reset {
+		return nil
+	}
+
+	if err := st.session.writeFrame(frameClose, st.id, 0, nil); err != nil && !errors.Is(err, ErrSessionClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (st *Stream) LocalAddr() net.Addr {
+	return st.session.conn.LocalAddr()
+}
+
+func (st *Stream) RemoteAddr() net.Addr {
+	return st.session.conn.RemoteAddr()
+}
+
+func (st *Stream) SetDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.readDeadline, st.writeDeadline = t, t
+	st.mu.Unlock()
+
+	st.notify()
+
+	return nil
+}
+
+func (st *Stream) SetReadDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.readDeadline = t
+	st.mu.Unlock()
+
+	st.notify()
+
+	return nil
+}
+
+func (st *Stream) SetWriteDeadline(t time.Time) error {
+	st.mu.Lock()
+	st.writeDeadline = t
+	st.mu.Unlock()
+
+	st.notify()
+
+	return nil
+}
+
+func (st *Stream) push(payload []byte) error {
+	st.mu.Lock()
+	if uint32(len(payload)) > st.recvWindow {
+		st.mu.Unlock()
+
+		return fmt.Errorf("%w: stream %d exceeded its window", errProtocol, st.id)
+	}
+
+	st.recvWindow -= uint32(len(payload))
+	if !st.closed && !st.remoteClosed {
+		st.buf.Write(payload)
+	}
+	st.mu.Unlock()
+
+	signal(st.readReady)
+
+	return nil
+}
+
+func (st *Stream) addCredit(n uint32) {
+	st.mu.Lock()
+	st.sendWindow += n
+	st.mu.Unlock()
+
+	signal(st.writeReady)
+}
+
+func (st *Stream) setRemoteClosed() {
+	st.mu.Lock()
+	st.remoteClosed = true
+	st.mu.Unlock()
+
+	st.notify()
+}
+
+func (st *Stream) setReset(err error) {
+	st.mu.Lock()
+	if st.err == nil {
+		st.err = err
+	}
+	st.mu.Unlock()
+
+	st.notify()
+}
+
+func (st *Stream) notify() {
+	signal(st.readReady)
+	signal(st.writeReady)
+}
+
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// wait waits for c to be signaled, or for deadline to pass.
+func wait(c <-chan struct{}, deadline time.Time) error {
+	if deadline.IsZero() {
+		<-c
+
+		return nil
+	}
+
+	d := time.Until(deadline)
+	if d <= 0 {
+		return os.ErrDeadlineExceeded
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-c:
+		return nil
+	case <-t.C:
+		return os.ErrDeadlineExceeded
+	}
+}
@@ -130,6 +130,7 @@ type server struct {
 	tunnels               map[tunnelKey]*wg.Tunnel
 	tokens                *tokens.Tokens
 	cancelTokenMonitoring func()
+	lastSessionID         atomic.Uint64
 }
 
 type terminateError struct{ error }
@@ -161,13 +162,11 @@ func (s *server) serve(parent context.Context, l net.Listener) (err error) {
 		s.printf("OK %d", os.Getpid())
 		defer s.print("QUIT")
 
-		var sID uint64
-
 		for {
 			var conn net.Conn
 			if conn, err = s.listener.Accept(); err == nil {
 				eg.Go(func() error {
-					runSession(ctx, s, conn, id(atomic.AddUint64(&sID, 1)))
+					runSession(ctx, s, conn, s.nextSessionID())
 
 					return nil
 				})
@@ -200,6 +199,10 @@ func (s *server) serve(parent context.Context, l net.Listener) (err error) {
 	return
 }
 
+func (s *server) nextSessionID() id {
+	return id(s.lastSessionID.Add(1))
+}
+
 func (s *server) shutdown() {
 	_ = s.listener.Close()
 }
@@ -22,6 +22,7 @@ import (
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/fly-go/tokens"
 	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/agent/internal/mux"
 	"github.com/superfly/flyctl/agent/internal/proto"
 	"github.com/superfly/flyctl/wg"
 
@@ -42,6 +43,10 @@ type session struct {
 	logger *log.Logger
 	id     id
 	tokens *tokens.Tokens
+
+	// protocolVersion is the version negotiated with hello; clients that
+	// don't send it speak version 1.
+	protocolVersion int
 }
 
 var errUnsupportedCommand = errors.New("unsupported command")
@@ -71,10 +76,11 @@ func runSession(ctx context.Context, srv *server, conn net.Conn, id id) {
 	}()
 
 	s := &session{
-		srv:    srv,
-		conn:   conn,
-		logger: logger,
-		id:     id,
+		srv:             srv,
+		conn:            conn,
+		logger:          logger,
+		id:              id,
+		protocolVersion: 1,
 	}
 
 	if err := s.srv.checkForConfigChange(); err != nil {
@@ -126,6 +132,10 @@ func (s *session) runCommand(ctx context.Context) {
 		handler = (*session).ping6
 	case "set-token":
 		handler = (*session).setToken
+	case "hello":
+		handler = (*session).hello
+	case "mux":
+		handler = (*session).mux
 	default:
 		s.error(errUnsupportedCommand)
 
@@ -161,6 +171,81 @@ func (s *session) ping(_ context.Context, args ...string) {
 	})
 }
 
+var errMalformedHello = errors.New("malformed hello command")
+
+// hello negotiates the protocol version with the client, which is the lowest
+// of the versions both sides speak.
+func (s *session) hello(ctx context.Context, args ...string) {
+	if !s.exactArgs(1, args, errMalformedHello) {
+		return
+	}
+
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < 1 {
+		s.error(errMalformedHello)
+
+		return
+	}
+
+	s.protocolVersion = min(version, agent.ProtocolVersion)
+
+	if !s.marshal(agent.HelloResponse{
+		ProtocolVersion: s.protocolVersion,
+		Version:         buildinfo.Version().String(),
+	}) {
+		return
+	}
+
+	s.runCommand(ctx)
+}
+
+var (
+	errMalformedMux   = errors.New("malformed mux command")
+	errMuxUnsupported = errors.New("mux requires negotiating protocol version 2 with hello")
+)
+
+// mux repurposes the connection to carry many streams, each of which is
+// served as a session of its own.
+func (s *session) mux(ctx context.Context, args ...string) {
+	if !s.noArgs(args, errMalformedMux) {
+		return
+	}
+
+	if s.protocolVersion < agent.ProtocolVersionMux {
+		s.error(errMuxUnsupported)
+
+		return
+	}
+
+	if !s.ok() {
+		return
+	}
+
+	ms := mux.Server(s.conn)
+	defer ms.Close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		stream, err := ms.Accept()
+		if err != nil {
+			if !isClosed(err) {
+				s.logger.Printf("failed accepting stream: %v", err)
+			}
+
+			return
+		}
+
+		id := s.srv.nextSessionID()
+		s.logger.Printf("stream %d is session %s", stream.ID(), id)
+
+		wg.Go(func() {
+			runSession(ctx, s.srv, stream, id)
+		})
+	}
+}
+
 var errMalformedEstablish = errors.New("malformed establish command")
 
 func (s *session) doEstablish(ctx context.Context, recycle bool, args ...string) {
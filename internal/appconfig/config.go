@@ -13,6 +13,7 @@ import (
 	"slices"
 
 	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/containerconfig"
 	"github.com/superfly/flyctl/internal/dockerfileurl"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/launchdarkly"
@@ -116,6 +117,42 @@ type Deploy struct {
 	ReleaseCommandTimeout *fly.Duration `toml:"release_command_timeout,omitempty" json:"release_command_timeout,omitempty"`
 	ReleaseCommandCompute *Compute      `toml:"release_command_vm,omitempty" json:"release_command_vm,omitempty"`
 	SeedCommand           string        `toml:"seed_command,omitempty" json:"seed_command,omitempty"`
+	Progressive           *Progressive  `toml:"progressive,omitempty" json:"progressive,omitempty"`
+	PreDeploy             *DeployHook   `toml:"pre_deploy,omitempty" json:"pre_deploy,omitempty"`
+	PostDeploy            *DeployHook   `toml:"post_deploy,omitempty" json:"post_deploy,omitempty"`
+	OnRollback            *DeployHook   `toml:"on_rollback,omitempty" json:"on_rollback,omitempty"`
+	// AutoRollback restores updated machines to their previous config when a
+	// rolling or canary deployment fails.
+	AutoRollback bool `toml:"auto_rollback,omitempty" json:"auto_rollback,omitempty"`
+}
+
+// DeployHook is a command run at a given point of a deployment. A failing
+// pre_deploy or post_deploy hook fails the deployment.
+type DeployHook struct {
+	Command string `toml:"command,omitempty" json:"command,omitempty"`
+	// Local runs the command on the host running flyctl instead of in an
+	// ephemeral Machine started from the deployed image, like the release command.
+	Local   bool          `toml:"local,omitempty" json:"local,omitempty"`
+	Timeout *fly.Duration `toml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// Progressive configures the "progressive" deployment strategy, which updates a
+// growing share of each process group's machines and checks a gate between steps.
+type Progressive struct {
+	// Steps are the cumulative percentages of machines per process group that are
+	// updated at the end of each step, e.g. [10, 50, 100]. A final 100% step is
+	// implied when missing.
+	Steps []float64 `toml:"steps,omitempty" json:"steps,omitempty"`
+	// Pause is how long to watch the updated machines before evaluating the gate.
+	Pause *fly.Duration `toml:"pause,omitempty" json:"pause,omitempty"`
+	// MaxFailedChecks is the number of failing health checks tolerated across
+	// updated machines before the deployment is rolled back.
+	MaxFailedChecks *int `toml:"max_failed_checks,omitempty" json:"max_failed_checks,omitempty"`
+	// MetricsQuery is an optional PromQL query run against the organization's
+	// Prometheus endpoint; the deployment is rolled back when any resulting
+	// sample is above MetricsThreshold.
+	MetricsQuery     string   `toml:"metrics_query,omitempty" json:"metrics_query,omitempty"`
+	MetricsThreshold *float64 `toml:"metrics_threshold,omitempty" json:"metrics_threshold,omitempty"`
 }
 
 type File struct {
@@ -169,6 +206,9 @@ type Mount struct {
 
 type BuildCompose struct {
 	File string `toml:"file,omitempty" json:"file,omitempty"`
+	// Split runs every service of the compose file in its own process group
+	// instead of running all of them on every Machine.
+	Split bool `toml:"split,omitempty" json:"split,omitempty"`
 }
 
 type Build struct {
@@ -184,6 +224,14 @@ type Build struct {
 	Compose           *BuildCompose     `toml:"compose,omitempty" json:"compose,omitempty"`
 	Compression       string            `toml:"compression,omitempty" json:"compression,omitempty"`
 	CompressionLevel  *int              `toml:"compression_level,omitempty" json:"compression_level,omitempty"`
+	// Platforms are the platforms to build the image for, linux/amd64 unless
+	// set. Machines run the linux/amd64 variant, the others are for use
+	// outside of Fly.io.
+	Platforms []string `toml:"platforms,omitempty" json:"platforms,omitempty"`
+	// Context is the directory the image is built from, relative to fly.toml,
+	// such as the root of the monorepo workspace of the app. The working
+	// directory of the deploy unless set.
+	Context string `toml:"context,omitempty" json:"context,omitempty"`
 }
 
 type Experimental struct {
@@ -289,6 +337,20 @@ func (c *Config) DetermineCompression(ctx context.Context) (compression string,
 	return
 }
 
+// DeterminePlatforms returns the platforms to build the image for: the ones of
+// the --platform flag, or of fly.toml. None means the default, linux/amd64.
+func (c *Config) DeterminePlatforms(ctx context.Context) []string {
+	if platforms := flag.GetPlatforms(ctx); len(platforms) > 0 {
+		return platforms
+	}
+
+	if c != nil && c.Build != nil {
+		return c.Build.Platforms
+	}
+
+	return nil
+}
+
 // IsUsingGPU returns true if any VMs have a gpu-kind set.
 func (c *Config) IsUsingGPU() bool {
 	for _, vm := range c.Compute {
@@ -326,6 +388,16 @@ func (c *Config) Ignorefile() string {
 	return c.Build.Ignorefile
 }
 
+// BuildContext returns the directory the image is built from when [build]
+// sets one, or an empty string.
+func (c *Config) BuildContext() string {
+	if c == nil || c.Build == nil || c.Build.Context == "" {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(c.configFilePath), c.Build.Context)
+}
+
 func (c *Config) DockerBuildTarget() string {
 	if c == nil || c.Build == nil {
 		return ""
@@ -477,3 +549,66 @@ func (c *Config) DetectComposeFile() string {
 
 	return ""
 }
+
+// ComposeFilePath returns the path of the compose file the app is deployed
+// from, or an empty string when it isn't. Relative paths are relative to the
+// directory of the config file.
+func (c *Config) ComposeFilePath() string {
+	if c.Build == nil || c.Build.Compose == nil {
+		return ""
+	}
+
+	path := c.DetectComposeFile()
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(filepath.Dir(c.configFilePath), path)
+}
+
+// ComposeSplit reports whether the services of the compose file of the app run
+// in process groups of their own.
+func (c *Config) ComposeSplit() bool {
+	return c.Build != nil && c.Build.Compose != nil && c.Build.Compose.Split
+}
+
+// ComposeDeployWaves returns the process groups of the split compose file of
+// the app in the order their dependencies require deploying them in, or nil
+// when the compose file isn't split.
+func (c *Config) ComposeDeployWaves() ([][]string, error) {
+	if !c.ComposeSplit() {
+		return nil, nil
+	}
+
+	return containerconfig.ComposeDeployWaves(c.ComposeFilePath())
+}
+
+// AddComposeMounts mounts the named volume the services of the compose file
+// share, unless [[mounts]] mounts a volume already. Split compose files mount
+// the volumes of their process groups in [[mounts]].
+func (c *Config) AddComposeMounts() error {
+	path := c.ComposeFilePath()
+	if path == "" || c.ComposeSplit() {
+		return nil
+	}
+
+	volumes, err := containerconfig.ComposeVolumes(path)
+	if err != nil || len(volumes) == 0 {
+		return err
+	}
+
+	if len(c.Mounts) == 0 {
+		c.Mounts = append(c.Mounts, Mount{
+			Source:      volumes[0].Name,
+			Destination: volumes[0].Path,
+		})
+
+		return nil
+	}
+
+	if !slices.ContainsFunc(c.Mounts, func(m Mount) bool { return m.Source == volumes[0].Name }) {
+		return fmt.Errorf("compose volume %s isn't the source of any of the app's [[mounts]]", volumes[0].Name)
+	}
+
+	return nil
+}
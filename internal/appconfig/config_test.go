@@ -1,6 +1,7 @@
 package appconfig
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,6 +40,16 @@ func TestConfigDockerGetters(t *testing.T) {
 	assert.Equal(t, nilCfg.Dockerfile(), "")
 	assert.Equal(t, nilCfg.Ignorefile(), "")
 	assert.Equal(t, nilCfg.DockerBuildTarget(), "")
+	assert.Equal(t, nilCfg.BuildContext(), "")
+}
+
+func TestConfigBuildContext(t *testing.T) {
+	cfg := Config{
+		configFilePath: filepath.Join("monorepo", "apps", "web", "fly.toml"),
+		Build:          &Build{Context: "../.."},
+	}
+
+	assert.Equal(t, "monorepo", cfg.BuildContext())
 }
 
 func TestNilBuildStrategy(t *testing.T) {
@@ -2,6 +2,7 @@ package appconfig
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/docker/go-units"
 	"github.com/google/shlex"
@@ -264,7 +265,20 @@ func (c *Config) updateMachineConfig(src *fly.MachineConfig) (*fly.MachineConfig
 		// DetectComposeFile returns the explicit file if set, otherwise auto-detects
 		composePath = c.DetectComposeFile()
 	}
-	if err := containerconfig.ParseContainerConfig(mConfig, composePath, appMachineConfig, c.ConfigFilePath(), c.Container); err != nil {
+	if c.ComposeSplit() {
+		if err := containerconfig.ParseComposeFileForGroup(mConfig, c.ComposeFilePath(), processGroup); err != nil {
+			return nil, err
+		}
+
+		// Services reach other process groups by their name, which is the name
+		// of the services running in them unless they're grouped otherwise
+		if mConfig.DNS == nil {
+			mConfig.DNS = &fly.DNSConfig{}
+		}
+		if search := "process." + c.AppName + ".internal"; !slices.Contains(mConfig.DNS.Searches, search) {
+			mConfig.DNS.Searches = append(mConfig.DNS.Searches, search)
+		}
+	} else if err := containerconfig.ParseContainerConfig(mConfig, composePath, appMachineConfig, c.ConfigFilePath(), c.Container); err != nil {
 		return nil, err
 	}
 
@@ -53,6 +53,17 @@ func TestLoadTOMLAppConfigWithCompose(t *testing.T) {
 	assert.Equal(t, p.Build.Compose.File, "docker-compose.yml")
 }
 
+func TestLoadTOMLAppConfigWithComposeSplit(t *testing.T) {
+	const path = "./testdata/compose-split.toml"
+
+	p, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.NotNil(t, p.Build)
+	require.NotNil(t, p.Build.Compose)
+	assert.True(t, p.Build.Compose.Split)
+	assert.True(t, p.ComposeSplit())
+}
+
 func TestLoadTOMLAppConfigWithComposeAutoDetect(t *testing.T) {
 	const path = "./testdata/compose-autodetect.toml"
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 	"time"
@@ -13,13 +14,14 @@ import (
 	"github.com/logrusorgru/aurora"
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/containerconfig"
 	"github.com/superfly/flyctl/internal/flag/validation"
 	"github.com/superfly/flyctl/internal/sentry"
 )
 
 var (
 	ErrInvalidApplicationConfig = errors.New("invalid app configuration")
-	MachinesDeployStrategies    = []string{"canary", "rolling", "immediate", "bluegreen"}
+	MachinesDeployStrategies    = []string{"canary", "rolling", "immediate", "bluegreen", "progressive"}
 )
 
 func (c *Config) Validate(ctx context.Context) (err error, extra_info string) {
@@ -38,6 +40,8 @@ func (c *Config) Validate(ctx context.Context) (err error, extra_info string) {
 		c.validateMounts,
 		c.validateRestartPolicy,
 		c.validateCompression,
+		c.validatePlatforms,
+		c.validateComposeSplit,
 	}
 
 	extra_info = fmt.Sprintf("Validating %s\n", c.ConfigFilePath())
@@ -121,6 +125,75 @@ func (c *Config) validateDeploySection() (extraInfo string, err error) {
 		}
 	}
 
+	if p := c.Deploy.Progressive; p != nil {
+		info, vErr := p.validate()
+		extraInfo += info
+		if vErr != nil {
+			err = vErr
+		}
+	}
+
+	hooks := []struct {
+		name string
+		hook *DeployHook
+	}{
+		{"pre_deploy", c.Deploy.PreDeploy},
+		{"post_deploy", c.Deploy.PostDeploy},
+		{"on_rollback", c.Deploy.OnRollback},
+	}
+	for _, h := range hooks {
+		if h.hook == nil {
+			continue
+		}
+		info, vErr := h.hook.validate(h.name)
+		extraInfo += info
+		if vErr != nil {
+			err = vErr
+		}
+	}
+
+	return
+}
+
+func (h *DeployHook) validate(name string) (extraInfo string, err error) {
+	if strings.TrimSpace(h.Command) == "" {
+		extraInfo += fmt.Sprintf("deploy.%s.command must be set\n", name)
+		err = ErrInvalidApplicationConfig
+	} else if _, vErr := shlex.Split(h.Command); !h.Local && vErr != nil {
+		extraInfo += fmt.Sprintf("Can't shell split deploy.%s command: '%s'\n", name, h.Command)
+		err = ErrInvalidApplicationConfig
+	}
+
+	if h.Timeout != nil && h.Timeout.Duration <= 0 {
+		extraInfo += fmt.Sprintf("deploy.%s.timeout must be positive, got %s\n", name, h.Timeout.Duration)
+		err = ErrInvalidApplicationConfig
+	}
+
+	return
+}
+
+func (p *Progressive) validate() (extraInfo string, err error) {
+	last := 0.0
+	for _, step := range p.Steps {
+		if step <= last || step > 100 {
+			extraInfo += fmt.Sprintf("deploy.progressive.steps must be increasing percentages between 0 and 100, got %v\n", p.Steps)
+			err = ErrInvalidApplicationConfig
+
+			break
+		}
+		last = step
+	}
+
+	if p.MaxFailedChecks != nil && *p.MaxFailedChecks < 0 {
+		extraInfo += fmt.Sprintf("deploy.progressive.max_failed_checks can't be negative, got %d\n", *p.MaxFailedChecks)
+		err = ErrInvalidApplicationConfig
+	}
+
+	if p.MetricsQuery != "" && p.MetricsThreshold == nil {
+		extraInfo += "deploy.progressive.metrics_threshold must be set when metrics_query is used\n"
+		err = ErrInvalidApplicationConfig
+	}
+
 	return
 }
 
@@ -368,6 +441,17 @@ func (c *Config) validateRestartPolicy() (extraInfo string, err error) {
 	return
 }
 
+func (c *Config) validatePlatforms() (extraInfo string, err error) {
+	if c.Build != nil {
+		if vErr := validation.ValidatePlatforms(c.Build.Platforms); vErr != nil {
+			extraInfo += fmt.Sprintf("%s\n", vErr.Error())
+			err = ErrInvalidApplicationConfig
+		}
+	}
+
+	return
+}
+
 func (c *Config) validateCompression() (extraInfo string, err error) {
 	if c.Build != nil {
 		if c.Build.Compression != "" {
@@ -387,3 +471,28 @@ func (c *Config) validateCompression() (extraInfo string, err error) {
 
 	return
 }
+
+// validateComposeSplit warns about the services of a split compose file that
+// other process groups can't reach by their name, as only the names of the
+// process groups resolve.
+func (c *Config) validateComposeSplit() (extraInfo string, err error) {
+	if !c.ComposeSplit() {
+		return
+	}
+
+	groups, gErr := containerconfig.ComposeGroups(c.ComposeFilePath())
+	if gErr != nil {
+		// validateMachineConversion reports invalid compose files
+		return
+	}
+
+	for _, group := range slices.Sorted(maps.Keys(groups)) {
+		for _, service := range groups[group] {
+			if service != group {
+				extraInfo += fmt.Sprintf("Service '%s' runs in process group '%s': other process groups reach it as '%s', not '%s'\n", service, group, group, service)
+			}
+		}
+	}
+
+	return
+}
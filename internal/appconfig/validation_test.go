@@ -3,12 +3,14 @@ package appconfig
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	getsentry "github.com/getsentry/sentry-go"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/internal/cmdutil/preparers"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/logger"
@@ -81,6 +83,74 @@ func TestConfig_ValidateGroups(t *testing.T) {
 	require.NoErrorf(t, err, x)
 }
 
+func TestConfig_ValidateProgressiveDeploy(t *testing.T) {
+	threshold := 0.05
+	negative := -1
+
+	cases := []struct {
+		name        string
+		progressive *Progressive
+		contains    string
+	}{
+		{
+			name:        "valid",
+			progressive: &Progressive{Steps: []float64{10, 50, 100}, MetricsQuery: "up", MetricsThreshold: &threshold},
+		},
+		{
+			name:        "decreasing steps",
+			progressive: &Progressive{Steps: []float64{50, 10}},
+			contains:    "deploy.progressive.steps must be increasing",
+		},
+		{
+			name:        "step above 100",
+			progressive: &Progressive{Steps: []float64{10, 150}},
+			contains:    "deploy.progressive.steps must be increasing",
+		},
+		{
+			name:        "negative max failed checks",
+			progressive: &Progressive{MaxFailedChecks: &negative},
+			contains:    "max_failed_checks can't be negative",
+		},
+		{
+			name:        "query without threshold",
+			progressive: &Progressive{MetricsQuery: "up"},
+			contains:    "metrics_threshold must be set",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Deploy: &Deploy{Strategy: "progressive", Progressive: tc.progressive}}
+			x, err := cfg.validateDeploySection()
+			if tc.contains == "" {
+				require.NoError(t, err, x)
+
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, x, tc.contains)
+		})
+	}
+}
+
+func TestConfig_ValidateDeployHooks(t *testing.T) {
+	cfg := &Config{Deploy: &Deploy{
+		PreDeploy:  &DeployHook{Command: "./scripts/check-migrations.sh", Local: true},
+		PostDeploy: &DeployHook{Command: "bin/notify deployed", Timeout: fly.MustParseDuration("1m")},
+	}}
+	x, err := cfg.validateDeploySection()
+	require.NoError(t, err, x)
+
+	cfg = &Config{Deploy: &Deploy{
+		PreDeploy:  &DeployHook{Local: true},
+		OnRollback: &DeployHook{Command: "echo 'unterminated"},
+	}}
+	x, err = cfg.validateDeploySection()
+	require.Error(t, err)
+	require.Contains(t, x, "deploy.pre_deploy.command must be set")
+	require.Contains(t, x, "Can't shell split deploy.on_rollback command")
+}
+
 func TestConfig_ValidateMounts(t *testing.T) {
 	cfg, err := LoadConfig("./testdata/validate-mounts.toml")
 	require.NoError(t, err)
@@ -110,3 +180,50 @@ func TestConfig_ValidateServices(t *testing.T) {
 	err, x = cfg.ValidateGroups(ctx, []string{"success"})
 	require.NoErrorf(t, err, x)
 }
+
+func TestConfig_ValidatePlatforms(t *testing.T) {
+	ctx := _getValidationContext(t)
+
+	cfg := NewConfig()
+	cfg.AppName = "foo"
+	cfg.Build = &Build{Platforms: []string{"linux/amd64", "linux/arm64"}}
+	err, x := cfg.Validate(ctx)
+	require.NoError(t, err, x)
+
+	cfg.Build.Platforms = []string{"linux/arm64", "linux/riscv64"}
+	err, x = cfg.Validate(ctx)
+	require.Error(t, err, x)
+	require.Contains(t, x, "Invalid platform 'linux/riscv64'")
+
+	cfg.Build.Platforms = []string{"linux/arm64", "linux/arm64"}
+	err, x = cfg.Validate(ctx)
+	require.Error(t, err, x)
+	require.Contains(t, x, "Platform 'linux/arm64' is listed more than once")
+
+	cfg.Build.Platforms = []string{"linux/arm64"}
+	err, x = cfg.Validate(ctx)
+	require.Error(t, err, x)
+	require.Contains(t, x, "don't include linux/amd64")
+}
+
+func TestConfig_ValidateComposeSplit(t *testing.T) {
+	ctx := _getValidationContext(t)
+
+	composePath := filepath.Join(t.TempDir(), "compose.yaml")
+	require.NoError(t, os.WriteFile(composePath, []byte(`services:
+  web:
+    image: nginx
+  db:
+    image: postgres
+    x-fly-process-group: data
+`), 0o644))
+
+	cfg := NewConfig()
+	cfg.AppName = "foo"
+	cfg.Build = &Build{Compose: &BuildCompose{File: composePath, Split: true}}
+	cfg.Processes = map[string]string{"web": "", "data": ""}
+	err, x := cfg.Validate(ctx)
+	require.NoError(t, err, x)
+	assert.Contains(t, x, "Service 'db' runs in process group 'data': other process groups reach it as 'data', not 'db'")
+	assert.NotContains(t, x, "Service 'web'")
+}
@@ -0,0 +1,190 @@
+package appsecrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/superfly/flyctl/helpers"
+)
+
+const (
+	// IdentityEnv may hold age identities, for machines like CI runners
+	// without an identity file.
+	IdentityEnv = "FLY_SECRETS_AGE_KEY"
+
+	// RecipientsFile lists the age recipients files of secrets are encrypted
+	// to when none are given. It's meant to be committed next to them.
+	RecipientsFile = ".age-recipients"
+
+	identityFile = "secrets-identity.txt"
+	binaryHeader = "age-encryption.org/v1\n"
+)
+
+// DefaultIdentityPath returns the path of the identity used when none is given.
+func DefaultIdentityPath() (string, error) {
+	dir, err := helpers.GetConfigDirectory()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, identityFile), nil
+}
+
+// LoadIdentities reads the age identities of the file at path. Without a path
+// they're read from $FLY_SECRETS_AGE_KEY, or else from the default identity file.
+func LoadIdentities(path string) ([]age.Identity, error) {
+	if path == "" {
+		if key := os.Getenv(IdentityEnv); key != "" {
+			identities, err := age.ParseIdentities(strings.NewReader(key))
+			if err != nil {
+				return nil, fmt.Errorf("failed parsing identities of $%s: %w", IdentityEnv, err)
+			}
+
+			return identities, nil
+		}
+
+		var err error
+		if path, err = DefaultIdentityPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing identities of %s: %w", path, err)
+	}
+
+	return identities, nil
+}
+
+// GenerateIdentity creates an identity and saves it to the default identity
+// path, which must not exist yet.
+func GenerateIdentity() (*age.X25519Identity, string, error) {
+	path, err := DefaultIdentityPath()
+	if err != nil {
+		return nil, "", err
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, "", err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "# public key: %s\n%s\n", identity.Recipient(), identity)
+
+	return identity, path, f.Close()
+}
+
+// LoadRecipients reads the age recipients of the file at path, one per line.
+func LoadRecipients(path string) ([]age.Recipient, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing recipients of %s: %w", path, err)
+	}
+
+	return recipients, nil
+}
+
+// ParseRecipients parses recipients given as strings, like age1...
+func ParseRecipients(values []string) ([]age.Recipient, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	return age.ParseRecipients(strings.NewReader(strings.Join(values, "\n")))
+}
+
+// IsEncrypted reports whether data is an age encrypted file, armored or not.
+func IsEncrypted(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+
+	return bytes.HasPrefix(data, []byte(armor.Header)) || bytes.HasPrefix(data, []byte(binaryHeader))
+}
+
+// Encrypt encrypts plaintext to recipients as an armored age file, which the
+// age CLI decrypts.
+func Encrypt(plaintext []byte, recipients ...age.Recipient) ([]byte, error) {
+	var buf bytes.Buffer
+
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decrypt decrypts an age file, armored or not, with identities.
+func Decrypt(ciphertext []byte, identities ...age.Identity) ([]byte, error) {
+	var src io.Reader = bytes.NewReader(ciphertext)
+	if trimmed := bytes.TrimLeft(ciphertext, " \t\r\n"); bytes.HasPrefix(trimmed, []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(trimmed))
+	}
+
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, errors.New("none of the identities can decrypt the file, it must be re-encrypted with your recipient")
+		}
+
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+// Plaintext returns data decrypted with the identities of identityPath, see
+// LoadIdentities, when it's encrypted, and as-is otherwise.
+func Plaintext(data []byte, identityPath string) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	identities, err := LoadIdentities(identityPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading identities to decrypt secrets: %w", err)
+	}
+
+	return Decrypt(data, identities...)
+}
@@ -0,0 +1,86 @@
+package appsecrets
+
+import (
+	"bytes"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	plaintext := []byte("FOO=BAR\nQUX=\"\"\"multi\nline\"\"\"\n")
+
+	ciphertext, err := Encrypt(plaintext, identity.Recipient())
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(ciphertext))
+	assert.False(t, IsEncrypted(plaintext))
+	assert.NotContains(t, string(ciphertext), "FOO")
+
+	decrypted, err := Decrypt(ciphertext, identity)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	_, err = Decrypt(ciphertext, other)
+	assert.ErrorContains(t, err, "none of the identities")
+}
+
+func TestDecryptBinary(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, identity.Recipient())
+	require.NoError(t, err)
+	_, err = w.Write([]byte("FOO=BAR\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.True(t, IsEncrypted(buf.Bytes()))
+
+	decrypted, err := Decrypt(buf.Bytes(), identity)
+	require.NoError(t, err)
+	assert.Equal(t, "FOO=BAR\n", string(decrypted))
+}
+
+func TestPlaintext(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	t.Setenv(IdentityEnv, identity.String())
+
+	data, err := Plaintext([]byte("FOO=BAR\n"), "")
+	require.NoError(t, err)
+	assert.Equal(t, "FOO=BAR\n", string(data))
+
+	ciphertext, err := Encrypt([]byte("FOO=BAZ\n"), identity.Recipient())
+	require.NoError(t, err)
+
+	data, err = Plaintext(ciphertext, "")
+	require.NoError(t, err)
+
+	secrets, err := Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"FOO": "BAZ"}, secrets)
+}
+
+func TestParseRecipients(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	recipients, err := ParseRecipients(nil)
+	require.NoError(t, err)
+	assert.Empty(t, recipients)
+
+	recipients, err = ParseRecipients([]string{identity.Recipient().String()})
+	require.NoError(t, err)
+	assert.Len(t, recipients, 1)
+
+	_, err = ParseRecipients([]string{"age1nope"})
+	assert.Error(t, err)
+}
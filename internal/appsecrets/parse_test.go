@@ -1,4 +1,4 @@
-package secrets
+package appsecrets
 
 import (
 	"strings"
@@ -15,7 +15,7 @@ FOO=BAR
 # Another comment
 QUX=NAH
 `)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR",
@@ -25,7 +25,7 @@ QUX=NAH
 
 func Test_parse_unix(t *testing.T) {
 	reader := strings.NewReader("FOO=BAR\nQUX=NAH\n")
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR",
@@ -35,7 +35,7 @@ func Test_parse_unix(t *testing.T) {
 
 func Test_parse_windows(t *testing.T) {
 	reader := strings.NewReader("FOO=BAR\r\nQUX=NAH\r\n")
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR",
@@ -54,7 +54,7 @@ TRAILERENV=what
 FIN="""Here is the end,
 my only friend"""
 `)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO":        "BAR",
@@ -66,7 +66,7 @@ my only friend"""
 
 func Test_parse_with_comma(t *testing.T) {
 	reader := strings.NewReader("FOO=BAR,BAZ")
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR,BAZ",
@@ -75,7 +75,7 @@ func Test_parse_with_comma(t *testing.T) {
 
 func Test_parse_with_equal(t *testing.T) {
 	reader := strings.NewReader("FOO=BAR BAZ")
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR BAZ",
@@ -84,7 +84,7 @@ func Test_parse_with_equal(t *testing.T) {
 
 func Test_parse_with_double_quotes(t *testing.T) {
 	reader := strings.NewReader(`FOO="BAR BAZ"`)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR BAZ",
@@ -94,7 +94,7 @@ func Test_parse_with_double_quotes(t *testing.T) {
 // https://github.com/superfly/flyctl/issues/3002
 func Test_parse_with_spaces(t *testing.T) {
 	reader := strings.NewReader(`FOO = BAR`)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR",
@@ -104,7 +104,7 @@ func Test_parse_with_spaces(t *testing.T) {
 // https://github.com/superfly/flyctl/issues/4291
 func Test_parse_with_comment(t *testing.T) {
 	reader := strings.NewReader(`FOO="BAR BAZ" # comment`)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR BAZ",
@@ -113,7 +113,7 @@ func Test_parse_with_comment(t *testing.T) {
 
 func Test_parse_with_single_quotes(t *testing.T) {
 	reader := strings.NewReader("FOO='BAR BAZ'\nKEY='value'")
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR BAZ",
@@ -125,7 +125,7 @@ func Test_parse_with_single_quotes(t *testing.T) {
 func Test_parse_singleline_triple_quotes(t *testing.T) {
 	reader := strings.NewReader(`VARIABLE="""my-single-line-multiline-string"""
 ANOTHER="""another"""`)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"VARIABLE": "my-single-line-multiline-string",
@@ -140,7 +140,7 @@ SINGLE="""x"""
 WITHSPACES="""  spaces  """
 MIXED="""line1"""
 NORMAL=regular`)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"EMPTY":      "",
@@ -155,7 +155,7 @@ NORMAL=regular`)
 func Test_parse_singleline_triple_quotes_with_spaces(t *testing.T) {
 	reader := strings.NewReader(`VARIABLE = """my-single-line-multiline-string"""
 ANOTHER = """another"""`)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"VARIABLE": "my-single-line-multiline-string",
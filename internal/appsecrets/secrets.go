@@ -3,8 +3,10 @@ package appsecrets
 import (
 	"context"
 	crand "crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/superfly/fly-go"
 
@@ -62,3 +64,18 @@ func Sync(ctx context.Context, client flapsutil.FlapsClient, appName string) err
 
 	return Update(ctx, client, appName, nil, unsetSecrets)
 }
+
+// DigestMatches reports whether digest, as ListAppSecrets returns it, is the
+// digest of value. The secrets API digests values as the hex encoded SHA-256
+// of their value, shortened to its first 16 characters; a prefix of any length
+// is accepted. Digests of other algorithms never match, which makes callers
+// treat the secret as changed rather than skip it.
+func DigestMatches(value, digest string) bool {
+	if digest == "" {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(value))
+
+	return strings.HasPrefix(hex.EncodeToString(sum[:]), strings.ToLower(digest))
+}
@@ -0,0 +1,32 @@
+package appsecrets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	fly "github.com/superfly/fly-go"
+)
+
+func TestDigestMatches(t *testing.T) {
+	sum := sha256.Sum256([]byte("hunter2"))
+	digest := hex.EncodeToString(sum[:])
+
+	assert.True(t, DigestMatches("hunter2", digest))
+	assert.True(t, DigestMatches("hunter2", digest[:16]))
+	assert.True(t, DigestMatches("hunter2", strings.ToUpper(digest[:16])))
+	assert.False(t, DigestMatches("hunter3", digest[:16]))
+	assert.False(t, DigestMatches("hunter2", ""))
+}
+
+func TestDigestMatchesListedSecret(t *testing.T) {
+	// A secret as ListAppSecrets returns it: its digest is the first 16
+	// characters of the hex encoded SHA-256 of its value.
+	secret := fly.AppSecret{Name: "DATABASE_URL", Digest: "aca7922f0f9f9849"}
+	value := "postgres://localhost:5432/app"
+
+	assert.True(t, DigestMatches(value, secret.Digest))
+	assert.False(t, DigestMatches(value+"?sslmode=disable", secret.Digest))
+}
@@ -0,0 +1,134 @@
+package imgsrc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	// BuildArgsDigestAttr is the frontend attribute holding the digest of the
+	// build args of an attested build. Unlike the build args themselves, it's
+	// kept in provenance statements of mode=min.
+	BuildArgsDigestAttr = "fly:build-args-digest"
+
+	// SBOMPredicateType is the predicate type of the SBOM statements BuildKit
+	// attaches to images.
+	SBOMPredicateType = "https://spdx.dev/Document"
+
+	// builderIDPrefix prefixes the name of the builder in the builder ID of
+	// provenance statements.
+	builderIDPrefix = "https://fly.io/builders/"
+)
+
+// ProvenancePredicateTypes are the SLSA provenance predicate types BuildKit
+// attaches to images, depending on its version.
+var ProvenancePredicateTypes = []string{
+	"https://slsa.dev/provenance/v0.2",
+	"https://slsa.dev/provenance/v1",
+}
+
+// Attestation describes the SBOM and SLSA provenance statements attached to an
+// image built with ImageOptions.Attest. BuildKit only generates SPDX SBOMs.
+type Attestation struct {
+	SBOMFormat       string `json:"sbom_format"`
+	BuilderID        string `json:"builder_id"`
+	SourceRepository string `json:"source_repository,omitempty"`
+	SourceRevision   string `json:"source_revision,omitempty"`
+	BuildArgsDigest  string `json:"build_args_digest"`
+}
+
+// newAttestation returns the attestation of the image opts builds with the
+// builder named builder, or nil when opts doesn't ask for one.
+func newAttestation(ctx context.Context, opts ImageOptions, builder string) *Attestation {
+	if !opts.Attest {
+		return nil
+	}
+
+	a := &Attestation{
+		SBOMFormat:      "spdx",
+		BuilderID:       builderIDPrefix + builder,
+		BuildArgsDigest: BuildArgsDigest(opts.BuildArgs),
+	}
+
+	revision, err := git(ctx, opts.WorkingDir, "rev-parse", "HEAD")
+	if err != nil {
+		terminal.Debugf("no source revision to attest: %v\n", err)
+
+		return a
+	}
+	a.SourceRevision = revision
+
+	if remote, err := git(ctx, opts.WorkingDir, "config", "--get", "remote.origin.url"); err == nil {
+		a.SourceRepository = redactURL(remote)
+	}
+
+	return a
+}
+
+// frontendAttrs returns the frontend attributes having BuildKit attach the
+// statements of a to the image.
+func (a *Attestation) frontendAttrs() map[string]string {
+	attrs := map[string]string{
+		"attest:sbom":       "",
+		"attest:provenance": "mode=min,builder-id=" + a.BuilderID,
+		BuildArgsDigestAttr: a.BuildArgsDigest,
+	}
+
+	if a.SourceRepository != "" {
+		attrs["vcs:source"] = a.SourceRepository
+	}
+	if a.SourceRevision != "" {
+		attrs["vcs:revision"] = a.SourceRevision
+	}
+
+	return attrs
+}
+
+// BuildArgsDigest returns the digest of args, which doesn't depend on their
+// order.
+func BuildArgsDigest(args map[string]string) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, args[k])
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// redactURL removes the credentials of remote, which CI systems often put in
+// the URL of the repository they clone.
+func redactURL(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil {
+		return remote
+	}
+	u.User = nil
+
+	return u.String()
+}
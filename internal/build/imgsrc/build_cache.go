@@ -0,0 +1,93 @@
+package imgsrc
+
+import (
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/client"
+)
+
+// cacheTypes are the types of caches builds can import and export, along with
+// the attribute locating them when importing and when exporting.
+var cacheTypes = map[string][2]string{
+	"registry": {"ref", "ref"},
+	"local":    {"src", "dest"},
+}
+
+// ParseCacheOptions parses --cache-from values, or --cache-to ones when export
+// is set, as docker buildx does: comma separated attributes such as
+// type=local,dest=path, or the reference of a registry cache.
+func ParseCacheOptions(values []string, export bool) ([]client.CacheOptionsEntry, error) {
+	var entries []client.CacheOptionsEntry
+
+	for _, value := range values {
+		fields, err := csv.NewReader(strings.NewReader(value)).Read()
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache %q: %w", value, err)
+		}
+
+		entry := client.CacheOptionsEntry{Attrs: map[string]string{}}
+		if len(fields) == 1 && !strings.Contains(fields[0], "=") {
+			entry.Type = "registry"
+			entry.Attrs["ref"] = fields[0]
+		} else {
+			for _, field := range fields {
+				k, v, ok := strings.Cut(field, "=")
+				switch {
+				case !ok:
+					return nil, fmt.Errorf("invalid cache %q: %q isn't a key=value pair", value, field)
+				case k == "type":
+					entry.Type = v
+				default:
+					entry.Attrs[k] = v
+				}
+			}
+		}
+
+		location, ok := cacheTypes[entry.Type]
+		if !ok {
+			return nil, fmt.Errorf("invalid cache %q: type must be one of %s", value, strings.Join(slices.Sorted(maps.Keys(cacheTypes)), ", "))
+		}
+
+		attr := location[0]
+		if export {
+			attr = location[1]
+		}
+		if entry.Attrs[attr] == "" {
+			return nil, fmt.Errorf("invalid cache %q: %s caches need %s", value, entry.Type, attr)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// cacheOptions returns the caches the build of opts imports and exports.
+func (io ImageOptions) cacheOptions() (imports, exports []client.CacheOptionsEntry, err error) {
+	if imports, err = ParseCacheOptions(io.CacheFrom, false); err != nil {
+		return nil, nil, err
+	}
+	if exports, err = ParseCacheOptions(io.CacheTo, true); err != nil {
+		return nil, nil, err
+	}
+
+	return imports, exports, nil
+}
+
+// registryCacheRefs returns the references of the registry caches of entries,
+// which builders without BuildKit can only import. It fails on other caches.
+func registryCacheRefs(entries []client.CacheOptionsEntry) ([]string, error) {
+	var refs []string
+	for _, entry := range entries {
+		if entry.Type != "registry" {
+			return nil, fmt.Errorf("%s caches need BuildKit", entry.Type)
+		}
+		refs = append(refs, entry.Attrs["ref"])
+	}
+
+	return refs, nil
+}
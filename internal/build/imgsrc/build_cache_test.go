@@ -0,0 +1,53 @@
+package imgsrc
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCacheOptions(t *testing.T) {
+	entries, err := ParseCacheOptions([]string{
+		"registry.fly.io/my-app:cache",
+		"type=registry,ref=registry.fly.io/my-app:cache,mode=max",
+		`type=local,"dest=/tmp/build cache"`,
+	}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []client.CacheOptionsEntry{
+		{Type: "registry", Attrs: map[string]string{"ref": "registry.fly.io/my-app:cache"}},
+		{Type: "registry", Attrs: map[string]string{"ref": "registry.fly.io/my-app:cache", "mode": "max"}},
+		{Type: "local", Attrs: map[string]string{"dest": "/tmp/build cache"}},
+	}, entries)
+
+	entries, err = ParseCacheOptions(nil, false)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	for _, value := range []string{
+		"type=gha",
+		"type=local,dest=/tmp/cache",
+		"type=registry",
+		"type=local,src",
+	} {
+		_, err := ParseCacheOptions([]string{value}, false)
+		assert.Error(t, err, value)
+	}
+
+	_, err = ParseCacheOptions([]string{"type=local,src=/tmp/cache"}, true)
+	assert.Error(t, err)
+}
+
+func TestRegistryCacheRefs(t *testing.T) {
+	refs, err := registryCacheRefs([]client.CacheOptionsEntry{
+		{Type: "registry", Attrs: map[string]string{"ref": "registry.fly.io/my-app:cache"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"registry.fly.io/my-app:cache"}, refs)
+
+	_, err = registryCacheRefs([]client.CacheOptionsEntry{
+		{Type: "local", Attrs: map[string]string{"src": "/tmp/cache"}},
+	})
+	assert.Error(t, err)
+}
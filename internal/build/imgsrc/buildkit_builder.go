@@ -47,6 +47,10 @@ func (r *BuildkitBuilder) Name() string { return "Buildkit" }
 
 func (*BuildkitBuilder) usesDockerfile() {}
 
+func (*BuildkitBuilder) buildsMultiPlatform() {}
+
+func (*BuildkitBuilder) attests() {}
+
 func (r *BuildkitBuilder) Run(ctx context.Context, _ *dockerClientFactory, streams *iostreams.IOStreams, opts ImageOptions, build *build) (*DeploymentImage, string, error) {
 	ctx, span := tracing.GetTracer().Start(ctx, "buildkit_builder", trace.WithAttributes(opts.ToSpanAttributes()...))
 	defer span.End()
@@ -120,12 +124,19 @@ func (r *BuildkitBuilder) buildWithBuildkit(ctx context.Context, streams *iostre
 	buildState.BuildAndPushStart()
 	defer buildState.BuildAndPushFinish()
 
-	res, err := buildImage(ctx, buildkitClient, opts, dockerfilePath)
+	attestation := newAttestation(ctx, opts, "buildkit")
+	res, err := buildImage(ctx, buildkitClient, opts, dockerfilePath, attestation)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := newDeploymentImage(ctx, buildkitClient, res, opts.Tag)
 	if err != nil {
 		return nil, err
 	}
+	image.Attestation = attestation
 
-	return newDeploymentImage(ctx, buildkitClient, res, opts.Tag)
+	return image, nil
 }
 
 func (r *BuildkitBuilder) connectClient(ctx context.Context, app *flaps.App, appName string) (*client.Client, error) {
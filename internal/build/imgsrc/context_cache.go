@@ -0,0 +1,329 @@
+package imgsrc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/terminal"
+	"github.com/tonistiigi/fsutil"
+	"github.com/tonistiigi/fsutil/types"
+)
+
+// contextCacheDir is the directory, in the config directory, holding the
+// indexes of the build contexts sent to BuildKit builders.
+const contextCacheDir = "build-context-cache"
+
+// contextIndexEntry records a file of a build context, as last sent.
+type contextIndexEntry struct {
+	Size int64 `json:"size"`
+	// ModTime is the modification time of the file when it was hashed.
+	ModTime int64  `json:"mtime"`
+	Digest  string `json:"digest"`
+	// SentModTime is the modification time the builder was given for the
+	// file. It only changes along with the content of the file.
+	SentModTime int64 `json:"sent_mtime"`
+}
+
+// cachedContext is the build context of a directory for BuildKit builders,
+// filtered by its .dockerignore patterns, which only has the builder upload
+// the files whose content changed since the previous build.
+//
+// BuildKit keeps the context of the previous build of the same shared key, and
+// only asks for the files whose size or modification time differ from its
+// copy. Checking out a branch, or a fresh CI clone, changes the modification
+// time of files whose content is the same, though, so cachedContext keeps an
+// index of the content digest of every file, and gives the builder the
+// modification time it was previously given for files whose content didn't
+// change.
+type cachedContext struct {
+	fs        fsutil.FS
+	root      string
+	key       string
+	indexPath string
+
+	mu   sync.Mutex
+	prev map[string]contextIndexEntry
+	next map[string]contextIndexEntry
+
+	sentFiles atomic.Int64
+	sentBytes atomic.Int64
+}
+
+// newBuildContext returns the build context of opts for BuildKit builders. It
+// falls back to the plain directory when the context can't be cached.
+func newBuildContext(opts ImageOptions, dockerfile string) (fsutil.FS, *cachedContext, error) {
+	var relDockerfile string
+	if isPathInRoot(dockerfile, opts.WorkingDir) {
+		if p, err := filepath.Rel(opts.WorkingDir, dockerfile); err == nil {
+			relDockerfile = filepath.ToSlash(p)
+		}
+	}
+
+	excludes, err := contextExcludes(opts.WorkingDir, opts.IgnorefilePath, relDockerfile)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error reading .dockerignore")
+	}
+
+	configDir, err := helpers.GetConfigDirectory()
+	if err == nil {
+		var cached *cachedContext
+		if cached, err = newCachedContext(opts.WorkingDir, excludes, filepath.Join(configDir, contextCacheDir)); err == nil {
+			return cached, cached, nil
+		}
+	}
+	terminal.Debugf("not caching the build context: %v\n", err)
+
+	dir, err := fsutil.NewFS(opts.WorkingDir)
+
+	return dir, nil, err
+}
+
+// contextExcludes returns the patterns of the ignore file of the build
+// context, if any. Unlike readDockerignore, it doesn't exclude fly.toml when
+// there's none, as BuildKit builders never did.
+func contextExcludes(workingDir, ignoreFile, relDockerfile string) ([]string, error) {
+	if ignoreFile == "" {
+		ignoreFile = filepath.Join(workingDir, ".dockerignore")
+	}
+
+	f, err := os.Open(ignoreFile)
+	switch {
+	case os.IsNotExist(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	defer f.Close() // skipcq: GO-S2307
+
+	return parseDockerignore(f, relDockerfile)
+}
+
+// newCachedContext returns the build context of workingDir without the files
+// excludes match, keeping its index in cacheDir.
+func newCachedContext(workingDir string, excludes []string, cacheDir string) (*cachedContext, error) {
+	root, err := filepath.Abs(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := fsutil.NewFS(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(excludes) > 0 {
+		if dir, err = fsutil.NewFilterFS(dir, &fsutil.FilterOpt{ExcludePatterns: excludes}); err != nil {
+			return nil, err
+		}
+	}
+
+	key := contextCacheKey(root, excludes)
+	c := &cachedContext{
+		fs:        dir,
+		root:      root,
+		key:       key,
+		indexPath: filepath.Join(cacheDir, key+".json"),
+		prev:      map[string]contextIndexEntry{},
+		next:      map[string]contextIndexEntry{},
+	}
+
+	data, err := os.ReadFile(c.indexPath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &c.prev); err != nil {
+			terminal.Debugf("ignoring invalid build context index %s: %v\n", c.indexPath, err)
+			c.prev = map[string]contextIndexEntry{}
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// contextCacheKey identifies the build context of root filtered by excludes.
+// It's also the shared key BuildKit keeps the context under.
+func contextCacheKey(root string, excludes []string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", root, strings.Join(excludes, "\n"))
+
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// Walk walks the context, giving the builder the modification time of the
+// files it was previously given when their content didn't change.
+func (c *cachedContext) Walk(ctx context.Context, target string, fn fs.WalkDirFunc) error {
+	err := c.fs.Walk(ctx, target, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry == nil || !entry.Type().IsRegular() {
+			return fn(path, entry, err)
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return fn(path, entry, err)
+		}
+
+		stat, ok := info.Sys().(*types.Stat)
+		if !ok {
+			return fn(path, entry, nil)
+		}
+
+		modTime, err := c.sentModTime(path, stat)
+		if err != nil {
+			return err
+		}
+
+		stat = stat.Clone()
+		stat.ModTime = modTime
+
+		return fn(path, &fsutil.DirEntryInfo{Stat: stat}, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.save(); err != nil {
+		terminal.Debugf("failed to save the build context index: %v\n", err)
+	}
+
+	return nil
+}
+
+// Open opens a file the builder asks for, as it changed.
+func (c *cachedContext) Open(path string) (io.ReadCloser, error) {
+	rc, err := c.fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.sentFiles.Add(1)
+
+	return &countingReadCloser{ReadCloser: rc, n: &c.sentBytes}, nil
+}
+
+// sentModTime returns the modification time to give the builder for the file
+// at path, hashing it when it changed since it was indexed.
+func (c *cachedContext) sentModTime(path string, stat *types.Stat) (int64, error) {
+	c.mu.Lock()
+	entry, ok := c.prev[path]
+	c.mu.Unlock()
+
+	if !ok || entry.Size != stat.Size || entry.ModTime != stat.ModTime {
+		digest, err := c.digest(path)
+		if err != nil {
+			return 0, err
+		}
+
+		sentModTime := stat.ModTime
+		if ok && entry.Digest == digest {
+			sentModTime = entry.SentModTime
+		}
+
+		entry = contextIndexEntry{
+			Size:        stat.Size,
+			ModTime:     stat.ModTime,
+			Digest:      digest,
+			SentModTime: sentModTime,
+		}
+	}
+
+	c.mu.Lock()
+	c.next[path] = entry
+	c.mu.Unlock()
+
+	return entry.SentModTime, nil
+}
+
+func (c *cachedContext) digest(path string) (string, error) {
+	f, err := os.Open(filepath.Join(c.root, path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() // skipcq: GO-S2307
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// save writes the index of the files walked so far. Walks can be partial, as
+// when the builder only asks for the .dockerignore file, so files of the
+// previous index that weren't walked are kept as long as they exist.
+func (c *cachedContext) save() error {
+	c.mu.Lock()
+	index := make(map[string]contextIndexEntry, len(c.prev))
+	for path, entry := range c.prev {
+		if _, walked := c.next[path]; walked {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(c.root, path)); err == nil {
+			index[path] = entry
+		}
+	}
+	for path, entry := range c.next {
+		index[path] = entry
+	}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.indexPath), 0o700); err != nil {
+		return err
+	}
+
+	tmp := c.indexPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.indexPath)
+}
+
+// summary describes how much of the context was uploaded.
+func (c *cachedContext) summary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total int64
+	for _, entry := range c.next {
+		total += entry.Size
+	}
+
+	return fmt.Sprintf("uploaded %s of the %s build context (%s of %s files)",
+		humanize.Bytes(uint64(c.sentBytes.Load())),
+		humanize.Bytes(uint64(total)),
+		humanize.Comma(c.sentFiles.Load()),
+		humanize.Comma(int64(len(c.next))),
+	)
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n.Add(int64(n))
+
+	return n, err
+}
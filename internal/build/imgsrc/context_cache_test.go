@@ -0,0 +1,94 @@
+package imgsrc
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonistiigi/fsutil/types"
+)
+
+// walkContext returns the modification times a builder is given for the files
+// of a fresh cachedContext of dir.
+func walkContext(t *testing.T, dir, cacheDir string, excludes ...string) (*cachedContext, map[string]int64) {
+	t.Helper()
+
+	c, err := newCachedContext(dir, excludes, cacheDir)
+	require.NoError(t, err)
+
+	modTimes := map[string]int64{}
+	err = c.Walk(context.Background(), string(filepath.Separator), func(path string, entry fs.DirEntry, err error) error {
+		require.NoError(t, err)
+
+		info, err := entry.Info()
+		require.NoError(t, err)
+
+		if entry.Type().IsRegular() {
+			modTimes[filepath.ToSlash(path)] = info.Sys().(*types.Stat).ModTime
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	return c, modTimes
+}
+
+func TestCachedContext(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.env"), []byte("TOKEN=x"), 0o644))
+
+	_, first := walkContext(t, dir, cacheDir, "*.env")
+	assert.Len(t, first, 2)
+	assert.NotContains(t, first, "secret.env")
+
+	// Touching a file without changing it keeps the time the builder knows.
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "README.md"), later, later))
+
+	// Changing a file gives the builder its new time.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main // changed"), 0o644))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "src", "main.go"), later, later))
+
+	c, second := walkContext(t, dir, cacheDir, "*.env")
+	assert.Equal(t, first["README.md"], second["README.md"])
+	assert.NotEqual(t, first["src/main.go"], second["src/main.go"])
+	assert.Equal(t, later.UnixNano(), second["src/main.go"])
+
+	rc, err := c.Open(filepath.Join("src", "main.go"))
+	require.NoError(t, err)
+	_, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, "uploaded 23 B of the 28 B build context (1 of 2 files)", c.summary())
+
+	// Other ignore patterns are cached separately.
+	_, other := walkContext(t, dir, cacheDir)
+	assert.Len(t, other, 3)
+	assert.Equal(t, later.UnixNano(), other["README.md"])
+}
+
+func TestContextExcludes(t *testing.T) {
+	dir := t.TempDir()
+
+	excludes, err := contextExcludes(dir, "", "Dockerfile")
+	require.NoError(t, err)
+	assert.Empty(t, excludes)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("node_modules\n*"), 0o644))
+
+	excludes, err = contextExcludes(dir, "", "Dockerfile")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node_modules", "*", "!.dockerignore", "!Dockerfile"}, excludes)
+}
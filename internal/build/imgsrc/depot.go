@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -61,6 +62,10 @@ func (d *DepotBuilder) Name() string { return "depot.dev" }
 
 func (*DepotBuilder) usesDockerfile() {}
 
+func (*DepotBuilder) buildsMultiPlatform() {}
+
+func (*DepotBuilder) attests() {}
+
 func (d *DepotBuilder) Run(ctx context.Context, _ *dockerClientFactory, streams *iostreams.IOStreams, opts ImageOptions, build *build) (*DeploymentImage, string, error) {
 	ctx, span := tracing.GetTracer().Start(ctx, "depot_builder", trace.WithAttributes(opts.ToSpanAttributes()...))
 	defer span.End()
@@ -177,7 +182,8 @@ func depotBuild(ctx context.Context, streams *iostreams.IOStreams, opts ImageOpt
 	tb.Done(link)
 
 	buildState.BuildAndPushStart()
-	res, buildErr := buildImage(ctx, buildkitClient, opts, dockerfilePath)
+	attestation := newAttestation(ctx, opts, "depot")
+	res, buildErr := buildImage(ctx, buildkitClient, opts, dockerfilePath, attestation)
 	if buildErr != nil {
 		buildState.BuildAndPushFinish()
 
@@ -188,7 +194,13 @@ func depotBuild(ctx context.Context, streams *iostreams.IOStreams, opts ImageOpt
 	link = streams.CreateLink("Build Summary: ", build.BuildURL)
 	tb.Done(link)
 
-	return newDeploymentImage(ctx, buildkitClient, res, opts.Tag)
+	image, err := newDeploymentImage(ctx, buildkitClient, res, opts.Tag)
+	if err != nil {
+		return nil, err
+	}
+	image.Attestation = attestation
+
+	return image, nil
 }
 
 // initBuilder returns a Depot machine to build a container image.
@@ -237,7 +249,9 @@ func initBuilder(ctx context.Context, buildState *build, appName string, streams
 	return machine, &build, nil
 }
 
-func buildImage(ctx context.Context, buildkitClient *client.Client, opts ImageOptions, dockerfilePath string) (*client.SolveResponse, error) {
+// buildImage builds the image of opts, attaching the statements of attestation
+// to it when set.
+func buildImage(ctx context.Context, buildkitClient *client.Client, opts ImageOptions, dockerfilePath string, attestation *Attestation) (*client.SolveResponse, error) {
 	ctx, span := tracing.GetTracer().Start(ctx, "depot_build_image", trace.WithAttributes(opts.ToSpanAttributes()...))
 	defer span.End()
 
@@ -262,11 +276,16 @@ func buildImage(ctx context.Context, buildkitClient *client.Client, opts ImageOp
 	exportEntry.Attrs["compression-level"] = strconv.Itoa(opts.CompressionLevel)
 	exportEntry.Attrs["force-compression"] = "true"
 
+	cacheImports, cacheExports, err := opts.cacheOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	dockerfileDir, err := fsutil.NewFS(filepath.Dir(dockerfilePath))
 	if err != nil {
 		return nil, err
 	}
-	contextDir, err := fsutil.NewFS(opts.WorkingDir)
+	contextDir, cachedCtx, err := newBuildContext(opts, dockerfilePath)
 	if err != nil {
 		return nil, err
 	}
@@ -279,16 +298,22 @@ func buildImage(ctx context.Context, buildkitClient *client.Client, opts ImageOp
 			FrontendAttrs: map[string]string{
 				"filename": filepath.Base(dockerfilePath),
 				"target":   opts.Target,
-				"platform": "linux/amd64",
+				"platform": opts.platform(),
 			},
 			LocalMounts: map[string]fsutil.FS{
 				"dockerfile": dockerfileDir,
 				"context":    contextDir,
 			},
-			Exports: []client.ExportEntry{exportEntry},
+			Exports:      []client.ExportEntry{exportEntry},
+			CacheImports: cacheImports,
+			CacheExports: cacheExports,
 			// Prevent recording the build steps and traces in buildkit as it is _very_ slow.
 			Internal: true,
 		}
+		if cachedCtx != nil {
+			// Have the builder reuse the context of the previous build.
+			solverOptions.SharedKey = cachedCtx.key
+		}
 		if opts.NoCache {
 			solverOptions.FrontendAttrs["no-cache"] = ""
 		}
@@ -298,6 +323,9 @@ func buildImage(ctx context.Context, buildkitClient *client.Client, opts ImageOp
 		for k, v := range opts.BuildArgs {
 			solverOptions.FrontendAttrs["build-arg:"+k] = v
 		}
+		if attestation != nil {
+			maps.Copy(solverOptions.FrontendAttrs, attestation.frontendAttrs())
+		}
 
 		secrets := make(map[string][]byte)
 		for k, v := range opts.BuildSecrets {
@@ -312,6 +340,9 @@ func buildImage(ctx context.Context, buildkitClient *client.Client, opts ImageOp
 		)
 
 		res, err = buildkitClient.Solve(ctx, nil, solverOptions, ch)
+		if err == nil && cachedCtx != nil {
+			terminal.Debugf("%s\n", cachedCtx.summary())
+		}
 
 		return err
 	})
@@ -348,6 +379,21 @@ func newDeploymentImage(ctx context.Context, c *client.Client, res *client.Solve
 		}
 	}
 
+	// Multi-platform builds export an index of the images of every platform.
+	// Its size is the one of the image Machines run.
+	if manifest, err := descriptor.Annotations.Manifest(); err == nil && len(manifest.Manifests) > 0 {
+		image := manifest.PlatformManifest(defaultPlatform)
+		descriptor = &Descriptor{
+			MediaType: image.MediaType,
+			Digest:    image.Digest,
+			Size:      image.Size,
+		}
+		descriptor.Annotations.RawManifest, err = readContent(ctx, c.ContentClient(), descriptor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var builderHostname string
 	workers, err := c.ListWorkers(ctx)
 	if err != nil {
@@ -407,6 +453,20 @@ type Manifest struct {
 	MediaType     string          `json:"mediaType,omitempty"`
 	Config        OCIDescriptor   `json:"config"`
 	Layers        []OCIDescriptor `json:"layers,omitempty"`
+	// Manifests are the manifests of an image index, by platform.
+	Manifests []OCIDescriptor `json:"manifests,omitempty"`
+}
+
+// PlatformManifest returns the manifest of the image index for platform, as
+// "os/arch[/variant]", or the first one when the index has none for it.
+func (m *Manifest) PlatformManifest(platform string) OCIDescriptor {
+	for _, manifest := range m.Manifests {
+		if manifest.Platform != nil && manifest.Platform.String() == platform {
+			return manifest
+		}
+	}
+
+	return m.Manifests[0]
 }
 
 func (m *Manifest) Bytes() int64 {
@@ -422,4 +482,21 @@ type OCIDescriptor struct {
 	MediaType string `json:"mediaType,omitempty"`
 	Digest    string `json:"digest,omitempty"`
 	Size      int64  `json:"size,omitempty"`
+	// Platform is the platform of the manifests of an image index.
+	Platform *OCIPlatform `json:"platform,omitempty"`
+}
+
+type OCIPlatform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p *OCIPlatform) String() string {
+	platform := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		platform += "/" + p.Variant
+	}
+
+	return platform
 }
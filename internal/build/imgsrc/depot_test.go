@@ -32,3 +32,19 @@ func TestInitBuilder(t *testing.T) {
 	_, _, err := initBuilder(ctx, build, "app1", ios, DepotBuilderScopeOrganization)
 	require.ErrorContains(t, err, `unsupported protocol scheme "invalid"`)
 }
+
+func TestManifestPlatformManifest(t *testing.T) {
+	annotations := Annotations{RawManifest: `{
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": [
+			{"digest": "sha256:arm", "size": 1, "platform": {"os": "linux", "architecture": "arm64"}},
+			{"digest": "sha256:amd", "size": 2, "platform": {"os": "linux", "architecture": "amd64"}},
+			{"digest": "sha256:att", "size": 3, "platform": {"os": "unknown", "architecture": "unknown"}}
+		]
+	}`}
+	manifest, err := annotations.Manifest()
+	require.NoError(t, err)
+
+	require.Equal(t, "sha256:amd", manifest.PlatformManifest(defaultPlatform).Digest)
+	require.Equal(t, "sha256:arm", manifest.PlatformManifest("linux/riscv64").Digest)
+}
@@ -328,11 +328,23 @@ func runClassicBuild(ctx context.Context, streams *iostreams.IOStreams, docker *
 	)
 	defer span.End()
 
-	platform := "linux/amd64"
+	platform := opts.platform()
 	if p := os.Getenv("FLY_DEV_PLATFORM"); p != "" {
 		platform = p
 	}
 
+	cacheImports, cacheExports, err := opts.cacheOptions()
+	if err != nil {
+		return "", err
+	}
+	if len(cacheExports) > 0 {
+		return "", errors.New("exporting the build cache needs BuildKit")
+	}
+	cacheFrom, err := registryCacheRefs(cacheImports)
+	if err != nil {
+		return "", err
+	}
+
 	options := types.ImageBuildOptions{
 		Tags:        []string{opts.Tag},
 		BuildArgs:   buildArgs,
@@ -342,6 +354,7 @@ func runClassicBuild(ctx context.Context, streams *iostreams.IOStreams, docker *
 		Target:      opts.Target,
 		NoCache:     opts.NoCache,
 		Labels:      opts.Label,
+		CacheFrom:   cacheFrom,
 	}
 
 	resp, err := docker.ImageBuild(ctx, r, options)
@@ -365,10 +378,10 @@ func runClassicBuild(ctx context.Context, streams *iostreams.IOStreams, docker *
 	return imageID, nil
 }
 
-func solveOptFromImageOptions(opts ImageOptions, dockerfilePath string, buildArgs map[string]*string) (client.SolveOpt, error) {
-	// Fly.io only supports linux/amd64, but local Docker Engine could be running on ARM,
-	// including Apple Silicon. Use FLY_DEV_PLATFORM to override for local testing.
-	platform := "linux/amd64"
+func solveOptFromImageOptions(opts ImageOptions, dockerfilePath string, buildArgs map[string]*string) (client.SolveOpt, *cachedContext, error) {
+	// Images are built for linux/amd64 unless set otherwise, but local Docker Engine could
+	// be running on ARM, including Apple Silicon. Use FLY_DEV_PLATFORM to override for local testing.
+	platform := opts.platform()
 	if p := os.Getenv("FLY_DEV_PLATFORM"); p != "" {
 		platform = p
 	}
@@ -394,16 +407,21 @@ func solveOptFromImageOptions(opts ImageOptions, dockerfilePath string, buildArg
 		attrs["build-arg:"+k] = *v
 	}
 
+	cacheImports, cacheExports, err := opts.cacheOptions()
+	if err != nil {
+		return client.SolveOpt{}, nil, err
+	}
+
 	dockerfileDir, err := fsutil.NewFS(filepath.Dir(dockerfilePath))
 	if err != nil {
-		return client.SolveOpt{}, err
+		return client.SolveOpt{}, nil, err
 	}
-	contextDir, err := fsutil.NewFS(opts.WorkingDir)
+	contextDir, cachedCtx, err := newBuildContext(opts, dockerfilePath)
 	if err != nil {
-		return client.SolveOpt{}, err
+		return client.SolveOpt{}, nil, err
 	}
 
-	return client.SolveOpt{
+	solveOpt := client.SolveOpt{
 		Frontend:      "dockerfile.v0",
 		FrontendAttrs: attrs,
 		LocalMounts: map[string]fsutil.FS{
@@ -417,7 +435,15 @@ func solveOptFromImageOptions(opts ImageOptions, dockerfilePath string, buildArg
 		Exports: []client.ExportEntry{
 			{Type: "moby", Attrs: map[string]string{"name": opts.Tag}},
 		},
-	}, nil
+		CacheImports: cacheImports,
+		CacheExports: cacheExports,
+	}
+	if cachedCtx != nil {
+		// Have the builder reuse the context of the previous build.
+		solveOpt.SharedKey = cachedCtx.key
+	}
+
+	return solveOpt, cachedCtx, nil
 }
 
 func runBuildKitBuild(ctx context.Context, docker *dockerclient.Client, opts ImageOptions, dockerfilePath string, buildArgs map[string]*string) (string, error) {
@@ -442,7 +468,7 @@ func runBuildKitBuild(ctx context.Context, docker *dockerclient.Client, opts Ima
 	eg.Go(newDisplay(statusCh))
 	var res *client.SolveResponse
 	eg.Go(func() error {
-		options, err := solveOptFromImageOptions(opts, dockerfilePath, buildArgs)
+		options, cachedCtx, err := solveOptFromImageOptions(opts, dockerfilePath, buildArgs)
 		if err != nil {
 			return err
 		}
@@ -465,6 +491,10 @@ func runBuildKitBuild(ctx context.Context, docker *dockerclient.Client, opts Ima
 			return err
 		}
 
+		if cachedCtx != nil {
+			terminal.Debugf("%s\n", cachedCtx.summary())
+		}
+
 		return nil
 	})
 	err = eg.Wait()
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/superfly/flyctl/internal/dockerfileurl"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/sentry"
 	"github.com/superfly/flyctl/internal/tracing"
@@ -58,6 +60,28 @@ type ImageOptions struct {
 	BuildpacksVolumes    []string
 	Compression          string
 	CompressionLevel     int
+	// Platforms are the platforms to build for, defaultPlatform when empty.
+	// Several produce a multi-platform image.
+	Platforms []string
+	// Attest attaches an SBOM and a SLSA provenance statement to the image.
+	Attest bool
+	// CacheFrom and CacheTo are the caches the build imports and exports, in
+	// the format of ParseCacheOptions.
+	CacheFrom []string
+	CacheTo   []string
+}
+
+// defaultPlatform is the platform images are built for unless set otherwise.
+const defaultPlatform = "linux/amd64"
+
+// platform returns the platforms to build for, comma separated as BuildKit
+// takes them.
+func (io ImageOptions) platform() string {
+	if len(io.Platforms) == 0 {
+		return defaultPlatform
+	}
+
+	return strings.Join(io.Platforms, ",")
 }
 
 func (io ImageOptions) ToSpanAttributes() []attribute.KeyValue {
@@ -78,6 +102,10 @@ func (io ImageOptions) ToSpanAttributes() []attribute.KeyValue {
 		attribute.StringSlice("imageoptions.buildpacks_volumes", io.BuildpacksVolumes),
 		attribute.String("imageoptions.compression", io.Compression),
 		attribute.Int("imageoptions.compressionLevel", io.CompressionLevel),
+		attribute.StringSlice("imageoptions.platforms", io.Platforms),
+		attribute.Bool("imageoptions.attest", io.Attest),
+		attribute.StringSlice("imageoptions.cache_from", io.CacheFrom),
+		attribute.StringSlice("imageoptions.cache_to", io.CacheTo),
 	}
 
 	if io.BuildArgs != nil {
@@ -129,6 +157,11 @@ type DeploymentImage struct {
 	BuildID   int64
 	BuilderID string
 	Labels    map[string]string
+	// Attestation describes the statements attached to the image, if any.
+	Attestation *Attestation
+	// Containers are the images built alongside this one for the services of
+	// a compose file, by container name.
+	Containers map[string]string
 }
 
 func (di *DeploymentImage) String() string {
@@ -308,6 +341,43 @@ func (r *Resolver) BuildImage(ctx context.Context, streams *iostreams.IOStreams,
 		}
 	}
 
+	if len(opts.Platforms) > 1 && !slices.ContainsFunc(strategies, func(s imageBuilder) bool {
+		_, ok := s.(multiPlatformBuilder)
+
+		return ok
+	}) {
+		err := fmt.Errorf("building for several platforms (%s) needs the Depot or BuildKit builder", strings.Join(opts.Platforms, ", "))
+		tracing.RecordError(span, err, "no multi-platform builder")
+
+		return nil, flyerr.GenericErr{
+			Err:     err.Error(),
+			Suggest: "Deploy with --depot or --buildkit, or build for a single platform.",
+		}
+	}
+
+	if opts.Attest && !slices.ContainsFunc(strategies, func(s imageBuilder) bool {
+		_, ok := s.(attestingBuilder)
+
+		return ok
+	}) {
+		err := errors.New("attesting images needs the Depot or BuildKit builder")
+		tracing.RecordError(span, err, "no attesting builder")
+
+		return nil, flyerr.GenericErr{
+			Err:     err.Error(),
+			Suggest: "Deploy with --depot or --buildkit, or without --attest.",
+		}
+	}
+
+	if _, _, err := opts.cacheOptions(); err != nil {
+		tracing.RecordError(span, err, "invalid cache options")
+
+		return nil, flyerr.GenericErr{
+			Err:     err.Error(),
+			Suggest: "Use a registry reference, type=registry,ref=<image> or type=local,src=<dir> for --cache-from, and type=local,dest=<dir> for --cache-to.",
+		}
+	}
+
 	strategiesString := []string{}
 	for _, strategy := range strategies {
 		strategiesString = append(strategiesString, strategy.Name())
@@ -868,6 +938,18 @@ type dockerfileConsumer interface {
 	usesDockerfile()
 }
 
+// multiPlatformBuilder is implemented by the builders that can build
+// multi-platform images.
+type multiPlatformBuilder interface {
+	buildsMultiPlatform()
+}
+
+// attestingBuilder is implemented by the builders that can attach attestations
+// to images.
+type attestingBuilder interface {
+	attests()
+}
+
 func runImageBuilder(ctx context.Context, strategy imageBuilder, dockerFactory *dockerClientFactory, streams *iostreams.IOStreams, opts ImageOptions, build *build, materializer *DockerfileMaterializer) (*DeploymentImage, string, error) {
 	if _, ok := strategy.(dockerfileConsumer); ok {
 		path, err := materializer.materialize(ctx, opts.DockerfilePath)
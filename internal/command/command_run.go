@@ -81,6 +81,8 @@ func DetermineImage(ctx context.Context, appName string, imageOrPath string) (im
 			NoCache:              flag.GetBool(ctx, "no-build-cache"),
 			BuildpacksDockerHost: flag.GetString(ctx, flag.BuildpacksDockerHost),
 			BuildpacksVolumes:    flag.GetStringSlice(ctx, flag.BuildpacksVolume),
+			CacheFrom:            flag.GetCacheFrom(ctx),
+			CacheTo:              flag.GetCacheTo(ctx),
 		}
 
 		dockerfilePath := cfg.Dockerfile()
@@ -0,0 +1,60 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/internal/workspace"
+)
+
+// changedSince reports whether the app changed since the git ref. The app of
+// a package of a monorepo workspace changed when its package, the packages it
+// depends on, the files at the root of the workspace or the directory of its
+// fly.toml changed; other apps changed when their directory changed.
+func changedSince(ctx context.Context, ref string) (bool, error) {
+	dir := state.WorkingDirectory(ctx)
+	if cfg := appconfig.ConfigFromContext(ctx); cfg != nil && cfg.ConfigFilePath() != "" {
+		dir = filepath.Dir(cfg.ConfigFilePath())
+	}
+
+	w, err := workspace.Detect(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to detect workspace: %w", err)
+	}
+
+	var pkg *workspace.Package
+	if w != nil {
+		pkg = w.Package(dir)
+	}
+	if pkg == nil {
+		files, err := workspace.ChangedFiles(ctx, dir, ref)
+		if err != nil {
+			return false, err
+		}
+
+		return len(files) > 0, nil
+	}
+
+	files, err := workspace.ChangedFiles(ctx, w.Root, ref)
+	if err != nil {
+		return false, err
+	}
+
+	appDir, err := filepath.Rel(w.Root, dir)
+	if err != nil {
+		return false, err
+	}
+	appDir = filepath.ToSlash(appDir)
+
+	for _, file := range files {
+		if strings.HasPrefix(file, appDir+"/") {
+			return true, nil
+		}
+	}
+
+	return w.Affected(pkg, files), nil
+}
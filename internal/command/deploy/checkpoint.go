@@ -0,0 +1,253 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/sentry"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// DeployCheckpoint records the progress of an in-flight deployment, so that an
+// interrupted `fly deploy` can be continued with `fly deploy --resume`.
+//
+// The checkpoint is written when the deployment starts and removed once it
+// completes. All methods are safe to call on a nil checkpoint.
+type DeployCheckpoint struct {
+	Manifest       *DeployManifest `json:"manifest"`
+	ReleaseID      string          `json:"release_id,omitempty"`
+	ReleaseVersion int             `json:"release_version,omitempty"`
+	// PreDeployCompleted is set once the pre_deploy hook has succeeded, so it
+	// isn't run a second time when resuming.
+	PreDeployCompleted bool `json:"pre_deploy_completed,omitempty"`
+	// ReleaseCommandCompleted is set once the release command has succeeded,
+	// so it isn't run a second time when resuming.
+	ReleaseCommandCompleted bool `json:"release_command_completed,omitempty"`
+	// UpdatedMachines are the IDs of the machines that were successfully updated.
+	UpdatedMachines []string  `json:"updated_machines,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// checkpointPath returns where the checkpoint of appName's deployments is stored.
+func checkpointPath(ctx context.Context, appName string) string {
+	return filepath.Join(state.ConfigDirectory(ctx), "deploys", appName+".json")
+}
+
+func newDeployCheckpoint(path string, manifest *DeployManifest) *DeployCheckpoint {
+	return &DeployCheckpoint{
+		Manifest: manifest,
+		path:     path,
+	}
+}
+
+func loadDeployCheckpoint(path string) (*DeployCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := &DeployCheckpoint{path: path}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode deploy checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Manifest == nil {
+		return nil, fmt.Errorf("deploy checkpoint %s has no deploy manifest", path)
+	}
+
+	return checkpoint, nil
+}
+
+// setRelease records the release the deployment is rolling out.
+func (c *DeployCheckpoint) setRelease(id string, version int) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ReleaseID = id
+	c.ReleaseVersion = version
+
+	return c.save()
+}
+
+// markPreDeployCompleted records that the pre_deploy hook succeeded.
+func (c *DeployCheckpoint) markPreDeployCompleted() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.PreDeployCompleted = true
+
+	return c.save()
+}
+
+// markReleaseCommandCompleted records that the release command succeeded.
+func (c *DeployCheckpoint) markReleaseCommandCompleted() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ReleaseCommandCompleted = true
+
+	return c.save()
+}
+
+// markUpdated records that the machine was successfully updated.
+func (c *DeployCheckpoint) markUpdated(machineID string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if slices.Contains(c.UpdatedMachines, machineID) {
+		return nil
+	}
+	c.UpdatedMachines = append(c.UpdatedMachines, machineID)
+
+	return c.save()
+}
+
+// isUpdated reports whether the machine was updated before the deployment was interrupted.
+func (c *DeployCheckpoint) isUpdated(machineID string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Contains(c.UpdatedMachines, machineID)
+}
+
+// remove deletes the checkpoint once there is nothing left to resume.
+func (c *DeployCheckpoint) remove() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// save writes the checkpoint to a temporary file first, so a deploy dying
+// mid-write never leaves a truncated checkpoint behind. c.mu must be held.
+func (c *DeployCheckpoint) save() error {
+	c.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.path)
+}
+
+// markMachineUpdated records the machine in the deployment's checkpoint. Failing
+// to do so only makes a later --resume redo more work, so it isn't fatal.
+func (md *machineDeployment) markMachineUpdated(machineID string) {
+	if err := md.checkpoint.markUpdated(machineID); err != nil {
+		terminal.Warnf("failed to save deploy checkpoint: %v\n", err)
+	}
+}
+
+// checkResumable returns an error for the strategies whose deployments can't
+// be resumed: bluegreen doesn't record its progress, and canary would recreate
+// its canary machines.
+func checkResumable(strategy string) error {
+	switch strategy {
+	case "bluegreen", "canary":
+		return fmt.Errorf("--resume isn't supported with the %s strategy, run 'fly deploy' again to start a new deployment", strategy)
+	default:
+		return nil
+	}
+}
+
+// resumeDeploy continues the interrupted deployment of appName from its checkpoint.
+func resumeDeploy(ctx context.Context, appName string) error {
+	var (
+		io   = iostreams.FromContext(ctx)
+		path = checkpointPath(ctx, appName)
+	)
+
+	checkpoint, err := loadDeployCheckpoint(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("no interrupted deployment found for app %s", appName)
+	case err != nil:
+		return err
+	}
+
+	manifest := checkpoint.Manifest
+	fmt.Fprintf(io.Out, "Resuming deployment of %s with image %s, last checkpointed at %s (%d machines already updated)\n",
+		manifest.AppName, manifest.DeploymentImage, checkpoint.UpdatedAt.Format(time.RFC3339), len(checkpoint.UpdatedMachines))
+
+	flapsClient := flapsutil.ClientFromContext(ctx)
+	app, err := flapsClient.GetApp(ctx, manifest.AppName)
+	if err != nil {
+		sentry.CaptureException(err)
+
+		return err
+	}
+
+	ctx = appconfig.WithConfig(ctx, manifest.Config)
+
+	args := argsFromManifest(manifest, app)
+	args.Checkpoint = checkpoint
+	args.Resume = true
+
+	md, err := NewMachineDeployment(ctx, args)
+	if err != nil {
+		sentry.CaptureExceptionWithFlapsAppInfo(ctx, err, "deploy", app)
+
+		return err
+	}
+
+	err = md.DeployMachinesApp(ctx)
+	if err != nil {
+		sentry.CaptureExceptionWithFlapsAppInfo(ctx, err, "deploy", app)
+
+		return err
+	}
+
+	return nil
+}
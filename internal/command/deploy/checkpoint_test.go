@@ -0,0 +1,59 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deploys", "my-app.json")
+
+	checkpoint := newDeployCheckpoint(path, &DeployManifest{AppName: "my-app", DeploymentImage: "registry.fly.io/my-app:v2"})
+	require.NoError(t, checkpoint.setRelease("release-id", 7))
+	require.NoError(t, checkpoint.markPreDeployCompleted())
+	require.NoError(t, checkpoint.markReleaseCommandCompleted())
+	require.NoError(t, checkpoint.markUpdated("m1"))
+	require.NoError(t, checkpoint.markUpdated("m2"))
+	require.NoError(t, checkpoint.markUpdated("m1"))
+
+	loaded, err := loadDeployCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, "my-app", loaded.Manifest.AppName)
+	assert.Equal(t, "registry.fly.io/my-app:v2", loaded.Manifest.DeploymentImage)
+	assert.Equal(t, "release-id", loaded.ReleaseID)
+	assert.Equal(t, 7, loaded.ReleaseVersion)
+	assert.True(t, loaded.PreDeployCompleted)
+	assert.True(t, loaded.ReleaseCommandCompleted)
+	assert.Equal(t, []string{"m1", "m2"}, loaded.UpdatedMachines)
+	assert.True(t, loaded.isUpdated("m2"))
+	assert.False(t, loaded.isUpdated("m3"))
+
+	require.NoError(t, loaded.remove())
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoError(t, loaded.remove())
+}
+
+func TestNilDeployCheckpoint(t *testing.T) {
+	var checkpoint *DeployCheckpoint
+
+	assert.NoError(t, checkpoint.setRelease("release-id", 1))
+	assert.NoError(t, checkpoint.markPreDeployCompleted())
+	assert.NoError(t, checkpoint.markUpdated("m1"))
+	assert.False(t, checkpoint.isUpdated("m1"))
+	assert.NoError(t, checkpoint.remove())
+}
+
+func TestCheckResumable(t *testing.T) {
+	for _, strategy := range []string{"rolling", "immediate", "progressive"} {
+		assert.NoError(t, checkResumable(strategy), strategy)
+	}
+
+	for _, strategy := range []string{"bluegreen", "canary"} {
+		assert.ErrorContains(t, checkResumable(strategy), "--resume isn't supported with the "+strategy+" strategy")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -194,6 +195,13 @@ var CommonFlags = flag.Set{
 	},
 	flag.Compression(),
 	flag.CompressionLevel(),
+	flag.Platform(),
+	flag.CacheFrom(),
+	flag.CacheTo(),
+	flag.Bool{
+		Name:        "attest",
+		Description: "Attach an unsigned SBOM and SLSA provenance statement to the image, and record them in the release. Requires the Depot or BuildKit builder",
+	},
 }
 
 type Command struct {
@@ -242,6 +250,38 @@ func New() *Command {
 			Description: "Path to a deploy manifest file to use for deployment.",
 			Hidden:      true,
 		},
+		flag.Bool{
+			Name:        "auto-rollback",
+			Description: "Restore updated Machines to their previous configuration if a rolling or canary deployment fails",
+			Default:     false,
+		},
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Show the changes the deployment would make to the app's Machines without deploying",
+			Default:     false,
+		},
+		flag.JSONOutput(),
+		flag.Bool{
+			Name:        "resume",
+			Description: "Resume the app's last interrupted rolling, immediate or progressive deployment, skipping Machines that were already updated",
+			Default:     false,
+		},
+		flag.String{
+			Name:        "secrets-file",
+			Description: "Set the new or changed secrets of a file of NAME=VALUE pairs before deploying, which may be encrypted with 'fly secrets encrypt'",
+		},
+		flag.String{
+			Name:        "changed-since",
+			Description: "Skip the deployment unless the app, or the packages of its monorepo workspace it depends on, changed since a git ref",
+		},
+		flag.String{
+			Name:        "secrets-identity",
+			Description: "age identity file to decrypt --secrets-file with, instead of $FLY_SECRETS_AGE_KEY or ~/.fly/secrets-identity.txt",
+		},
+		flag.Bool{
+			Name:        "resolve-secrets",
+			Description: "Resolve the values of --secrets-file that reference secret managers or local files, like vault://path#key or file://path",
+		},
 	)
 
 	return cmd
@@ -287,6 +327,18 @@ func (cmd *Command) run(ctx context.Context) (err error) {
 		return err
 	}
 
+	if err := validation.ValidatePlatforms(flag.GetPlatforms(ctx)); err != nil {
+		return err
+	}
+
+	if flag.GetBool(ctx, "resume") {
+		if appName == "" {
+			return errors.New("the app name must be specified to resume a deployment")
+		}
+
+		return resumeDeploy(ctx, appName)
+	}
+
 	var manifestPath = flag.GetString(ctx, "from-manifest")
 
 	switch {
@@ -306,6 +358,18 @@ func (cmd *Command) run(ctx context.Context) (err error) {
 		return deployFromManifest(ctx, manifest)
 	}
 
+	if ref := flag.GetString(ctx, "changed-since"); ref != "" {
+		changed, err := changedSince(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			fmt.Fprintf(io.Out, "Skipping deployment of %s, which hasn't changed since %s\n", appName, ref)
+
+			return nil
+		}
+	}
+
 	appConfig, err := determineAppConfig(ctx)
 	if err != nil {
 		if strings.Contains(err.Error(), "Could not find App") {
@@ -354,6 +418,18 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, userID i
 		ctx = launchdarkly.NewContextWithClient(ctx, ffClient)
 	}
 
+	// Read the secrets file before building, so that a file that can't be
+	// decrypted fails the deployment early.
+	secrets, err := readSecretsFile(ctx)
+	if err != nil {
+		return err
+	}
+
+	composeSecrets, err := readComposeSecrets(appConfig)
+	if err != nil {
+		return err
+	}
+
 	for env := range appConfig.Env {
 		if containsCommonSecretSubstring(env) {
 			warning := fmt.Sprintf("%s %s may be a potentially sensitive environment variable. Consider setting it as a secret, and removing it from the [env] section: https://fly.io/docs/apps/secrets/\n", aurora.Yellow("WARN"), env)
@@ -361,6 +437,16 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, userID i
 		}
 	}
 
+	// A dry run plans the deployment without building or pushing an image.
+	if flag.GetBool(ctx, "dry-run") {
+		img, err := dryRunImage(ctx, appConfig)
+		if err != nil {
+			return err
+		}
+
+		return deployToMachines(ctx, appConfig, app, img)
+	}
+
 	httpFailover := flag.GetHTTPSFailover(ctx)
 	usingWireguard := flag.GetWireguard(ctx)
 	recreateBuilder := flag.GetRecreateBuilder(ctx)
@@ -389,6 +475,13 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, userID i
 		return nil
 	}
 
+	if err := stageSecrets(ctx, appName, secrets, flag.GetString(ctx, "secrets-file")); err != nil {
+		return err
+	}
+	if err := stageSecrets(ctx, appName, composeSecrets, appConfig.ComposeFilePath()); err != nil {
+		return err
+	}
+
 	colorize := io.ColorScheme()
 	fmt.Fprintf(io.Out, "\nWatch your deployment at %s\n\n", colorize.Purple(fmt.Sprintf("https://fly.io/apps/%s/monitoring", appName)))
 	if err := deployToMachines(ctx, appConfig, app, img); err != nil {
@@ -525,19 +618,22 @@ func deployToMachines(
 	var status metrics.DeployStatusPayload
 	status.Operator, status.AgentName = metrics.OperatorFromSignals(clientsignals.DetectOnce())
 
-	metrics.Started(ctx, "deploy")
-	// TODO: remove this once there is nothing upstream using it
-	metrics.Started(ctx, "deploy_machines")
+	// Dry runs don't deploy anything, so they aren't reported as deployments.
+	if !flag.GetBool(ctx, "dry-run") {
+		metrics.Started(ctx, "deploy")
+		// TODO: remove this once there is nothing upstream using it
+		metrics.Started(ctx, "deploy_machines")
 
-	defer func() {
-		if err != nil {
-			status.Error = err.Error()
-		}
-		status.TraceID = span.SpanContext().TraceID().String()
-		status.Duration = time.Since(startTime)
-		metrics.DeployStatus(ctx, status)
-		metrics.Status(ctx, "deploy_machines", err == nil)
-	}()
+		defer func() {
+			if err != nil {
+				status.Error = err.Error()
+			}
+			status.TraceID = span.SpanContext().TraceID().String()
+			status.Duration = time.Since(startTime)
+			metrics.DeployStatus(ctx, status)
+			metrics.Status(ctx, "deploy_machines", err == nil)
+		}()
+	}
 
 	releaseCmdTimeout, err := parseDurationFlag(ctx, "release-command-timeout")
 	if err != nil {
@@ -682,8 +778,11 @@ func deployToMachines(
 		VolumeInitialSize:     flag.GetInt(ctx, "volume-initial-size"),
 		ProcessGroups:         processGroups,
 		DeployRetries:         deployRetries,
+		AutoRollback:          flag.GetBool(ctx, "auto-rollback"),
 		BuildID:               img.BuildID,
 		BuilderID:             img.BuilderID,
+		Attestation:           img.Attestation,
+		ContainerImages:       img.Containers,
 	}
 
 	var path = flag.GetString(ctx, "export-manifest")
@@ -707,6 +806,13 @@ func deployToMachines(
 		return nil
 	}
 
+	if flag.GetBool(ctx, "dry-run") {
+		return planDeployment(ctx, args)
+	}
+
+	// Record the deployment's progress so it can be continued with --resume if it's interrupted.
+	args.Checkpoint = newDeployCheckpoint(checkpointPath(ctx, app.Name), NewManifest(app.Name, cfg, args))
+
 	md, err := NewMachineDeployment(ctx, args)
 	if err != nil {
 		sentry.CaptureExceptionWithFlapsAppInfo(ctx, err, "deploy", app)
@@ -758,6 +864,12 @@ func determineAppConfig(ctx context.Context) (cfg *appconfig.Config, err error)
 		cfg.AppName = appName
 	}
 
+	if err := cfg.AddComposeMounts(); err != nil {
+		tracing.RecordError(span, err, "add compose mounts")
+
+		return nil, err
+	}
+
 	err, extraInfo := cfg.Validate(ctx)
 	if extraInfo != "" {
 		fmt.Fprint(io.Out, extraInfo)
@@ -768,7 +880,7 @@ func determineAppConfig(ctx context.Context) (cfg *appconfig.Config, err error)
 		return nil, err
 	}
 
-	if cfg.Deploy != nil && cfg.Deploy.Strategy != "rolling" && cfg.Deploy.Strategy != "canary" && cfg.Deploy.MaxUnavailable != nil {
+	if cfg.Deploy != nil && !slices.Contains([]string{"rolling", "canary", "progressive"}, cfg.Deploy.Strategy) && cfg.Deploy.MaxUnavailable != nil {
 		if !config.FromContext(ctx).JSONOutput {
 			fmt.Fprintf(io.Out, "Warning: max-unavailable set for non-rolling strategy '%s', ignoring\n", cfg.Deploy.Strategy)
 		}
@@ -179,7 +179,7 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 	// We're building from source
 	opts := imgsrc.ImageOptions{
 		AppName:              appConfig.AppName,
-		WorkingDir:           state.WorkingDirectory(ctx),
+		WorkingDir:           buildContextDir(ctx, appConfig),
 		Publish:              flag.GetBool(ctx, "push") || !flag.GetBuildOnly(ctx),
 		ImageLabel:           flag.GetString(ctx, "image-label"),
 		NoCache:              flag.GetBool(ctx, "no-cache"),
@@ -189,6 +189,9 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 		Buildpacks:           build.Buildpacks,
 		BuildpacksDockerHost: flag.GetString(ctx, flag.BuildpacksDockerHost),
 		BuildpacksVolumes:    flag.GetStringSlice(ctx, flag.BuildpacksVolume),
+		Attest:               flag.GetBool(ctx, "attest"),
+		CacheFrom:            flag.GetCacheFrom(ctx),
+		CacheTo:              flag.GetCacheTo(ctx),
 	}
 
 	if appConfig.Experimental != nil && appConfig.Experimental.LazyLoadImages {
@@ -197,6 +200,7 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 
 	// Determine compression based on CLI flags, then app config, then LaunchDarkly, then default to gzip
 	opts.Compression, opts.CompressionLevel = appConfig.DetermineCompression(ctx)
+	opts.Platforms = appConfig.DeterminePlatforms(ctx)
 
 	// flyctl supports key=value form while Docker supports id=key,src=/path/to/secret form.
 	// https://docs.docker.com/engine/reference/commandline/buildx_build/#secret
@@ -279,6 +283,12 @@ func determineImage(ctx context.Context, app *flaps.App, appConfig *appconfig.Co
 		sendDurationMetrics()
 	}
 
+	if err == nil {
+		if img.Containers, err = buildComposeImages(ctx, resolver, appConfig, opts); err != nil {
+			tracing.RecordError(span, err, "failed to build compose services")
+		}
+	}
+
 	if err == nil {
 		tb.Printf("image: %s\n", img.Tag)
 		tb.Printf("image size: %s\n", humanize.Bytes(uint64(img.Size)))
@@ -329,6 +339,16 @@ func resolveIgnorefilePath(ctx context.Context, appConfig *appconfig.Config) (pa
 	return
 }
 
+// buildContextDir returns the directory the image is built from: the [build]
+// context of the app config, or the working directory.
+func buildContextDir(ctx context.Context, appConfig *appconfig.Config) string {
+	if dir := appConfig.BuildContext(); dir != "" {
+		return dir
+	}
+
+	return state.WorkingDirectory(ctx)
+}
+
 func mergeBuildArgs(ctx context.Context, args map[string]string) (map[string]string, error) {
 	if args == nil {
 		args = make(map[string]string)
@@ -345,6 +365,23 @@ func mergeBuildArgs(ctx context.Context, args map[string]string) (map[string]str
 	return args, nil
 }
 
+// dryRunImage returns the image a dry run plans the deployment with: the image
+// the deployment would use as-is, without resolving it, or a placeholder for
+// the image that would be built from source, since dry runs neither build nor
+// push images.
+func dryRunImage(ctx context.Context, appConfig *appconfig.Config) (*imgsrc.DeploymentImage, error) {
+	ref, err := fetchImageRef(ctx, appConfig)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = fmt.Sprintf("registry.fly.io/%s:dry-run", appConfig.AppName)
+		fmt.Fprintf(iostreams.FromContext(ctx).ErrOut, "Planning with %s in place of the image that would be built from source\n", ref)
+	}
+
+	return &imgsrc.DeploymentImage{ID: ref, Tag: ref}, nil
+}
+
 func fetchImageRef(ctx context.Context, cfg *appconfig.Config) (ref string, err error) {
 	if ref = flag.GetString(ctx, "image"); ref != "" {
 		return
@@ -6,10 +6,13 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flag/flagctx"
 	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
 )
 
 func TestMultipleDockerfile(t *testing.T) {
@@ -97,3 +100,21 @@ func TestResolveDockerfilePath(t *testing.T) {
 		})
 	}
 }
+
+func TestDryRunImage(t *testing.T) {
+	ios, _, _, stderr := iostreams.Test()
+	flags := pflag.NewFlagSet("deploy", pflag.ContinueOnError)
+	flags.String("image", "", "")
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flagctx.NewContext(ctx, flags)
+
+	img, err := dryRunImage(ctx, &appconfig.Config{AppName: "my-app", Build: &appconfig.Build{Image: "nginx:1.27"}})
+	require.NoError(t, err)
+	assert.Equal(t, "nginx:1.27", img.Tag)
+
+	// Images built from source are neither built nor pushed.
+	img, err = dryRunImage(ctx, &appconfig.Config{AppName: "my-app", Build: &appconfig.Build{Dockerfile: "Dockerfile"}})
+	require.NoError(t, err)
+	assert.Equal(t, "registry.fly.io/my-app:dry-run", img.Tag)
+	assert.Contains(t, stderr.String(), "in place of the image that would be built from source")
+}
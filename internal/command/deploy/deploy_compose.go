@@ -0,0 +1,117 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/containerconfig"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// readComposeSecrets reads the secrets of the compose file the app is deployed
+// from. It returns no secrets when there's no compose file.
+func readComposeSecrets(appConfig *appconfig.Config) (map[string]string, error) {
+	path := appConfig.ComposeFilePath()
+	if path == "" {
+		return nil, nil
+	}
+
+	secrets, err := containerconfig.ComposeSecrets(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the secrets of %s: %w", path, err)
+	}
+
+	return secrets, nil
+}
+
+// buildComposeImages builds the images of the services of the compose file the
+// app is deployed from, with the builder and options of the app's image. It
+// returns the references of the images by container name, see composeImageRef.
+func buildComposeImages(ctx context.Context, resolver *imgsrc.Resolver, appConfig *appconfig.Config, opts imgsrc.ImageOptions) (map[string]string, error) {
+	path := appConfig.ComposeFilePath()
+	if path == "" {
+		return nil, nil
+	}
+
+	builds, err := containerconfig.ComposeBuilds(path)
+	if err != nil {
+		return nil, err
+	}
+
+	io := iostreams.FromContext(ctx)
+	images := map[string]string{}
+	for _, build := range separateComposeBuilds(builds, opts.WorkingDir) {
+		fmt.Fprintf(io.Out, "==> Building image of service %s\n", build.Service)
+
+		img, err := resolver.BuildImage(ctx, io, composeImageOptions(opts, build))
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("failed to build service %s: %w", build.Service, err)
+		case img == nil:
+			return nil, fmt.Errorf("no image built for service %s", build.Service)
+		}
+
+		images[build.Service] = composeImageRef(img)
+	}
+
+	return images, nil
+}
+
+// composeImageRef returns the reference of img, pinned to its digest so that
+// the machines of a release all run the same image. BuildKit and Depot don't
+// set the digest of the images they build, but their ID is the digest.
+func composeImageRef(img *imgsrc.DeploymentImage) string {
+	switch {
+	case img.Digest != "":
+		return img.String()
+	case strings.HasPrefix(img.ID, "sha256:"):
+		return img.Tag + "@" + img.ID
+	default:
+		return img.Tag
+	}
+}
+
+// separateComposeBuilds returns the builds that differ from the build of the
+// app in workingDir. The other services run the app's image.
+func separateComposeBuilds(builds []containerconfig.ComposeBuild, workingDir string) []containerconfig.ComposeBuild {
+	var separate []containerconfig.ComposeBuild
+	for _, build := range builds {
+		sameContext := filepath.Clean(build.Context) == filepath.Clean(workingDir)
+		if sameContext && build.Dockerfile == "" && build.Target == "" && len(build.Args) == 0 {
+			continue
+		}
+
+		separate = append(separate, build)
+	}
+
+	return separate
+}
+
+// composeImageOptions returns the options of the image of build, derived from
+// the options of the app's image.
+func composeImageOptions(opts imgsrc.ImageOptions, build containerconfig.ComposeBuild) imgsrc.ImageOptions {
+	opts.WorkingDir = build.Context
+	opts.DockerfilePath = build.Dockerfile
+	opts.IgnorefilePath = ""
+	opts.Target = build.Target
+	opts.BuildArgs = build.Args
+
+	// Services are built from their Dockerfile
+	opts.BuiltIn = ""
+	opts.BuiltInSettings = nil
+	opts.Builder = ""
+	opts.Buildpacks = nil
+
+	// Services exporting to the cache of the app would overwrite it
+	opts.CacheTo = nil
+
+	if opts.ImageLabel != "" {
+		opts.ImageLabel += "-" + build.Service
+	}
+
+	return opts
+}
@@ -0,0 +1,75 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/containerconfig"
+)
+
+func TestSeparateComposeBuilds(t *testing.T) {
+	builds := []containerconfig.ComposeBuild{
+		{Service: "api", Context: "/app/api"},
+		{Service: "web", Context: "/app/"},
+		{Service: "worker", Context: "/app", Target: "worker"},
+	}
+
+	separate := separateComposeBuilds(builds, "/app")
+	assert.Equal(t, []containerconfig.ComposeBuild{builds[0], builds[2]}, separate)
+}
+
+func TestComposeImageOptions(t *testing.T) {
+	opts := imgsrc.ImageOptions{
+		AppName:        "my-app",
+		WorkingDir:     "/app",
+		DockerfilePath: "/app/Dockerfile",
+		IgnorefilePath: "/app/.dockerignore",
+		ImageLabel:     "v1",
+		Builder:        "paketobuildpacks/builder:base",
+		BuildArgs:      map[string]string{"APP": "true"},
+		CacheFrom:      []string{"registry.fly.io/my-app:cache"},
+		CacheTo:        []string{"registry.fly.io/my-app:cache"},
+		Publish:        true,
+	}
+
+	serviceOpts := composeImageOptions(opts, containerconfig.ComposeBuild{
+		Service:    "api",
+		Context:    "/app/api",
+		Dockerfile: "/app/api/Dockerfile.prod",
+		Target:     "release",
+		Args:       map[string]string{"VERSION": "1.0"},
+	})
+
+	assert.Equal(t, "my-app", serviceOpts.AppName)
+	assert.True(t, serviceOpts.Publish)
+	assert.Equal(t, "/app/api", serviceOpts.WorkingDir)
+	assert.Equal(t, "/app/api/Dockerfile.prod", serviceOpts.DockerfilePath)
+	assert.Empty(t, serviceOpts.IgnorefilePath)
+	assert.Empty(t, serviceOpts.Builder)
+	assert.Equal(t, "release", serviceOpts.Target)
+	assert.Equal(t, map[string]string{"VERSION": "1.0"}, serviceOpts.BuildArgs)
+	assert.Equal(t, "v1-api", serviceOpts.ImageLabel)
+	assert.Equal(t, opts.CacheFrom, serviceOpts.CacheFrom)
+	assert.Empty(t, serviceOpts.CacheTo)
+}
+
+func TestComposeImageRef(t *testing.T) {
+	const digest = "sha256:f107dbfaa732063b31ee94aa728c4f5648a672259fd62bfaa245f9b7a53b5479"
+
+	assert.Equal(t, "registry.fly.io/my-app:api@"+digest, composeImageRef(&imgsrc.DeploymentImage{
+		Tag:    "registry.fly.io/my-app:api",
+		Digest: digest,
+	}))
+
+	// BuildKit and Depot report the digest as the ID of the image
+	assert.Equal(t, "registry.fly.io/my-app:api@"+digest, composeImageRef(&imgsrc.DeploymentImage{
+		ID:  digest,
+		Tag: "registry.fly.io/my-app:api",
+	}))
+
+	assert.Equal(t, "registry.fly.io/my-app:api", composeImageRef(&imgsrc.DeploymentImage{
+		ID:  "f107dbfaa732",
+		Tag: "registry.fly.io/my-app:api",
+	}))
+}
@@ -0,0 +1,457 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/sentry"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+type MachinePlanAction string
+
+const (
+	MachinePlanCreate    MachinePlanAction = "create"
+	MachinePlanUpdate    MachinePlanAction = "update"
+	MachinePlanReplace   MachinePlanAction = "replace"
+	MachinePlanDestroy   MachinePlanAction = "destroy"
+	MachinePlanUnchanged MachinePlanAction = "unchanged"
+)
+
+// DeployPlan describes what a deployment would do to an app's machines.
+type DeployPlan struct {
+	App            string             `json:"app"`
+	Image          string             `json:"image"`
+	Strategy       string             `json:"strategy"`
+	ReleaseCommand string             `json:"release_command,omitempty"`
+	ProcessGroups  []ProcessGroupPlan `json:"process_groups"`
+}
+
+type ProcessGroupPlan struct {
+	Name     string        `json:"name"`
+	Machines []MachinePlan `json:"machines"`
+}
+
+type MachinePlan struct {
+	// ID is empty for machines that would be created.
+	ID      string             `json:"id,omitempty"`
+	Region  string             `json:"region"`
+	Action  MachinePlanAction  `json:"action"`
+	Reason  string             `json:"reason,omitempty"`
+	Volumes []VolumeAttachment `json:"volumes,omitempty"`
+	Changes []ConfigChange     `json:"changes,omitempty"`
+}
+
+type VolumeAttachment struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// VolumeID is empty when a new volume would be created.
+	VolumeID string `json:"volume_id,omitempty"`
+}
+
+// ConfigChange is a single field that differs between a machine's current and new configs.
+type ConfigChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+// Count returns how many machines the plan would apply action to.
+func (p *DeployPlan) Count(action MachinePlanAction) (n int) {
+	for _, group := range p.ProcessGroups {
+		for _, m := range group.Machines {
+			if m.Action == action {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// planDeployment prints what deploying with args would change, without
+// acquiring leases, creating a release or modifying any machine.
+func planDeployment(ctx context.Context, args MachineDeploymentArgs) error {
+	io := iostreams.FromContext(ctx)
+
+	args.DryRun = true
+	md, err := newMachineDeployment(ctx, args)
+	if err != nil {
+		sentry.CaptureExceptionWithFlapsAppInfo(ctx, err, "deploy", args.App)
+
+		return err
+	}
+
+	plan, err := md.plan(ctx)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, plan)
+	}
+
+	RenderDeployPlan(io.Out, io.ColorScheme(), plan)
+
+	return nil
+}
+
+// PlanManifest computes what deploying manifest would change, without
+// modifying the app. It's used to show what redeploying a previous release
+// would do before going ahead.
+func PlanManifest(ctx context.Context, manifest *DeployManifest) (*DeployPlan, error) {
+	app, err := flapsutil.ClientFromContext(ctx).GetApp(ctx, manifest.AppName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = appconfig.WithConfig(ctx, manifest.Config)
+
+	args := argsFromManifest(manifest, app)
+	args.DryRun = true
+	md, err := newMachineDeployment(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return md.plan(ctx)
+}
+
+// plan computes the changes deployMachinesApp would make, following the same
+// steps: remove machines from dropped process groups, create machines for new
+// ones and update the rest.
+func (md *machineDeployment) plan(ctx context.Context) (*DeployPlan, error) {
+	plan := &DeployPlan{
+		App:      md.app.Name,
+		Image:    md.img,
+		Strategy: md.strategy,
+	}
+	if !md.skipReleaseCommand && md.appConfig.Deploy != nil {
+		plan.ReleaseCommand = md.appConfig.Deploy.ReleaseCommand
+	}
+
+	groups := map[string]*ProcessGroupPlan{}
+	addMachine := func(group string, mp MachinePlan) {
+		if groups[group] == nil {
+			groups[group] = &ProcessGroupPlan{Name: group}
+		}
+		groups[group].Machines = append(groups[group].Machines, mp)
+	}
+
+	diff := md.resolveProcessGroupChanges()
+	removed := map[string]bool{}
+	for _, lm := range diff.machinesToRemove {
+		m := lm.Machine()
+		removed[m.ID] = true
+		addMachine(m.ProcessGroup(), MachinePlan{
+			ID:      m.ID,
+			Region:  m.Region,
+			Action:  MachinePlanDestroy,
+			Reason:  "process group was removed from the app config",
+			Volumes: volumeAttachments(m.Config),
+		})
+	}
+
+	if !md.updateOnly {
+		for _, name := range slices.Sorted(maps.Keys(diff.groupsNeedingMachines)) {
+			plans, err := md.planMachinesForNewGroup(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, mp := range plans {
+				addMachine(name, mp)
+			}
+		}
+	}
+
+	for _, lm := range md.machineSet.GetMachines() {
+		m := lm.Machine()
+		if removed[m.ID] {
+			continue
+		}
+
+		li, err := md.launchInputForUpdate(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update machine configuration for %s: %w", lm.FormattedMachineId(), err)
+		}
+
+		mp := MachinePlan{
+			ID:      m.ID,
+			Region:  m.Region,
+			Volumes: volumeAttachments(li.Config),
+			Changes: diffMachineConfigs(m.Config, li.Config),
+		}
+		switch {
+		case li.RequiresReplacement:
+			mp.Action = MachinePlanReplace
+			mp.Reason = "the machine can't be updated in place"
+		case len(mp.Changes) > 0:
+			mp.Action = MachinePlanUpdate
+		default:
+			mp.Action = MachinePlanUnchanged
+		}
+		addMachine(li.Config.ProcessGroup(), mp)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		plan.ProcessGroups = append(plan.ProcessGroups, *groups[name])
+	}
+
+	return plan, nil
+}
+
+// planMachinesForNewGroup mirrors the machines deployCreateMachinesForGroups
+// launches for a process group without machines.
+func (md *machineDeployment) planMachinesForNewGroup(name string) ([]MachinePlan, error) {
+	groupConfig, err := md.appConfig.Flatten(name)
+	if err != nil {
+		return nil, err
+	}
+
+	reasons := []string{"new process group"}
+	switch {
+	case !md.increasedAvailability || len(groupConfig.Mounts) > 0:
+	case len(groupConfig.AllServices()) > 0:
+		reasons = append(reasons, "second machine for high availability")
+	default:
+		reasons = append(reasons, "standby machine")
+	}
+
+	region := md.appConfig.PrimaryRegion
+	plans := make([]MachinePlan, 0, len(reasons))
+	for _, reason := range reasons {
+		mConfig, err := md.appConfig.ToMachineConfig(name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		volumes := volumeAttachments(mConfig)
+		for i := range volumes {
+			if vol := md.popVolumeFor(volumes[i].Name, region); vol != nil {
+				volumes[i].VolumeID = vol.ID
+			}
+		}
+
+		plans = append(plans, MachinePlan{
+			Region:  region,
+			Action:  MachinePlanCreate,
+			Reason:  reason,
+			Volumes: volumes,
+		})
+	}
+
+	return plans, nil
+}
+
+func volumeAttachments(mConfig *fly.MachineConfig) []VolumeAttachment {
+	if mConfig == nil {
+		return nil
+	}
+
+	var volumes []VolumeAttachment
+	for _, m := range mConfig.Mounts {
+		volumes = append(volumes, VolumeAttachment{
+			Name:     m.Name,
+			Path:     m.Path,
+			VolumeID: m.Volume,
+		})
+	}
+
+	return volumes
+}
+
+// releaseMetadataKeys change on every deployment, so they are left out of plans.
+var releaseMetadataKeys = []string{
+	fly.MachineConfigMetadataKeyFlyReleaseId,
+	fly.MachineConfigMetadataKeyFlyReleaseVersion,
+}
+
+// diffMachineConfigs returns the fields that differ between two machine
+// configs, using the same comparison as compareConfigs.
+func diffMachineConfigs(oldConfig, newConfig *fly.MachineConfig) []ConfigChange {
+	var reporter configDiffReporter
+
+	cmp.Equal(oldConfig, newConfig,
+		machineConfigCmpOptions(),
+		cmp.FilterPath(func(p cmp.Path) bool {
+			mi, ok := p.Last().(cmp.MapIndex)
+
+			return ok && slices.Contains(releaseMetadataKeys, mi.Key().String())
+		}, cmp.Ignore()),
+		cmp.Reporter(&reporter),
+	)
+
+	return reporter.changes
+}
+
+// configDiffReporter is a cmp.Reporter collecting every differing leaf.
+type configDiffReporter struct {
+	path    cmp.Path
+	changes []ConfigChange
+}
+
+func (r *configDiffReporter) PushStep(ps cmp.PathStep) {
+	r.path = append(r.path, ps)
+}
+
+func (r *configDiffReporter) PopStep() {
+	r.path = r.path[:len(r.path)-1]
+}
+
+func (r *configDiffReporter) Report(rs cmp.Result) {
+	if rs.Equal() {
+		return
+	}
+
+	vx, vy := r.path.Last().Values()
+	r.changes = append(r.changes, ConfigChange{
+		Field: configFieldPath(r.path),
+		Old:   reportedValue(vx),
+		New:   reportedValue(vy),
+	})
+}
+
+// configFieldPath formats path using the JSON names of the machine config
+// fields, e.g. services[0].ports[1].handlers.
+func configFieldPath(path cmp.Path) string {
+	var sb strings.Builder
+	for i, step := range path {
+		switch s := step.(type) {
+		case cmp.StructField:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(jsonFieldName(path[i-1].Type(), s.Index(), s.Name()))
+		case cmp.SliceIndex:
+			ix, iy := s.SplitKeys()
+			if ix < 0 {
+				ix = iy
+			}
+			fmt.Fprintf(&sb, "[%d]", ix)
+		case cmp.MapIndex:
+			fmt.Fprintf(&sb, "[%v]", s.Key())
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "config"
+	}
+
+	return sb.String()
+}
+
+func jsonFieldName(t reflect.Type, idx int, name string) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || idx >= t.NumField() {
+		return name
+	}
+
+	tag, _, _ := strings.Cut(t.Field(idx).Tag.Get("json"), ",")
+	if tag == "" || tag == "-" {
+		return name
+	}
+
+	return tag
+}
+
+func reportedValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	if !v.CanInterface() {
+		return fmt.Sprint(v)
+	}
+
+	return v.Interface()
+}
+
+// RenderDeployPlan prints plan grouped by process group, with the config changes of
+// every machine that would be updated.
+func RenderDeployPlan(w io.Writer, colorize *iostreams.ColorScheme, plan *DeployPlan) {
+	fmt.Fprintf(w, "Deployment plan for %s using the %s strategy\n", colorize.Bold(plan.App), plan.Strategy)
+	fmt.Fprintf(w, "Image: %s\n", plan.Image)
+	if plan.ReleaseCommand != "" {
+		fmt.Fprintf(w, "Release command: %s\n", plan.ReleaseCommand)
+	}
+
+	for _, group := range plan.ProcessGroups {
+		fmt.Fprintf(w, "\nProcess group %s:\n", colorize.Bold(group.Name))
+
+		for _, m := range group.Machines {
+			var symbol, subject string
+			switch m.Action {
+			case MachinePlanCreate:
+				symbol, subject = colorize.Green("+"), "new machine"
+			case MachinePlanUpdate:
+				symbol, subject = colorize.Yellow("~"), m.ID
+			case MachinePlanReplace:
+				symbol, subject = colorize.Yellow("±"), m.ID
+			case MachinePlanDestroy:
+				symbol, subject = colorize.Red("-"), m.ID
+			default:
+				symbol, subject = " ", m.ID
+			}
+
+			line := fmt.Sprintf("  %s %s %s in %s", symbol, m.Action, colorize.Bold(subject), m.Region)
+			if m.Reason != "" {
+				line += fmt.Sprintf(" (%s)", m.Reason)
+			}
+			fmt.Fprintln(w, line)
+
+			for _, v := range m.Volumes {
+				volume := v.VolumeID
+				if volume == "" {
+					volume = "new volume"
+				}
+				fmt.Fprintf(w, "      volume %s (%s) mounted at %s\n", v.Name, volume, v.Path)
+			}
+			for _, c := range m.Changes {
+				fmt.Fprintf(w, "      %s: %s → %s\n", c.Field, colorize.Red(formatPlanValue(c.Old)), colorize.Green(formatPlanValue(c.New)))
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to replace, %d to destroy, %d unchanged.\n",
+		plan.Count(MachinePlanCreate),
+		plan.Count(MachinePlanUpdate),
+		plan.Count(MachinePlanReplace),
+		plan.Count(MachinePlanDestroy),
+		plan.Count(MachinePlanUnchanged),
+	)
+	fmt.Fprintln(w, "This was a dry run, no changes were made.")
+}
+
+func formatPlanValue(v any) string {
+	if v == nil {
+		return "<none>"
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
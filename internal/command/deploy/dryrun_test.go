@@ -0,0 +1,73 @@
+package deploy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestDiffMachineConfigs(t *testing.T) {
+	oldConfig := &fly.MachineConfig{
+		Image: "registry.fly.io/my-app:v1",
+		Env:   map[string]string{"LOG_LEVEL": "info", "REMOVED": "yes"},
+		Metadata: map[string]string{
+			fly.MachineConfigMetadataKeyFlyReleaseId:      "old-release",
+			fly.MachineConfigMetadataKeyFlyReleaseVersion: "1",
+			fly.MachineConfigMetadataKeyFlyctlVersion:     "0.1.0",
+		},
+	}
+	newConfig := &fly.MachineConfig{
+		Image: "registry.fly.io/my-app:v2",
+		Env:   map[string]string{"LOG_LEVEL": "debug"},
+		Metadata: map[string]string{
+			fly.MachineConfigMetadataKeyFlyReleaseId:      "new-release",
+			fly.MachineConfigMetadataKeyFlyReleaseVersion: "2",
+			fly.MachineConfigMetadataKeyFlyctlVersion:     "0.2.0",
+		},
+	}
+
+	assert.ElementsMatch(t, []ConfigChange{
+		{Field: "image", Old: "registry.fly.io/my-app:v1", New: "registry.fly.io/my-app:v2"},
+		{Field: "env[LOG_LEVEL]", Old: "info", New: "debug"},
+		{Field: "env[REMOVED]", Old: "yes"},
+	}, diffMachineConfigs(oldConfig, newConfig))
+
+	assert.Empty(t, diffMachineConfigs(oldConfig, oldConfig))
+}
+
+func TestRenderDeployPlan(t *testing.T) {
+	ios, _, _, _ := iostreams.Test()
+	plan := &DeployPlan{
+		App:      "my-app",
+		Image:    "registry.fly.io/my-app:v2",
+		Strategy: "rolling",
+		ProcessGroups: []ProcessGroupPlan{
+			{
+				Name: "app",
+				Machines: []MachinePlan{
+					{ID: "m1", Region: "ord", Action: MachinePlanUpdate, Changes: []ConfigChange{{Field: "image", Old: "v1", New: "v2"}}},
+					{ID: "m2", Region: "ord", Action: MachinePlanUnchanged},
+				},
+			},
+			{
+				Name: "worker",
+				Machines: []MachinePlan{
+					{Region: "ord", Action: MachinePlanCreate, Reason: "new process group", Volumes: []VolumeAttachment{{Name: "data", Path: "/data"}}},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	RenderDeployPlan(&buf, ios.ColorScheme(), plan)
+	out := buf.String()
+
+	assert.Contains(t, out, "update m1 in ord")
+	assert.Contains(t, out, `image: "v1" → "v2"`)
+	assert.Contains(t, out, "create new machine in ord (new process group)")
+	assert.Contains(t, out, "volume data (new volume) mounted at /data")
+	assert.Contains(t, out, "Plan: 1 to create, 1 to update, 0 to replace, 0 to destroy, 1 unchanged.")
+}
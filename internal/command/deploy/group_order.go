@@ -0,0 +1,112 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// groupOrder holds back the updates of process groups until the groups they
+// depend on are updated. The process groups of split compose files depend on
+// the groups of the services their services depend on. Other groups are
+// updated right away.
+type groupOrder struct {
+	// waves are the process groups in the order they're updated in. The groups
+	// of a wave wait for the groups of the waves before it.
+	waves [][]string
+	done  map[string]chan struct{}
+
+	mu     sync.Mutex
+	failed map[string]bool
+}
+
+// newGroupOrder returns the order of the updates of groups.
+func (md *machineDeployment) newGroupOrder(groups []string) (*groupOrder, error) {
+	var composeWaves [][]string
+	if md.appConfig != nil {
+		waves, err := md.appConfig.ComposeDeployWaves()
+		if err != nil {
+			return nil, err
+		}
+		composeWaves = waves
+	}
+
+	return newGroupOrder(groups, composeWaves), nil
+}
+
+func newGroupOrder(groups []string, composeWaves [][]string) *groupOrder {
+	o := &groupOrder{
+		done:   make(map[string]chan struct{}, len(groups)),
+		failed: map[string]bool{},
+	}
+
+	remaining := map[string]bool{}
+	for _, group := range groups {
+		remaining[group] = true
+		o.done[group] = make(chan struct{})
+	}
+
+	for _, wave := range composeWaves {
+		var ordered []string
+		for _, group := range wave {
+			if remaining[group] {
+				ordered = append(ordered, group)
+				delete(remaining, group)
+			}
+		}
+		if len(ordered) > 0 {
+			o.waves = append(o.waves, ordered)
+		}
+	}
+
+	if len(remaining) > 0 {
+		o.waves = append([][]string{slices.Sorted(maps.Keys(remaining))}, o.waves...)
+	}
+
+	return o
+}
+
+// groups returns the groups in the order they're updated in, which is the order
+// their updates have to be started in for none of them to wait on a group that
+// isn't started.
+func (o *groupOrder) groups() []string {
+	return slices.Concat(o.waves...)
+}
+
+// wait waits for the groups group depends on to be updated. It fails when one
+// of them fails to update.
+func (o *groupOrder) wait(ctx context.Context, group string) error {
+	for _, wave := range o.waves {
+		if slices.Contains(wave, group) {
+			return nil
+		}
+
+		for _, dep := range wave {
+			select {
+			case <-o.done[dep]:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			o.mu.Lock()
+			failed := o.failed[dep]
+			o.mu.Unlock()
+			if failed {
+				return fmt.Errorf("not updating process group %s, as process group %s it depends on failed to update", group, dep)
+			}
+		}
+	}
+
+	return nil
+}
+
+// finish records the update of group, failed when err is set.
+func (o *groupOrder) finish(group string, err error) {
+	o.mu.Lock()
+	o.failed[group] = err != nil
+	o.mu.Unlock()
+
+	close(o.done[group])
+}
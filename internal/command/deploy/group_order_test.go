@@ -0,0 +1,47 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupOrder(t *testing.T) {
+	order := newGroupOrder(
+		[]string{"web", "worker", "db", "app"},
+		[][]string{{"db", "cache"}, {"web", "worker"}},
+	)
+
+	// Groups outside of the compose file don't wait, and groups without
+	// machines to update are skipped.
+	assert.Equal(t, [][]string{{"app"}, {"db"}, {"web", "worker"}}, order.waves)
+	assert.Equal(t, []string{"app", "db", "web", "worker"}, order.groups())
+
+	ctx := context.Background()
+	require.NoError(t, order.wait(ctx, "app"))
+	require.NoError(t, order.wait(ctx, "db"))
+
+	waited := make(chan error)
+	go func() { waited <- order.wait(ctx, "web") }()
+
+	order.finish("app", nil)
+	select {
+	case <-waited:
+		t.Fatal("web didn't wait for db")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	order.finish("db", nil)
+	require.NoError(t, <-waited)
+}
+
+func TestGroupOrderFailedDependency(t *testing.T) {
+	order := newGroupOrder([]string{"db", "web"}, [][]string{{"db"}, {"web"}})
+
+	order.finish("db", errors.New("boom"))
+	assert.ErrorContains(t, order.wait(context.Background(), "web"), "process group db it depends on failed")
+}
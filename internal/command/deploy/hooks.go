@@ -0,0 +1,207 @@
+package deploy
+
+import (
+	"bufio"
+	"container/ring"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/statuslogger"
+	"github.com/superfly/flyctl/internal/tracing"
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	hookPreDeploy  = "pre_deploy"
+	hookPostDeploy = "post_deploy"
+	hookOnRollback = "on_rollback"
+)
+
+func (md *machineDeployment) deployHook(name string) *appconfig.DeployHook {
+	if md.appConfig.Deploy == nil {
+		return nil
+	}
+
+	switch name {
+	case hookPreDeploy:
+		return md.appConfig.Deploy.PreDeploy
+	case hookPostDeploy:
+		return md.appConfig.Deploy.PostDeploy
+	case hookOnRollback:
+		return md.appConfig.Deploy.OnRollback
+	default:
+		return nil
+	}
+}
+
+// runDeployHook runs the named [deploy] hook, if configured, either locally or
+// in an ephemeral machine started from the deployment's image.
+func (md *machineDeployment) runDeployHook(ctx context.Context, name string) error {
+	hook := md.deployHook(name)
+	if hook == nil || hook.Command == "" {
+		return nil
+	}
+
+	if hook.Local {
+		return md.runLocalHook(ctx, name, hook)
+	}
+
+	return md.runRemoteHook(ctx, name, hook)
+}
+
+// runRollbackHook runs the on_rollback hook. The deployment has already failed
+// at that point, so a failing hook is only reported.
+func (md *machineDeployment) runRollbackHook(ctx context.Context) {
+	if err := md.runDeployHook(ctx, hookOnRollback); err != nil {
+		terminal.Warnf("on_rollback hook failed: %v\n", err)
+	}
+}
+
+// runRemoteHook runs the hook like the release command, which the seed command
+// also reuses, in a machine that is destroyed once the command exits.
+func (md *machineDeployment) runRemoteHook(ctx context.Context, name string, hook *appconfig.DeployHook) error {
+	releaseCommand, releaseCmdTimeout := md.appConfig.Deploy.ReleaseCommand, md.releaseCmdTimeout
+	defer func() {
+		md.appConfig.Deploy.ReleaseCommand = releaseCommand
+		md.releaseCmdTimeout = releaseCmdTimeout
+	}()
+
+	md.appConfig.Deploy.ReleaseCommand = hook.Command
+	if hook.Timeout != nil {
+		md.releaseCmdTimeout = hook.Timeout.Duration
+	}
+
+	return md.runReleaseCommand(ctx, name)
+}
+
+func (md *machineDeployment) runLocalHook(ctx context.Context, name string, hook *appconfig.DeployHook) (err error) {
+	ctx, span := tracing.GetTracer().Start(ctx, "run_"+name+"_hook")
+	defer func() {
+		if err != nil {
+			tracing.RecordError(span, err, "failed to run "+name+" hook")
+		}
+		span.End()
+	}()
+
+	fmt.Fprintf(md.io.ErrOut, "Running %s %s hook locally: %s\n",
+		md.colorize.Bold(md.app.Name),
+		name,
+		hook.Command,
+	)
+	ctx, loggerCleanup := statuslogger.SingleLine(ctx, true)
+	defer func() {
+		if err != nil {
+			statuslogger.Failed(ctx, err)
+		}
+		loggerCleanup(false)
+	}()
+
+	if hook.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout.Duration)
+		defer cancel()
+	}
+
+	cmd := localHookCommand(ctx, hook.Command)
+	if path := md.appConfig.ConfigFilePath(); path != "" {
+		cmd.Dir = filepath.Dir(path)
+	}
+	cmd.Env = append(os.Environ(),
+		"FLY_APP_NAME="+md.app.Name,
+		"FLY_IMAGE_REF="+md.img,
+		"FLY_RELEASE_ID="+md.releaseId,
+		"FLY_RELEASE_VERSION="+strconv.Itoa(md.releaseVersion),
+		"FLY_DEPLOY_HOOK="+name,
+	)
+
+	// Don't wait forever on background processes the hook left holding its output.
+	cmd.WaitDelay = 5 * time.Second
+
+	output := newHookOutput(ctx)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err = cmd.Run()
+	output.Close()
+	if err != nil {
+		statuslogger.LogStatus(ctx, statuslogger.StatusFailure, name+" hook failed")
+
+		// Preemptive cleanup of the logger so that the output has a clean place to write to
+		loggerCleanup(false)
+
+		fmt.Fprintf(md.io.ErrOut, "Error %s hook failed: %v. Last %d lines of output:\n", name, err, hookOutputLines)
+		output.lines.Do(func(line any) {
+			if line != nil {
+				fmt.Fprintln(md.io.ErrOut, line)
+			}
+		})
+
+		return fmt.Errorf("%s hook %q failed: %w", name, hook.Command, err)
+	}
+
+	statuslogger.LogfStatus(ctx, statuslogger.StatusSuccess, "%s hook completed successfully", name)
+
+	return nil
+}
+
+func localHookCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// hookOutputLines is how many lines of a failed local hook's output are printed.
+const hookOutputLines = 100
+
+// hookOutput shows each line a local hook writes on its status line, and keeps
+// the last ones around to be printed if the hook fails.
+type hookOutput struct {
+	*io.PipeWriter
+
+	lines *ring.Ring
+	done  sync.WaitGroup
+}
+
+func newHookOutput(ctx context.Context) *hookOutput {
+	r, w := io.Pipe()
+	out := &hookOutput{
+		PipeWriter: w,
+		lines:      ring.New(hookOutputLines),
+	}
+
+	out.done.Add(1)
+	go func() {
+		defer out.done.Done()
+
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := scanner.Text()
+			statuslogger.Log(ctx, line)
+			out.lines.Value = line
+			out.lines = out.lines.Next()
+		}
+		// Keep draining so the hook never blocks on a line too long to scan.
+		io.Copy(io.Discard, r)
+	}()
+
+	return out
+}
+
+// Close waits for all the output to be processed.
+func (o *hookOutput) Close() error {
+	err := o.PipeWriter.Close()
+	o.done.Wait()
+
+	return err
+}
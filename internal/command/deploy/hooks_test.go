@@ -0,0 +1,75 @@
+//go:build !windows
+
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestRunLocalDeployHooks(t *testing.T) {
+	ios, _, stdout, stderr := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	md := &machineDeployment{
+		io:       ios,
+		colorize: ios.ColorScheme(),
+		app:      &flaps.App{Name: "my-app"},
+		img:      "registry.fly.io/my-app:v2",
+		appConfig: &appconfig.Config{
+			Deploy: &appconfig.Deploy{
+				PreDeploy:  &appconfig.DeployHook{Command: `echo "pre $FLY_DEPLOY_HOOK $FLY_IMAGE_REF"`, Local: true},
+				PostDeploy: &appconfig.DeployHook{Command: "echo something went wrong; exit 3", Local: true},
+			},
+		},
+	}
+
+	require.NoError(t, md.runDeployHook(ctx, hookPreDeploy))
+	assert.Contains(t, stdout.String()+stderr.String(), "pre pre_deploy registry.fly.io/my-app:v2")
+
+	err := md.runDeployHook(ctx, hookPostDeploy)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 3")
+	assert.Contains(t, stderr.String(), "something went wrong")
+
+	// Hooks that aren't configured are a no-op.
+	require.NoError(t, md.runDeployHook(ctx, hookOnRollback))
+}
+
+func TestDeployMachinesAppRunsPostDeployHook(t *testing.T) {
+	for _, strategy := range []string{"rolling", "bluegreen", "progressive", "immediate"} {
+		t.Run(strategy, func(t *testing.T) {
+			ios, _, stdout, stderr := iostreams.Test()
+			client := &mockFlapsClient{}
+			ctx := iostreams.NewContext(context.Background(), ios)
+			ctx = flapsutil.NewContextWithClient(ctx, client)
+
+			md := &machineDeployment{
+				io:          ios,
+				colorize:    ios.ColorScheme(),
+				app:         &flaps.App{Name: "my-app"},
+				img:         "registry.fly.io/my-app:v2",
+				flapsClient: client,
+				strategy:    strategy,
+				updateOnly:  true,
+				machineSet:  machine.NewMachineSet(client, ios, "", nil, false),
+				appConfig: &appconfig.Config{
+					Deploy: &appconfig.Deploy{
+						PostDeploy: &appconfig.DeployHook{Command: `echo "post $FLY_DEPLOY_HOOK"`, Local: true},
+					},
+				},
+			}
+
+			require.NoError(t, md.deployMachinesApp(ctx))
+			assert.Contains(t, stdout.String()+stderr.String(), "post post_deploy")
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/fly-go/flaps"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/buildinfo"
 	"github.com/superfly/flyctl/internal/cmdutil"
 	"github.com/superfly/flyctl/internal/command/deploy/statics"
@@ -80,8 +81,22 @@ type MachineDeploymentArgs struct {
 	RestartPolicy         *fly.MachineRestartPolicy
 	RestartMaxRetries     int
 	DeployRetries         int
+	AutoRollback          bool
 	BuildID               int64
 	BuilderID             string
+	// Attestation describes the attestations of DeploymentImage, recorded in
+	// the release.
+	Attestation *imgsrc.Attestation
+	// ContainerImages are the images built for the services of the compose
+	// file, by container name.
+	ContainerImages map[string]string
+	// Checkpoint, when set, records the deployment's progress so it can be resumed.
+	Checkpoint *DeployCheckpoint
+	// Resume continues the deployment recorded in Checkpoint instead of starting a new release.
+	Resume bool
+	// DryRun prepares the deployment without provisioning anything or creating a release,
+	// so it can only be used to plan the deployment.
+	DryRun bool
 }
 
 func argsFromManifest(manifest *DeployManifest, app *flaps.App) MachineDeploymentArgs {
@@ -115,6 +130,9 @@ func argsFromManifest(manifest *DeployManifest, app *flaps.App) MachineDeploymen
 		RestartPolicy:         manifest.RestartPolicy,
 		RestartMaxRetries:     manifest.RestartMaxRetries,
 		DeployRetries:         manifest.DeployRetries,
+		AutoRollback:          manifest.AutoRollback,
+		Attestation:           manifest.Attestation,
+		ContainerImages:       manifest.ContainerImages,
 	}
 }
 
@@ -162,11 +180,25 @@ type machineDeployment struct {
 	volumeInitialSize     int
 	tigrisStatics         *statics.DeployerState
 	deployRetries         int
+	autoRollback          bool
 	buildID               int64
 	builderID             string
+	attestation           *imgsrc.Attestation
+	containerImages       map[string]string
+	checkpoint            *DeployCheckpoint
+	resuming              bool
 }
 
-func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ MachineDeployment, err error) {
+func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (MachineDeployment, error) {
+	md, err := newMachineDeployment(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
+func newMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ *machineDeployment, err error) {
 	var io = iostreams.FromContext(ctx)
 
 	ctx, span := tracing.GetTracer().Start(ctx, "new_machines_deployment")
@@ -282,8 +314,13 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ Ma
 		volumeInitialSize:     args.VolumeInitialSize,
 		processGroups:         args.ProcessGroups,
 		deployRetries:         args.DeployRetries,
+		autoRollback:          args.AutoRollback || (appConfig.Deploy != nil && appConfig.Deploy.AutoRollback),
 		buildID:               args.BuildID,
 		builderID:             args.BuilderID,
+		attestation:           args.Attestation,
+		containerImages:       args.ContainerImages,
+		checkpoint:            args.Checkpoint,
+		resuming:              args.Resume && args.Checkpoint != nil,
 	}
 	if err := md.setStrategy(); err != nil {
 		tracing.RecordError(span, err, "failed to set strategy")
@@ -291,6 +328,12 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ Ma
 		return nil, err
 	}
 
+	if md.resuming {
+		if err := checkResumable(md.strategy); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := md.setMachinesForDeployment(ctx); err != nil {
 		tracing.RecordError(span, err, "failed to set machines for first deployemt")
 
@@ -313,22 +356,41 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ Ma
 	}
 
 	// Provisioning must come after setVolumes
-	if err := md.provisionFirstDeploy(ctx, args.AllocIP, args.Org); err != nil {
-		tracing.RecordError(span, err, "failed to provision first depoloy")
+	if !args.DryRun {
+		if err := md.provisionFirstDeploy(ctx, args.AllocIP, args.Org); err != nil {
+			tracing.RecordError(span, err, "failed to provision first depoloy")
 
-		return nil, err
+			return nil, err
+		}
 	}
 
-	// validations must happen after every else
-	if err := md.validateVolumeConfig(ctx); err != nil {
-		tracing.RecordError(span, err, "failed to validate volume config")
+	// validations must happen after every else. A dry run of the first
+	// deployment skips them, as the volumes it needs haven't been provisioned.
+	if !args.DryRun || !md.isFirstDeploy {
+		if err := md.validateVolumeConfig(ctx); err != nil {
+			tracing.RecordError(span, err, "failed to validate volume config")
 
-		return nil, err
+			return nil, err
+		}
 	}
-	if err = md.createReleaseInBackend(ctx); err != nil {
-		tracing.RecordError(span, err, "failed to create release in backend")
+	if args.DryRun {
+		span.SetAttributes(md.ToSpanAttributes()...)
 
-		return nil, err
+		return md, nil
+	}
+	if md.resuming && md.checkpoint.ReleaseID != "" {
+		// The release was already created by the interrupted deployment.
+		md.releaseId = md.checkpoint.ReleaseID
+		md.releaseVersion = md.checkpoint.ReleaseVersion
+	} else {
+		if err = md.createReleaseInBackend(ctx); err != nil {
+			tracing.RecordError(span, err, "failed to create release in backend")
+
+			return nil, err
+		}
+		if err = md.checkpoint.setRelease(md.releaseId, md.releaseVersion); err != nil {
+			terminal.Warnf("failed to save deploy checkpoint: %v\n", err)
+		}
 	}
 
 	span.SetAttributes(md.ToSpanAttributes()...)
@@ -700,7 +762,7 @@ func (md *machineDeployment) createReleaseInBackend(ctx context.Context) error {
 
 	resp, err := md.uiexClient.CreateRelease(ctx, uiex.CreateReleaseRequest{
 		AppName:    md.app.Name,
-		Strategy:   uiex.DeploymentStrategy(strings.ToUpper(md.strategy)),
+		Strategy:   md.releaseStrategy(),
 		Definition: md.appConfig,
 		Image:      md.img,
 		BuildId:    md.buildID,
@@ -716,6 +778,16 @@ func (md *machineDeployment) createReleaseInBackend(ctx context.Context) error {
 	return nil
 }
 
+// releaseStrategy returns the strategy recorded for the release. The backend
+// doesn't know about the progressive strategy, so it's reported as a canary.
+func (md *machineDeployment) releaseStrategy() uiex.DeploymentStrategy {
+	if md.strategy == "progressive" {
+		return uiex.DeploymentStrategyCanary
+	}
+
+	return uiex.DeploymentStrategy(strings.ToUpper(md.strategy))
+}
+
 const (
 	// releaseStatusRetryAttempts bounds how many times a release status update is
 	// attempted before the deploy gives up.
@@ -740,7 +812,7 @@ func (md *machineDeployment) updateReleaseInBackend(ctx context.Context, status
 	err := retry.Do(
 		func() error {
 			attempts++
-			_, err := md.uiexClient.UpdateRelease(ctx, md.releaseId, status, metadata)
+			_, err := md.uiexClient.UpdateRelease(ctx, md.releaseId, status, md.releaseMetadata(metadata))
 
 			return err
 		},
@@ -766,6 +838,30 @@ func (md *machineDeployment) updateReleaseInBackend(ctx context.Context, status
 	return nil
 }
 
+// releaseMetadata returns the metadata to record in the release, adding the
+// attestation of the image to metadata when there's one.
+func (md *machineDeployment) releaseMetadata(metadata *fly.ReleaseMetadata) any {
+	if md.attestation == nil {
+		return metadata
+	}
+
+	fields := map[string]any{}
+	if metadata != nil {
+		data, err := json.Marshal(metadata)
+		if err == nil {
+			err = json.Unmarshal(data, &fields)
+		}
+		if err != nil {
+			terminal.Debugf("not recording the attestation in the release: %v\n", err)
+
+			return metadata
+		}
+	}
+	fields["attestation"] = md.attestation
+
+	return fields
+}
+
 // isRetryableReleaseStatusError reports whether a failed release status update is
 // worth another attempt.
 //
@@ -104,6 +104,8 @@ func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 		ctx, cancel = context.WithTimeout(onInterruptContext, time.Second)
 		defer cancel()
 	default:
+		// Rolled back deployments failed too, their error says what was
+		// restored
 		metadata.PostDeploymentInfo.Error = err.Error()
 		status = "failed"
 	}
@@ -135,6 +137,8 @@ func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 
 	if err != nil {
 		tracing.RecordError(span, err, "failed to deploy machines")
+	} else if rmErr := md.checkpoint.remove(); rmErr != nil {
+		terminal.Warnf("failed to remove deploy checkpoint: %v\n", rmErr)
 	}
 
 	// When FLY_EMIT_RELEASE_JSON is set, emit a JSON line to stdout with the
@@ -265,7 +269,15 @@ func (md *machineDeployment) restartMachinesApp(ctx context.Context) error {
 		return jerr
 	}
 
-	return md.updateExistingMachines(ctx, machineUpdateEntries)
+	if err := md.updateExistingMachines(ctx, machineUpdateEntries); err != nil {
+		return err
+	}
+
+	if err := md.runDeployHook(ctx, hookPostDeploy); err != nil {
+		return fmt.Errorf("post_deploy hook failed: %w", err)
+	}
+
+	return nil
 }
 
 func (md *machineDeployment) inferCanaryGuest(processGroup string) *fly.MachineGuest {
@@ -358,9 +370,12 @@ func (md *machineDeployment) deployCanaryMachines(ctx context.Context) (err erro
 func (md *machineDeployment) deployCreateMachinesForGroups(ctx context.Context, processGroupMachineDiff ProcessGroupsDiff) (err error) {
 	groupsWithAutostopEnabled := make(map[string]bool)
 	groupsWithAutosuspendEnabled := make(map[string]bool)
-	groups := slices.Collect(maps.Keys(processGroupMachineDiff.groupsNeedingMachines))
+	order, err := md.newGroupOrder(slices.Collect(maps.Keys(processGroupMachineDiff.groupsNeedingMachines)))
+	if err != nil {
+		return err
+	}
+	groups := order.groups()
 	total := len(groups)
-	slices.Sort(groups)
 
 	sl := statuslogger.Create(ctx, total, true)
 	defer sl.Destroy(false)
@@ -461,18 +476,38 @@ func (md *machineDeployment) deployCreateMachinesForGroups(ctx context.Context,
 }
 
 // deployMachinesApp executes the following flow:
+//   - Run pre_deploy hook
 //   - Run release command
 //   - Remove spare machines from removed groups
 //   - Launch new machines on new groups
 //   - Update existing machines
+//   - Run post_deploy hook
 func (md *machineDeployment) deployMachinesApp(ctx context.Context) error {
 	ctx, span := tracing.GetTracer().Start(ctx, "deploy_new_machines")
 	defer span.End()
 
-	if !md.skipReleaseCommand {
+	if md.resuming && md.checkpoint.PreDeployCompleted {
+		fmt.Fprintln(md.io.Out, "Skipping pre_deploy hook, it already completed before the deployment was interrupted")
+	} else {
+		if err := md.runDeployHook(ctx, hookPreDeploy); err != nil {
+			return fmt.Errorf("pre_deploy hook failed - aborting deployment. %w", err)
+		}
+		if err := md.checkpoint.markPreDeployCompleted(); err != nil {
+			terminal.Warnf("failed to save deploy checkpoint: %v\n", err)
+		}
+	}
+
+	switch {
+	case md.skipReleaseCommand:
+	case md.resuming && md.checkpoint.ReleaseCommandCompleted:
+		fmt.Fprintln(md.io.Out, "Skipping release command, it already completed before the deployment was interrupted")
+	default:
 		if err := md.runReleaseCommands(ctx); err != nil {
 			return fmt.Errorf("release command failed - aborting deployment. %w", err)
 		}
+		if err := md.checkpoint.markReleaseCommandCompleted(); err != nil {
+			terminal.Warnf("failed to save deploy checkpoint: %v\n", err)
+		}
 	}
 
 	processGroupMachineDiff := md.resolveProcessGroupChanges()
@@ -506,10 +541,22 @@ func (md *machineDeployment) deployMachinesApp(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("failed to update machine configuration for %s: %w", lm.FormattedMachineId(), err)
 		}
+		if md.resuming && md.checkpoint.isUpdated(lm.Machine().ID) && compareConfigs(ctx, lm.Machine().Config, li.Config) {
+			fmt.Fprintf(md.io.Out, "Skipping machine %s, it was already updated before the deployment was interrupted\n", lm.FormattedMachineId())
+			continue
+		}
 		machineUpdateEntries = append(machineUpdateEntries, &machineUpdateEntry{leasableMachine: lm, launchInput: li})
 	}
 
-	return md.updateExistingMachines(ctx, machineUpdateEntries)
+	if err := md.updateExistingMachines(ctx, machineUpdateEntries); err != nil {
+		return err
+	}
+
+	if err := md.runDeployHook(ctx, hookPostDeploy); err != nil {
+		return fmt.Errorf("post_deploy hook failed: %w", err)
+	}
+
+	return nil
 }
 
 type machineUpdateEntry struct {
@@ -600,7 +647,24 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 		span.End()
 	}()
 
-	if md.deployRetries > 0 {
+	if md.autoRollback && md.supportsAutoRollback() && len(updateEntries) > 0 {
+		originalAppState := &AppState{
+			Machines: lo.Map(updateEntries, func(e *machineUpdateEntry, _ int) *fly.Machine {
+				m := *e.leasableMachine.Machine()
+				m.Config = machine.CloneConfig(m.Config)
+
+				return &m
+			}),
+		}
+		defer func() {
+			if err != nil && !errors.Is(err, context.Canceled) {
+				err = md.rollbackFailedUpdate(ctx, originalAppState, err)
+			}
+		}()
+	}
+
+	// The progressive strategy rolls back on its own instead of pushing forward.
+	if md.deployRetries > 0 && md.strategy != "progressive" {
 		err := md.updateExistingMachinesWRecovery(ctx, updateEntries)
 		if err != nil {
 			span.RecordError(err)
@@ -629,6 +693,8 @@ func (md *machineDeployment) updateExistingMachines(ctx context.Context, updateE
 		err = md.updateUsingBlueGreenStrategy(ctx, updateEntries)
 	case "immediate":
 		err = md.updateUsingImmediateStrategy(ctx, updateEntries)
+	case "progressive":
+		err = md.updateUsingProgressiveStrategy(ctx, updateEntries)
 	case "canary", "rolling":
 		fallthrough
 	default:
@@ -766,6 +832,11 @@ func (md *machineDeployment) updateUsingBlueGreenStrategy(ctx context.Context, u
 
 			return rollbackErr
 		}
+		// Green machines are only destroyed, rolling back to the blue ones,
+		// for errors before traffic moved to them.
+		if !bg.rollbackLog.disableRollback && bg.CanDestroyGreenMachines(err) {
+			md.runRollbackHook(ctx)
+		}
 
 		return suggestChangeWaitTimeout(err, "wait-timeout")
 	}
@@ -830,6 +901,7 @@ func (md *machineDeployment) updateUsingImmediateStrategy(parentCtx context.Cont
 
 				return err
 			}
+			md.markMachineUpdated(e.leasableMachine.Machine().ID)
 			statusSuccess()
 
 			return nil
@@ -861,6 +933,11 @@ func (md *machineDeployment) updateUsingRollingStrategy(parentCtx context.Contex
 		return e.launchInput.Config.ProcessGroup()
 	})
 
+	order, err := md.newGroupOrder(lo.Keys(entriesByGroup))
+	if err != nil {
+		return err
+	}
+
 	startIdx := 0
 	groupsPool := pool.New().
 		WithErrors().
@@ -868,7 +945,8 @@ func (md *machineDeployment) updateUsingRollingStrategy(parentCtx context.Contex
 		WithContext(parentCtx).
 		WithCancelOnError()
 
-	for group, entries := range entriesByGroup {
+	for _, group := range order.groups() {
+		entries := entriesByGroup[group]
 
 		warmMachines := lo.Filter(entries, func(e *machineUpdateEntry, i int) bool {
 			return e.leasableMachine.Machine().State == "started"
@@ -877,10 +955,21 @@ func (md *machineDeployment) updateUsingRollingStrategy(parentCtx context.Contex
 			return e.leasableMachine.Machine().State != "started"
 		})
 
-		groupsPool.Go(func(ctx context.Context) error {
+		// Groups waiting for the groups they depend on start late, so their
+		// status lines are assigned upfront.
+		coldIdx := startIdx
+		startIdx += len(coldMachines)
+		warmIdx := startIdx
+		startIdx += len(warmMachines)
+
+		groupsPool.Go(func(ctx context.Context) (err error) {
+			if err := order.wait(ctx, group); err != nil {
+				return err
+			}
+			defer func() { order.finish(group, err) }()
+
 			eg, ctx := errgroup.WithContext(ctx)
 
-			coldIdx := startIdx
 			if len(coldMachines) > 0 {
 				eg.Go(func() error {
 					// Capping the size just in case, it may be okay to stop all of them at once.
@@ -889,9 +978,7 @@ func (md *machineDeployment) updateUsingRollingStrategy(parentCtx context.Contex
 					return md.updateEntriesGroup(ctx, group, coldMachines, sl, coldIdx, chunk)
 				})
 			}
-			startIdx += len(coldMachines)
 
-			warmIdx := startIdx
 			if len(warmMachines) > 0 {
 				eg.Go(func() error {
 					// Since these machines are still receiving traffic, the chunk size here is more conservative (lower)
@@ -901,13 +988,12 @@ func (md *machineDeployment) updateUsingRollingStrategy(parentCtx context.Contex
 					return md.updateEntriesGroup(ctx, group, warmMachines, sl, warmIdx, chunk)
 				})
 			}
-			startIdx += len(warmMachines)
 
 			return eg.Wait()
 		})
 	}
 
-	err := groupsPool.Wait()
+	err = groupsPool.Wait()
 	if err != nil {
 		span.RecordError(err)
 	}
@@ -1014,6 +1100,7 @@ func (md *machineDeployment) updateEntriesGroup(parentCtx context.Context, group
 				return err
 			}
 
+			md.markMachineUpdated(e.leasableMachine.Machine().ID)
 			statusSuccess()
 
 			return nil
@@ -325,7 +325,13 @@ func (md *machineDeployment) updateContainerImage(mConfig *fly.MachineConfig) er
 
 	for i := range mConfig.Containers {
 		if mConfig.Containers[i].Image == "." {
-			mConfig.Containers[i].Image = mConfig.Image
+			// Services of compose files built differently than the app have
+			// their own image
+			if image, ok := md.containerImages[mConfig.Containers[i].Name]; ok {
+				mConfig.Containers[i].Image = image
+			} else {
+				mConfig.Containers[i].Image = mConfig.Image
+			}
 		}
 		for j := range mConfig.Containers[i].Files {
 			if mConfig.Containers[i].Files[j].ImageConfig != nil && *mConfig.Containers[i].Files[j].ImageConfig == "." {
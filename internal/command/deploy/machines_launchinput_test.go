@@ -667,4 +667,18 @@ func testUpdateContainerImageConfig(t *testing.T) {
 	assert.Equal(t, "registry.fly.io/myapp:deploy-5678", *mConfig.Containers[0].Files[0].ImageConfig)
 	assert.Equal(t, "redis:7", *mConfig.Containers[0].Files[1].ImageConfig)
 	assert.Equal(t, "registry.fly.io/myapp:deploy-5678", *mConfig.Containers[1].Files[0].ImageConfig)
+
+	// Compose services built separately use their own image
+	md.containerImages = map[string]string{"worker": "registry.fly.io/myapp:deploy-5678-worker@sha256:abc"}
+	mConfig = &fly.MachineConfig{
+		Image: "registry.fly.io/myapp:deploy-5678",
+		Containers: []*fly.ContainerConfig{
+			{Name: "app", Image: "."},
+			{Name: "worker", Image: "."},
+		},
+	}
+	err = md.updateContainerImage(mConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "registry.fly.io/myapp:deploy-5678", mConfig.Containers[0].Image)
+	assert.Equal(t, "registry.fly.io/myapp:deploy-5678-worker@sha256:abc", mConfig.Containers[1].Image)
 }
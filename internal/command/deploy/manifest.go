@@ -12,6 +12,7 @@ import (
 
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/sentry"
 	"github.com/superfly/flyctl/iostreams"
@@ -49,6 +50,9 @@ type DeployManifest struct {
 	RestartPolicy         *fly.MachineRestartPolicy `json:"restart_policy,omitempty"`
 	RestartMaxRetries     int                       `json:"restart_max_retrie,omitempty"`
 	DeployRetries         int                       `json:"deploy_retries,omitempty"`
+	AutoRollback          bool                      `json:"auto_rollback,omitempty"`
+	Attestation           *imgsrc.Attestation       `json:"attestation,omitempty"`
+	ContainerImages       map[string]string         `json:"container_images,omitempty"`
 }
 
 func NewManifest(AppName string, config *appconfig.Config, args MachineDeploymentArgs) *DeployManifest {
@@ -83,6 +87,9 @@ func NewManifest(AppName string, config *appconfig.Config, args MachineDeploymen
 		RestartPolicy:         args.RestartPolicy,
 		RestartMaxRetries:     args.RestartMaxRetries,
 		DeployRetries:         args.DeployRetries,
+		AutoRollback:          args.AutoRollback,
+		Attestation:           args.Attestation,
+		ContainerImages:       args.ContainerImages,
 	}
 }
 
@@ -143,6 +150,12 @@ func deployFromManifest(ctx context.Context, manifest *DeployManifest) error {
 
 	fmt.Fprintf(io.Out, "Resuming %s deploy from manifest\n", manifest.AppName)
 
+	return DeployFromManifest(ctx, manifest)
+}
+
+// DeployFromManifest deploys the image and config recorded in manifest to the
+// app's machines.
+func DeployFromManifest(ctx context.Context, manifest *DeployManifest) error {
 	flapsClient := flapsutil.ClientFromContext(ctx)
 	app, err := flapsClient.GetApp(ctx, manifest.AppName)
 	if err != nil {
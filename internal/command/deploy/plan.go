@@ -222,12 +222,23 @@ func (md *machineDeployment) updateMachinesWRecovery(ctx context.Context, origin
 		}()
 	}
 
+	order, err := md.newGroupOrder(lo.Keys(machPairByProcessGroup))
+	if err != nil {
+		return err
+	}
+
 	pgroup := errgroup.Group{}
 	pgroup.SetLimit(rollingStrategyMaxConcurrentGroups)
 
 	// We want to update by process group
-	for _, machineTuples := range machPairByProcessGroup {
-		pgroup.Go(func() error {
+	for _, group := range order.groups() {
+		machineTuples := machPairByProcessGroup[group]
+		pgroup.Go(func() (err error) {
+			if err := order.wait(ctx, group); err != nil {
+				return err
+			}
+			defer func() { order.finish(group, err) }()
+
 			eg, ctx := errgroup.WithContext(ctx)
 
 			isWarm := func(e machinePairing, i int) bool {
@@ -266,7 +277,7 @@ func (md *machineDeployment) updateMachinesWRecovery(ctx context.Context, origin
 				return nil
 			})
 
-			err := eg.Wait()
+			err = eg.Wait()
 			if err != nil {
 				span.RecordError(err)
 				if strings.Contains(err.Error(), "lease currently held by") {
@@ -407,6 +418,7 @@ func (md *machineDeployment) updateProcessGroup(ctx context.Context, machineTupl
 
 				return fmt.Errorf("failed to update machine %s: %w", machineID, err)
 			}
+			md.markMachineUpdated(machineID)
 
 			return nil
 		})
@@ -550,7 +562,16 @@ func compareConfigs(ctx context.Context, oldConfig, newConfig *fly.MachineConfig
 	_, span := tracing.GetTracer().Start(ctx, "compare_configs")
 	defer span.End()
 
-	opt := cmp.Options{
+	isEqual := cmp.Equal(oldConfig, newConfig, machineConfigCmpOptions())
+	span.SetAttributes(attribute.Bool("configs_equal", isEqual))
+
+	return isEqual
+}
+
+// machineConfigCmpOptions are the options used to compare the machine configs
+// of a deployment's old and new states.
+func machineConfigCmpOptions() cmp.Options {
+	return cmp.Options{
 		cmp.FilterPath(func(p cmp.Path) bool {
 			vx := p.Last().String()
 
@@ -567,11 +588,6 @@ func compareConfigs(ctx context.Context, oldConfig, newConfig *fly.MachineConfig
 			return isEmptyOrNilSlice(x) && isEmptyOrNilSlice(y)
 		}, cmp.Ignore()),
 	}
-
-	isEqual := cmp.Equal(oldConfig, newConfig, opt)
-	span.SetAttributes(attribute.Bool("configs_equal", isEqual))
-
-	return isEqual
 }
 
 func isEmptyOrNilSlice(v interface{}) bool {
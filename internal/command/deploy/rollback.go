@@ -0,0 +1,106 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// autoRollbackError is returned when a failed deployment was rolled back.
+type autoRollbackError struct {
+	err      error
+	restored []string
+}
+
+func (e *autoRollbackError) Error() string {
+	return fmt.Sprintf("deployment failed and was rolled back (%d machines restored): %v", len(e.restored), e.err)
+}
+
+func (e *autoRollbackError) Unwrap() error {
+	return e.err
+}
+
+// supportsAutoRollback reports whether failed deployments with the current
+// strategy can be rolled back with --auto-rollback. Bluegreen deployments roll
+// back on their own and immediate ones don't wait for machines to be healthy.
+func (md *machineDeployment) supportsAutoRollback() bool {
+	return md.strategy == "rolling" || md.strategy == "canary"
+}
+
+// rollbackFailedUpdate restores every machine that was updated before the
+// deployment failed with deployErr to its config in originalAppState.
+func (md *machineDeployment) rollbackFailedUpdate(ctx context.Context, originalAppState *AppState, deployErr error) error {
+	ctx, span := tracing.GetTracer().Start(ctx, "auto_rollback", trace.WithAttributes(
+		attribute.Int("machines", len(originalAppState.Machines)),
+	))
+	defer span.End()
+
+	fmt.Fprintf(md.io.ErrOut, "Deployment failed, rolling back updated machines: %v\n", deployErr)
+
+	currentState, err := md.appState(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err, "failed to get app state")
+
+		return errors.Join(deployErr, fmt.Errorf("failed to get current app state to roll back: %w", err))
+	}
+	currentMachines := lo.KeyBy(currentState.Machines, func(m *fly.Machine) string {
+		return m.ID
+	})
+
+	originalMachines := slices.Clone(originalAppState.Machines)
+	slices.SortFunc(originalMachines, func(a, b *fly.Machine) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	var (
+		restored    []string
+		rollbackErr error
+	)
+	for _, original := range originalMachines {
+		if original.Config == nil {
+			continue
+		}
+
+		current, ok := currentMachines[original.ID]
+		if !ok {
+			fmt.Fprintf(md.io.ErrOut, "  %s can't restore %s, it was replaced during the deployment\n", md.colorize.Red("✘"), original.ID)
+			rollbackErr = errors.Join(rollbackErr, fmt.Errorf("machine %s was replaced", original.ID))
+
+			continue
+		}
+		if compareConfigs(ctx, current.Config, original.Config) {
+			continue
+		}
+
+		lm := machine.NewLeasableMachine(md.flapsClient, md.io, md.app.Name, current, false)
+		if err := md.restoreMachine(ctx, lm, snapshotMachine(original)); err != nil {
+			fmt.Fprintf(md.io.ErrOut, "  %s failed to restore %s: %v\n", md.colorize.Red("✘"), lm.FormattedMachineId(), err)
+			rollbackErr = errors.Join(rollbackErr, fmt.Errorf("machine %s: %w", original.ID, err))
+
+			continue
+		}
+		fmt.Fprintf(md.io.ErrOut, "  %s restored %s to its previous config\n", md.colorize.Green("✓"), lm.FormattedMachineId())
+		restored = append(restored, original.ID)
+	}
+
+	span.SetAttributes(attribute.Int("restored", len(restored)))
+	if rollbackErr != nil {
+		tracing.RecordError(span, rollbackErr, "failed to roll back machines")
+
+		return errors.Join(deployErr, fmt.Errorf("failed to roll back machines: %w", rollbackErr))
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "Rolled back %d machines to their previous config\n", len(restored))
+	md.runRollbackHook(ctx)
+
+	return &autoRollbackError{err: deployErr, restored: restored}
+}
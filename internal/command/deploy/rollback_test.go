@@ -0,0 +1,73 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/mock"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestRollbackFailedUpdate(t *testing.T) {
+	ctx := withQuietIOStreams(context.Background())
+
+	original := []*fly.Machine{
+		{ID: "m1", Region: "ord", State: "started", Config: &fly.MachineConfig{Image: "image:v1"}},
+		{ID: "m2", Region: "ord", State: "started", Config: &fly.MachineConfig{Image: "image:v1"}},
+	}
+	current := []*fly.Machine{
+		{ID: "m1", Region: "ord", State: "started", Config: &fly.MachineConfig{Image: "image:v2"}},
+		{ID: "m2", Region: "ord", State: "started", Config: &fly.MachineConfig{Image: "image:v1"}},
+	}
+
+	var updated []fly.LaunchMachineInput
+	flapsClient := &mock.FlapsClient{
+		ListFunc: func(ctx context.Context, appName, state string) ([]*fly.Machine, error) {
+			return current, nil
+		},
+		AcquireLeaseFunc: func(ctx context.Context, appName, machineID string, ttl *int) (*fly.MachineLease, error) {
+			return &fly.MachineLease{
+				Status: "success",
+				Data:   &fly.MachineLeaseData{Nonce: machineID + "nonce"},
+			}, nil
+		},
+		ReleaseLeaseFunc: func(ctx context.Context, appName, machineID, nonce string) error {
+			return nil
+		},
+		UpdateFunc: func(ctx context.Context, appName string, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			assert.Equal(t, "m1nonce", nonce)
+			updated = append(updated, input)
+
+			return &fly.Machine{ID: input.ID, Config: input.Config}, nil
+		},
+	}
+
+	ios := iostreams.FromContext(ctx)
+	md := &machineDeployment{
+		flapsClient:      flapsClient,
+		io:               ios,
+		colorize:         ios.ColorScheme(),
+		app:              &flaps.App{Name: "my-app"},
+		appConfig:        &appconfig.Config{AppName: "my-app"},
+		strategy:         "rolling",
+		skipHealthChecks: true,
+	}
+
+	deployErr := errors.New("health checks failed")
+	err := md.rollbackFailedUpdate(ctx, &AppState{Machines: original}, deployErr)
+
+	var rollbackErr *autoRollbackError
+	require.ErrorAs(t, err, &rollbackErr)
+	assert.ErrorIs(t, err, deployErr)
+	assert.Equal(t, []string{"m1"}, rollbackErr.restored)
+
+	require.Len(t, updated, 1)
+	assert.Equal(t, "image:v1", updated[0].Config.Image)
+	assert.False(t, updated[0].SkipLaunch)
+}
@@ -0,0 +1,89 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+
+	"github.com/superfly/flyctl/internal/appsecrets"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/secretref"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// readSecretsFile reads the secrets of the --secrets-file flag, decrypting
+// them with the identity of --secrets-identity when they're encrypted with
+// 'fly secrets encrypt', and resolving their references to secret managers
+// with --resolve-secrets. It returns no secrets when the flag isn't set.
+func readSecretsFile(ctx context.Context) (map[string]string, error) {
+	path := flag.GetString(ctx, "secrets-file")
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err = appsecrets.Plaintext(data, flag.GetString(ctx, "secrets-identity")); err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets from %s: %w", path, err)
+	}
+
+	secrets, err := appsecrets.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secrets from %s: %w", path, err)
+	}
+
+	if !flag.GetBool(ctx, "resolve-secrets") {
+		return secrets, nil
+	}
+
+	secrets, err = secretref.Default().ResolveAll(ctx, secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secret references of %s:\n%w", path, err)
+	}
+
+	return secrets, nil
+}
+
+// stageSecrets sets the secrets read from source that are new or changed on
+// the app, so that the deployment picks them up. Secrets missing from secrets
+// are kept.
+func stageSecrets(ctx context.Context, appName string, secrets map[string]string, source string) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	flapsClient := flapsutil.ClientFromContext(ctx)
+
+	current, err := appsecrets.List(ctx, flapsClient, appName)
+	if err != nil {
+		return fmt.Errorf("failed listing secrets of %s: %w", appName, err)
+	}
+
+	changed := maps.Clone(secrets)
+	for _, secret := range current {
+		if value, ok := changed[secret.Name]; ok && appsecrets.DigestMatches(value, secret.Digest) {
+			delete(changed, secret.Name)
+		}
+	}
+
+	io := iostreams.FromContext(ctx)
+	if len(changed) == 0 {
+		fmt.Fprintf(io.Out, "Secrets of %s are up to date with %s\n", appName, source)
+
+		return nil
+	}
+
+	if err := appsecrets.Update(ctx, flapsClient, appName, changed, nil); err != nil {
+		return fmt.Errorf("failed setting secrets of %s: %w", appName, err)
+	}
+
+	fmt.Fprintf(io.Out, "Set %d secrets of %s from %s\n", len(changed), appName, source)
+
+	return nil
+}
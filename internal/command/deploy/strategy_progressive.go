@@ -0,0 +1,403 @@
+package deploy
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/statuslogger"
+	"github.com/superfly/flyctl/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	defaultProgressiveSteps = []float64{10, 50, 100}
+	defaultProgressivePause = 1 * time.Minute
+)
+
+// progressiveSettings is the resolved [deploy.progressive] section of fly.toml.
+type progressiveSettings struct {
+	steps            []float64
+	pause            time.Duration
+	maxFailedChecks  int
+	metricsQuery     string
+	metricsThreshold float64
+}
+
+func progressiveSettingsFromConfig(cfg *appconfig.Config) progressiveSettings {
+	settings := progressiveSettings{
+		steps: defaultProgressiveSteps,
+		pause: defaultProgressivePause,
+	}
+
+	if cfg == nil || cfg.Deploy == nil || cfg.Deploy.Progressive == nil {
+		return settings
+	}
+
+	p := cfg.Deploy.Progressive
+	if len(p.Steps) > 0 {
+		settings.steps = p.Steps
+	}
+	if settings.steps[len(settings.steps)-1] < 100 {
+		settings.steps = append(slices.Clone(settings.steps), 100)
+	}
+	if p.Pause != nil {
+		settings.pause = p.Pause.Duration
+	}
+	if p.MaxFailedChecks != nil {
+		settings.maxFailedChecks = *p.MaxFailedChecks
+	}
+	if p.MetricsQuery != "" && p.MetricsThreshold != nil {
+		settings.metricsQuery = p.MetricsQuery
+		settings.metricsThreshold = *p.MetricsThreshold
+	}
+
+	return settings
+}
+
+// progressiveTargets returns, for every step, how many of total machines should
+// have been updated once the step finishes. Every step updates at least one
+// machine more than the previous one until all of them are done.
+func progressiveTargets(total int, steps []float64) []int {
+	targets := make([]int, 0, len(steps))
+	prev := 0
+	for _, step := range steps {
+		target := int(math.Ceil(float64(total) * step / 100))
+		target = min(max(target, prev+1), total)
+		targets = append(targets, target)
+		prev = target
+	}
+
+	return targets
+}
+
+// machineSnapshot is a copy of a machine as it was before the deployment touched it.
+type machineSnapshot struct {
+	id     string
+	region string
+	state  string
+	config *fly.MachineConfig
+}
+
+func snapshotMachine(m *fly.Machine) machineSnapshot {
+	return machineSnapshot{
+		id:     m.ID,
+		region: m.Region,
+		state:  m.State,
+		config: machine.CloneConfig(m.Config),
+	}
+}
+
+// updateUsingProgressiveStrategy updates each process group's machines in steps,
+// pausing after each one to evaluate the health gate. Machines updated so far are
+// restored to their original config when a step or its gate fails.
+func (md *machineDeployment) updateUsingProgressiveStrategy(ctx context.Context, updateEntries []*machineUpdateEntry) error {
+	ctx, span := tracing.GetTracer().Start(ctx, "progressive")
+	defer span.End()
+
+	settings := progressiveSettingsFromConfig(md.appConfig)
+
+	slices.SortFunc(updateEntries, func(a, b *machineUpdateEntry) int {
+		return cmp.Compare(a.leasableMachine.Machine().ID, b.leasableMachine.Machine().ID)
+	})
+
+	snapshots := make(map[*machineUpdateEntry]machineSnapshot, len(updateEntries))
+	for _, e := range updateEntries {
+		snapshots[e] = snapshotMachine(e.leasableMachine.Machine())
+	}
+
+	entriesByGroup := lo.GroupBy(updateEntries, func(e *machineUpdateEntry) string {
+		return e.launchInput.Config.ProcessGroup()
+	})
+	groups := lo.Keys(entriesByGroup)
+	slices.Sort(groups)
+
+	targets := make(map[string][]int, len(groups))
+	for _, group := range groups {
+		targets[group] = progressiveTargets(len(entriesByGroup[group]), settings.steps)
+	}
+
+	var touched []*machineUpdateEntry
+	done := make(map[string]int, len(groups))
+	totalSteps := len(settings.steps)
+
+	for step := range totalSteps {
+		batches := make(map[string][]*machineUpdateEntry, len(groups))
+		stepSize := 0
+		for _, group := range groups {
+			batch := entriesByGroup[group][done[group]:targets[group][step]]
+			if len(batch) > 0 {
+				batches[group] = batch
+				stepSize += len(batch)
+			}
+		}
+		if stepSize == 0 {
+			continue
+		}
+
+		fmt.Fprintf(md.io.Out, "Step %d/%d: updating machines up to %s of each process group\n",
+			step+1, totalSteps, md.colorize.Bold(strconv.FormatFloat(settings.steps[step], 'f', -1, 64)+"%"))
+
+		err := md.updateProgressiveStep(ctx, groups, batches, stepSize)
+		for _, group := range groups {
+			touched = append(touched, batches[group]...)
+			done[group] = targets[group][step]
+		}
+
+		if err == nil && step < totalSteps-1 {
+			err = md.waitForProgressiveGate(ctx, settings, touched)
+		}
+
+		if err != nil {
+			span.SetAttributes(attribute.Int("failed_step", step+1))
+			tracing.RecordError(span, err, "progressive step failed")
+
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+
+			fmt.Fprintf(md.io.ErrOut, "Step %d/%d failed, rolling back updated machines: %v\n", step+1, totalSteps, err)
+			if rollbackErr := md.restoreMachines(ctx, touched, snapshots); rollbackErr != nil {
+				return errors.Join(err, fmt.Errorf("failed to roll back machines: %w", rollbackErr))
+			}
+			md.runRollbackHook(ctx)
+
+			return fmt.Errorf("progressive deployment rolled back at step %d/%d: %w", step+1, totalSteps, err)
+		}
+	}
+
+	return nil
+}
+
+func (md *machineDeployment) updateProgressiveStep(ctx context.Context, groups []string, batches map[string][]*machineUpdateEntry, stepSize int) error {
+	sl := statuslogger.Create(ctx, stepSize, true)
+	defer sl.Destroy(false)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(rollingStrategyMaxConcurrentGroups)
+
+	startIdx := 0
+	for _, group := range groups {
+		batch := batches[group]
+		if len(batch) == 0 {
+			continue
+		}
+
+		idx := startIdx
+		eg.Go(func() error {
+			return md.updateEntriesGroup(ctx, group, batch, sl, idx, md.getPoolSize(len(batch)))
+		})
+		startIdx += len(batch)
+	}
+
+	return eg.Wait()
+}
+
+// waitForProgressiveGate pauses for the configured duration and then checks that
+// the machines updated so far are healthy enough to continue.
+func (md *machineDeployment) waitForProgressiveGate(ctx context.Context, settings progressiveSettings, updated []*machineUpdateEntry) error {
+	ctx, span := tracing.GetTracer().Start(ctx, "progressive_gate", trace.WithAttributes(
+		attribute.Float64("pause", settings.pause.Seconds()),
+		attribute.Int("updated_machines", len(updated)),
+	))
+	defer span.End()
+
+	if settings.pause > 0 {
+		fmt.Fprintf(md.io.Out, "Watching %d updated machines for %s before continuing\n", len(updated), settings.pause)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(settings.pause):
+		}
+	}
+
+	failing := 0
+	for _, e := range updated {
+		if e.launchInput.SkipLaunch {
+			continue
+		}
+
+		id := e.leasableMachine.Machine().ID
+		m, err := md.flapsClient.Get(ctx, md.app.Name, id)
+		if err != nil {
+			return fmt.Errorf("failed to get machine %s: %w", id, err)
+		}
+		failing += m.AllHealthChecks().Critical
+	}
+	span.SetAttributes(attribute.Int("failing_checks", failing))
+
+	if failing > settings.maxFailedChecks {
+		return fmt.Errorf("%d health checks are failing on updated machines, at most %d allowed", failing, settings.maxFailedChecks)
+	}
+
+	if settings.metricsQuery != "" {
+		cfg := config.FromContext(ctx)
+		endpoint := fmt.Sprintf("%s/prometheus/%s/api/v1/query", cfg.APIBaseURL, md.app.Organization.Slug)
+
+		samples, err := queryPrometheus(ctx, http.DefaultClient, endpoint, cfg.Tokens.GraphQL(), settings.metricsQuery)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate metrics query: %w", err)
+		}
+
+		for _, v := range samples {
+			if v > settings.metricsThreshold {
+				return fmt.Errorf("metrics query returned %v, above the threshold of %v", v, settings.metricsThreshold)
+			}
+		}
+	}
+
+	fmt.Fprintf(md.io.Out, "%s\n", md.colorize.Green("✓ Gate passed"))
+
+	return nil
+}
+
+// queryPrometheus runs an instant query and returns the value of every sample
+// in the result. Both vector and scalar results are supported.
+func queryPrometheus(ctx context.Context, client *http.Client, endpoint, token, query string) ([]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response (status %d): %w", res.StatusCode, err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("query failed (status %d): %s", res.StatusCode, response.Error)
+	}
+
+	var values [][2]any
+	switch response.Data.ResultType {
+	case "scalar":
+		var value [2]any
+		if err := json.Unmarshal(response.Data.Result, &value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	case "vector":
+		var vector []struct {
+			Value [2]any `json:"value"`
+		}
+		if err := json.Unmarshal(response.Data.Result, &vector); err != nil {
+			return nil, err
+		}
+		for _, sample := range vector {
+			values = append(values, sample.Value)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported result type %q, the query must return a scalar or an instant vector", response.Data.ResultType)
+	}
+
+	samples := make([]float64, 0, len(values))
+	for _, value := range values {
+		raw, ok := value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected sample value %v", value[1])
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected sample value %q: %w", raw, err)
+		}
+		samples = append(samples, v)
+	}
+
+	return samples, nil
+}
+
+// restoreMachines puts every entry's machine back to its snapshotted config.
+// Machines whose config never changed are left alone.
+func (md *machineDeployment) restoreMachines(ctx context.Context, entries []*machineUpdateEntry, snapshots map[*machineUpdateEntry]machineSnapshot) error {
+	ctx, span := tracing.GetTracer().Start(ctx, "restore_machines", trace.WithAttributes(
+		attribute.Int("machines", len(entries)),
+	))
+	defer span.End()
+
+	var restoreErr error
+	for _, e := range entries {
+		snapshot, ok := snapshots[e]
+		if !ok || snapshot.config == nil {
+			continue
+		}
+
+		lm := e.leasableMachine
+		if compareConfigs(ctx, lm.Machine().Config, snapshot.config) {
+			continue
+		}
+
+		fmtID := lm.FormattedMachineId()
+		if err := md.restoreMachine(ctx, lm, snapshot); err != nil {
+			fmt.Fprintf(md.io.ErrOut, "  %s failed to restore %s: %v\n", md.colorize.Red("✘"), fmtID, err)
+			restoreErr = errors.Join(restoreErr, fmt.Errorf("machine %s: %w", lm.Machine().ID, err))
+
+			continue
+		}
+		fmt.Fprintf(md.io.ErrOut, "  %s restored %s to its previous config\n", md.colorize.Green("✓"), fmtID)
+	}
+
+	if restoreErr != nil {
+		span.RecordError(restoreErr)
+	}
+
+	return restoreErr
+}
+
+func (md *machineDeployment) restoreMachine(ctx context.Context, lm machine.LeasableMachine, snapshot machineSnapshot) error {
+	// Replaced machines had their lease released once they were launched.
+	if !lm.HasLease() {
+		if err := lm.AcquireLease(ctx, md.leaseTimeout); err != nil {
+			return err
+		}
+		defer releaseLease(ctx, lm)
+	}
+
+	input := fly.LaunchMachineInput{
+		Region:     snapshot.region,
+		Config:     snapshot.config,
+		SkipLaunch: shouldSkipLaunch(&fly.Machine{State: snapshot.state}, snapshot.config),
+	}
+	if err := lm.Update(ctx, input); err != nil {
+		return err
+	}
+
+	if input.SkipLaunch || md.skipHealthChecks {
+		return nil
+	}
+
+	return lm.WaitForState(ctx, fly.MachineStateStarted, md.waitTimeout)
+}
@@ -0,0 +1,77 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestProgressiveTargets(t *testing.T) {
+	assert.Equal(t, []int{1, 5, 10}, progressiveTargets(10, []float64{10, 50, 100}))
+	assert.Equal(t, []int{1, 2, 3}, progressiveTargets(3, []float64{10, 50, 100}))
+	assert.Equal(t, []int{1, 1, 1}, progressiveTargets(1, []float64{10, 50, 100}))
+	assert.Equal(t, []int{0, 0}, progressiveTargets(0, []float64{50, 100}))
+	assert.Equal(t, []int{25, 100}, progressiveTargets(100, []float64{25, 100}))
+}
+
+func TestProgressiveSettingsFromConfig(t *testing.T) {
+	settings := progressiveSettingsFromConfig(&appconfig.Config{})
+	assert.Equal(t, defaultProgressiveSteps, settings.steps)
+	assert.Equal(t, defaultProgressivePause, settings.pause)
+
+	maxFailed := 2
+	threshold := 0.1
+	settings = progressiveSettingsFromConfig(&appconfig.Config{
+		Deploy: &appconfig.Deploy{
+			Progressive: &appconfig.Progressive{
+				Steps:            []float64{20, 60},
+				Pause:            fly.MustParseDuration("30s"),
+				MaxFailedChecks:  &maxFailed,
+				MetricsQuery:     "errors",
+				MetricsThreshold: &threshold,
+			},
+		},
+	})
+	assert.Equal(t, []float64{20, 60, 100}, settings.steps)
+	assert.Equal(t, 30*time.Second, settings.pause)
+	assert.Equal(t, 2, settings.maxFailedChecks)
+	assert.Equal(t, "errors", settings.metricsQuery)
+	assert.Equal(t, 0.1, settings.metricsThreshold)
+}
+
+func TestQueryPrometheus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch r.URL.Query().Get("query") {
+		case "vector":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"app":"a"},"value":[1700000000,"0.5"]},{"metric":{"app":"b"},"value":[1700000000,"2"]}]}}`))
+		case "scalar":
+			w.Write([]byte(`{"status":"success","data":{"resultType":"scalar","result":[1700000000,"1.5"]}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","error":"parse error"}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	samples, err := queryPrometheus(ctx, server.Client(), server.URL, "token", "vector")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 2}, samples)
+
+	samples, err = queryPrometheus(ctx, server.Client(), server.URL, "token", "scalar")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1.5}, samples)
+
+	_, err = queryPrometheus(ctx, server.Client(), server.URL, "token", "bad(")
+	assert.ErrorContains(t, err, "parse error")
+}
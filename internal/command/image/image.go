@@ -23,6 +23,7 @@ func New() *cobra.Command {
 	cmd.AddCommand(
 		newShow(),
 		newUpdate(),
+		newVerify(),
 	)
 
 	return cmd
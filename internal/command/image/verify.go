@@ -0,0 +1,420 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	provenancetypes "github.com/moby/buildkit/solver/llbsolver/provenance/types"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	// Annotations BuildKit sets on the attestation manifests of an index.
+	referenceTypeAnnotation   = "vnd.docker.reference.type"
+	referenceDigestAnnotation = "vnd.docker.reference.digest"
+	attestationManifestType   = "attestation-manifest"
+
+	// predicateTypeAnnotation is set on the layers of attestation manifests.
+	predicateTypeAnnotation = "in-toto.io/predicate-type"
+)
+
+func newVerify() *cobra.Command {
+	const (
+		long = `Verify the SBOM and SLSA provenance statements attached to an image by
+'fly deploy --attest'. Without an image, verifies the images the app's Machines run.
+
+The statements aren't signed. Anyone who can push to the app's repository can
+push an image with forged statements, so they only show how an image was built,
+not that it's authentic.`
+		short = "Verify the attestations of an image."
+		usage = "verify [image]"
+	)
+
+	cmd := command.New(usage, short, long, runVerify,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		flag.String{
+			Name:        "source-revision",
+			Description: "Require the provenance of the image to record this git commit",
+		},
+	)
+
+	return cmd
+}
+
+// verifyTarget is an image to verify. Machines record the digest of the image
+// of their platform, which the attestations of the index of its tag refer to.
+type verifyTarget struct {
+	ref string
+	tag string
+}
+
+// platformAttestation describes the statements attached to the image of a
+// platform.
+type platformAttestation struct {
+	Image            string `json:"image"`
+	Platform         string `json:"platform"`
+	Digest           string `json:"digest"`
+	SBOM             string `json:"sbom,omitempty"`
+	Provenance       string `json:"provenance,omitempty"`
+	BuilderID        string `json:"builder_id,omitempty"`
+	SourceRepository string `json:"source_repository,omitempty"`
+	SourceRevision   string `json:"source_revision,omitempty"`
+	BuildArgsDigest  string `json:"build_args_digest,omitempty"`
+}
+
+func runVerify(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		cfg     = config.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	var targets []verifyTarget
+	switch {
+	case flag.FirstArg(ctx) != "":
+		targets = append(targets, verifyTarget{ref: flag.FirstArg(ctx)})
+	case appName != "":
+		machines, err := flapsutil.ClientFromContext(ctx).List(ctx, appName, "")
+		if err != nil {
+			return fmt.Errorf("failed to get machines: %w", err)
+		}
+
+		for _, machine := range machines {
+			image := machine.ImageRef
+			repo := image.Registry + "/" + image.Repository
+
+			target := verifyTarget{ref: repo + ":" + image.Tag}
+			if image.Digest != "" {
+				target = verifyTarget{ref: repo + "@" + image.Digest, tag: target.ref}
+			}
+			if !slices.Contains(targets, target) {
+				targets = append(targets, target)
+			}
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("app %s has no machines", appName)
+		}
+	default:
+		return errors.New("an image or an app is required")
+	}
+
+	var (
+		attestations []platformAttestation
+		problems     []string
+	)
+	for _, target := range targets {
+		found, err := fetchAttestations(ctx, cfg, target)
+		if err != nil {
+			return err
+		}
+		attestations = append(attestations, found...)
+	}
+
+	revision := flag.GetString(ctx, "source-revision")
+	for _, a := range attestations {
+		problems = append(problems, a.problems(revision)...)
+	}
+
+	if cfg.JSONOutput {
+		if err := render.JSON(io.Out, attestations); err != nil {
+			return err
+		}
+	} else {
+		var rows [][]string
+		for _, a := range attestations {
+			rows = append(rows, []string{
+				a.Image,
+				a.Platform,
+				orNone(a.SBOM),
+				orNone(a.Provenance),
+				a.BuilderID,
+				a.SourceRevision,
+				a.BuildArgsDigest,
+			})
+		}
+
+		if err := render.Table(io.Out, "", rows, "Image", "Platform", "SBOM", "Provenance", "Builder", "Source revision", "Build args digest"); err != nil {
+			return err
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("verification failed:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+
+	return s
+}
+
+// fetchAttestations returns the attestations of the images of target.
+func fetchAttestations(ctx context.Context, cfg *config.Config, target verifyTarget) ([]platformAttestation, error) {
+	ref, err := name.ParseReference(target.ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image %s: %w", target.ref, err)
+	}
+
+	opts := []remote.Option{remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+	if ref.Context().RegistryStr() == cfg.RegistryHost {
+		opts = []remote.Option{remote.WithContext(ctx), remote.WithAuth(&authn.Basic{
+			Username: "x",
+			Password: cfg.Tokens.Docker(),
+		})}
+	}
+
+	desc, err := remote.Get(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image %s: %w", target.ref, err)
+	}
+
+	var subject string
+	if !desc.MediaType.IsIndex() {
+		if target.tag == "" {
+			return nil, fmt.Errorf("image %s has no attestations: it isn't an image index, which attestations are pushed in", target.ref)
+		}
+
+		subject = desc.Digest.String()
+		if ref, err = name.ParseReference(target.tag); err == nil {
+			desc, err = remote.Get(ref, opts...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch image %s: %w", target.tag, err)
+		}
+		if !desc.MediaType.IsIndex() {
+			return nil, fmt.Errorf("image %s has no attestations: it isn't an image index, which attestations are pushed in", target.tag)
+		}
+	}
+
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	attestations, err := indexAttestations(idx, ref.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the attestations of %s: %w", target.ref, err)
+	}
+
+	if subject != "" {
+		attestations = slices.DeleteFunc(attestations, func(a platformAttestation) bool {
+			return a.Digest != subject
+		})
+		if len(attestations) == 0 {
+			return nil, fmt.Errorf("image %s isn't in the index of %s", target.ref, target.tag)
+		}
+	}
+
+	return attestations, nil
+}
+
+// indexAttestations returns the attestations of the images of idx, named
+// image.
+func indexAttestations(idx v1.ImageIndex, image string) ([]platformAttestation, error) {
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		attestations []platformAttestation
+		attestedBy   = map[string]v1.Hash{}
+	)
+	for _, desc := range manifest.Manifests {
+		if desc.Annotations[referenceTypeAnnotation] == attestationManifestType {
+			attestedBy[desc.Annotations[referenceDigestAnnotation]] = desc.Digest
+
+			continue
+		}
+
+		a := platformAttestation{
+			Image:  image,
+			Digest: desc.Digest.String(),
+		}
+		if desc.Platform != nil {
+			a.Platform = desc.Platform.String()
+		}
+		attestations = append(attestations, a)
+	}
+
+	for i, a := range attestations {
+		digest, ok := attestedBy[a.Digest]
+		if !ok {
+			continue
+		}
+
+		img, err := idx.Image(digest)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := readStatements(img, &attestations[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return attestations, nil
+}
+
+// statement is an in-toto statement.
+type statement struct {
+	PredicateType string             `json:"predicateType"`
+	Subject       []statementSubject `json:"subject"`
+	Predicate     json.RawMessage    `json:"predicate"`
+}
+
+type statementSubject struct {
+	Digest map[string]string `json:"digest"`
+}
+
+// readStatements records the statements of the attestation manifest img about
+// the image of a in a.
+func readStatements(img v1.Image, a *platformAttestation) error {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range manifest.Layers {
+		predicateType := desc.Annotations[predicateTypeAnnotation]
+		if predicateType != imgsrc.SBOMPredicateType && !slices.Contains(imgsrc.ProvenancePredicateTypes, predicateType) {
+			continue
+		}
+
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return err
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+
+		var st statement
+		if err := json.Unmarshal(data, &st); err != nil {
+			return fmt.Errorf("invalid statement %s: %w", desc.Digest, err)
+		}
+		if !st.about(a.Digest) {
+			continue
+		}
+
+		if predicateType == imgsrc.SBOMPredicateType {
+			a.SBOM = predicateType
+
+			continue
+		}
+
+		a.Provenance = predicateType
+		if err := a.readProvenance(st); err != nil {
+			return fmt.Errorf("invalid provenance %s: %w", desc.Digest, err)
+		}
+	}
+
+	return nil
+}
+
+// about reports whether the subject of st is the image of digest.
+func (st statement) about(digest string) bool {
+	algorithm, encoded, _ := strings.Cut(digest, ":")
+
+	return slices.ContainsFunc(st.Subject, func(s statementSubject) bool {
+		return s.Digest[algorithm] == encoded
+	})
+}
+
+// readProvenance records the builder, source and build args digest of the
+// provenance statement st in a.
+func (a *platformAttestation) readProvenance(st statement) error {
+	var (
+		args    map[string]string
+		vcs     map[string]string
+		builder string
+	)
+
+	if st.PredicateType == "https://slsa.dev/provenance/v0.2" {
+		var p provenancetypes.ProvenancePredicateSLSA02
+		if err := json.Unmarshal(st.Predicate, &p); err != nil {
+			return err
+		}
+		builder = p.Builder.ID
+		args = p.Invocation.Parameters.Args
+		if p.Metadata != nil {
+			vcs = p.Metadata.BuildKitMetadata.VCS
+		}
+	} else {
+		var p provenancetypes.ProvenancePredicateSLSA1
+		if err := json.Unmarshal(st.Predicate, &p); err != nil {
+			return err
+		}
+		builder = p.RunDetails.Builder.ID
+		args = p.BuildDefinition.ExternalParameters.Request.Args
+		if p.RunDetails.Metadata != nil {
+			vcs = p.RunDetails.Metadata.BuildKitMetadata.VCS
+		}
+	}
+
+	a.BuilderID = builder
+	a.BuildArgsDigest = args[imgsrc.BuildArgsDigestAttr]
+	a.SourceRepository = vcs["source"]
+	a.SourceRevision = vcs["revision"]
+
+	return nil
+}
+
+// problems returns why a doesn't verify, requiring the source revision to be
+// revision when set.
+func (a platformAttestation) problems(revision string) []string {
+	image := a.Image + "@" + a.Digest
+	if a.Platform != "" {
+		image += " (" + a.Platform + ")"
+	}
+
+	var problems []string
+	if a.SBOM == "" {
+		problems = append(problems, fmt.Sprintf("%s has no SBOM", image))
+	}
+	if a.Provenance == "" {
+		problems = append(problems, fmt.Sprintf("%s has no provenance", image))
+	} else if revision != "" && a.SourceRevision != revision {
+		problems = append(problems, fmt.Sprintf("%s was built from revision %q, not %q", image, a.SourceRevision, revision))
+	}
+
+	return problems
+}
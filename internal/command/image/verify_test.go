@@ -0,0 +1,130 @@
+package image
+
+import (
+	"encoding/json"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attestationManifest returns an attestation manifest of statements of the
+// given predicate types and predicates about digest.
+func attestationManifest(t *testing.T, digest v1.Hash, predicates map[string]any) v1.Image {
+	t.Helper()
+
+	img := empty.Image
+	for predicateType, predicate := range predicates {
+		data, err := json.Marshal(map[string]any{
+			"_type":         "https://in-toto.io/Statement/v0.1",
+			"predicateType": predicateType,
+			"subject":       []any{map[string]any{"digest": map[string]string{digest.Algorithm: digest.Hex}}},
+			"predicate":     predicate,
+		})
+		require.NoError(t, err)
+
+		img, err = mutate.Append(img, mutate.Addendum{
+			Layer:       static.NewLayer(data, "application/vnd.in-toto+json"),
+			Annotations: map[string]string{predicateTypeAnnotation: predicateType},
+		})
+		require.NoError(t, err)
+	}
+
+	return img
+}
+
+func TestIndexAttestations(t *testing.T) {
+	amd64, err := random.Image(64, 1)
+	require.NoError(t, err)
+	amd64Digest, err := amd64.Digest()
+	require.NoError(t, err)
+
+	arm64, err := random.Image(64, 1)
+	require.NoError(t, err)
+
+	attestation := attestationManifest(t, amd64Digest, map[string]any{
+		"https://spdx.dev/Document": map[string]any{"spdxVersion": "SPDX-2.3"},
+		"https://slsa.dev/provenance/v0.2": map[string]any{
+			"builder": map[string]any{"id": "https://fly.io/builders/depot"},
+			"invocation": map[string]any{
+				"parameters": map[string]any{
+					"args": map[string]string{"fly:build-args-digest": "sha256:args"},
+				},
+			},
+			"metadata": map[string]any{
+				"https://mobyproject.org/buildkit@v1#metadata": map[string]any{
+					"vcs": map[string]string{"source": "https://github.com/superfly/flyctl.git", "revision": "0123abc"},
+				},
+			},
+		},
+	})
+
+	idx := mutate.IndexMediaType(empty.Index, types.OCIImageIndex)
+	idx = mutate.AppendManifests(idx,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+		mutate.IndexAddendum{Add: attestation, Descriptor: v1.Descriptor{
+			Platform: &v1.Platform{OS: "unknown", Architecture: "unknown"},
+			Annotations: map[string]string{
+				referenceTypeAnnotation:   attestationManifestType,
+				referenceDigestAnnotation: amd64Digest.String(),
+			},
+		}},
+	)
+
+	attestations, err := indexAttestations(idx, "registry.fly.io/web:deployment-1")
+	require.NoError(t, err)
+	require.Len(t, attestations, 2)
+
+	assert.Equal(t, platformAttestation{
+		Image:            "registry.fly.io/web:deployment-1",
+		Platform:         "linux/amd64",
+		Digest:           amd64Digest.String(),
+		SBOM:             "https://spdx.dev/Document",
+		Provenance:       "https://slsa.dev/provenance/v0.2",
+		BuilderID:        "https://fly.io/builders/depot",
+		SourceRepository: "https://github.com/superfly/flyctl.git",
+		SourceRevision:   "0123abc",
+		BuildArgsDigest:  "sha256:args",
+	}, attestations[0])
+	assert.Empty(t, attestations[0].problems(""))
+	assert.Empty(t, attestations[0].problems("0123abc"))
+	assert.Len(t, attestations[0].problems("4567def"), 1)
+
+	assert.Equal(t, "linux/arm64", attestations[1].Platform)
+	assert.Empty(t, attestations[1].SBOM)
+	assert.Len(t, attestations[1].problems(""), 2)
+}
+
+func TestReadProvenanceSLSA1(t *testing.T) {
+	predicate, err := json.Marshal(map[string]any{
+		"buildDefinition": map[string]any{
+			"externalParameters": map[string]any{
+				"request": map[string]any{
+					"args": map[string]string{"fly:build-args-digest": "sha256:args"},
+				},
+			},
+		},
+		"runDetails": map[string]any{
+			"builder": map[string]any{"id": "https://fly.io/builders/buildkit"},
+			"metadata": map[string]any{
+				"buildkit_metadata": map[string]any{
+					"vcs": map[string]string{"revision": "0123abc"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	var a platformAttestation
+	require.NoError(t, a.readProvenance(statement{PredicateType: "https://slsa.dev/provenance/v1", Predicate: predicate}))
+	assert.Equal(t, "https://fly.io/builders/buildkit", a.BuilderID)
+	assert.Equal(t, "sha256:args", a.BuildArgsDigest)
+	assert.Equal(t, "0123abc", a.SourceRevision)
+}
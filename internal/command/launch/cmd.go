@@ -91,6 +91,14 @@ func New() (cmd *cobra.Command) {
 			Name:        "into",
 			Description: "Destination directory for github repo specified with --from",
 		},
+		flag.String{
+			Name:        "template",
+			Description: "A launch template to render into the source directory, as a directory or git repo URL. Append #path to pick a template out of a catalog repo",
+		},
+		flag.StringArray{
+			Name:        "template-input",
+			Description: "Set an input of the --template in the form of NAME=VALUE instead of being prompted for it. Can be specified multiple times.",
+		},
 		flag.Bool{
 			Name:        "attach",
 			Description: "Attach this new application to the current application",
@@ -183,6 +191,15 @@ func New() (cmd *cobra.Command) {
 			Description: "Provision a Postgres database. Options: mpg (managed postgres), upg/legacy (unmanaged postgres), or true (default type)",
 			NoOptDefVal: "true",
 		},
+		flag.String{
+			Name:        "compose",
+			Description: "Deploy the services of a Docker Compose file, found in the source directory unless given",
+			NoOptDefVal: "true",
+		},
+		flag.Bool{
+			Name:        "split",
+			Description: "Run every service of the --compose file, or group of services sharing an x-fly-process-group, in its own process group with its own VM size, services and scale",
+		},
 	}
 
 	flag.Add(cmd, flags...)
@@ -343,6 +360,14 @@ func run(ctx context.Context) (err error) {
 		return err
 	}
 
+	if err := validation.ValidatePlatforms(flag.GetPlatforms(ctx)); err != nil {
+		return err
+	}
+
+	if err := validateComposeFlags(ctx); err != nil {
+		return err
+	}
+
 	var (
 		launchManifest *LaunchManifest
 		cache          *planBuildCache
@@ -400,6 +425,12 @@ func run(ctx context.Context) (err error) {
 		return err
 	}
 
+	// "--template" arg handling
+	ctx, err = setupFromLaunchTemplate(ctx)
+	if err != nil {
+		return err
+	}
+
 	incompleteLaunchManifest := false
 	canEnterUi := !flag.GetBool(ctx, "manifest") && io.IsInteractive() && !env.IsCI()
 
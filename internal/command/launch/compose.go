@@ -0,0 +1,174 @@
+package launch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/containerconfig"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// applyComposeFlags has the app deploy the services of the compose file of the
+// --compose flag, split in process groups with --split.
+func (state *launchState) applyComposeFlags(ctx context.Context) error {
+	file := flag.GetString(ctx, "compose")
+	if file == "" {
+		return nil
+	}
+
+	if file == "true" {
+		for _, name := range appconfig.WellKnownComposeFilenames {
+			if _, err := os.Stat(filepath.Join(state.workingDir, name)); err == nil {
+				file = name
+
+				break
+			}
+		}
+		if file == "true" {
+			return fmt.Errorf("no compose file found in %s, looked for %s", state.workingDir, strings.Join(appconfig.WellKnownComposeFilenames, ", "))
+		}
+	} else if rel, err := filepath.Rel(state.workingDir, file); err == nil && filepath.IsAbs(file) {
+		file = rel
+	}
+
+	if state.appConfig.Build == nil {
+		state.appConfig.Build = &appconfig.Build{}
+	}
+	state.appConfig.Build.Compose = &appconfig.BuildCompose{File: file}
+
+	if !flag.GetBool(ctx, "split") {
+		return nil
+	}
+
+	groups, err := containerconfig.ComposeGroupPlans(filepath.Join(state.workingDir, file))
+	if err != nil {
+		return err
+	}
+
+	io := iostreams.FromContext(ctx)
+	for _, note := range splitCompose(state.appConfig, groups) {
+		fmt.Fprintln(io.Out, note)
+	}
+
+	return nil
+}
+
+// splitCompose runs every process group of a compose file in its own Machines,
+// with its own [[vm]], services and volume. It returns notes about what the
+// generated config doesn't do.
+func splitCompose(appConfig *appconfig.Config, groups []containerconfig.ComposeGroup) (notes []string) {
+	appConfig.Build.Compose.Split = true
+
+	// The services of the plan run the image of the app, which the services
+	// of the compose file replace.
+	appConfig.HTTPService = nil
+	appConfig.Services = nil
+
+	base := &appconfig.Compute{}
+	if i := slices.IndexFunc(appConfig.Compute, isComputeValid); i >= 0 {
+		base = helpers.Clone(appConfig.Compute[i])
+	}
+
+	appConfig.Processes = map[string]string{}
+	appConfig.Compute = nil
+	mountVolumes := len(appConfig.Mounts) == 0
+	httpGroup := ""
+	for _, group := range groups {
+		// The containers of the group run their own command
+		appConfig.Processes[group.Name] = ""
+
+		compute := helpers.Clone(base)
+		compute.Processes = []string{group.Name}
+		if group.Memory != "" {
+			compute.Memory = group.Memory
+			if compute.MachineGuest != nil {
+				compute.MemoryMB = 0
+			}
+		}
+		appConfig.Compute = append(appConfig.Compute, compute)
+
+		for _, port := range group.Ports {
+			// One service serves both ports 80 and 443 of a single group
+			if isHTTPPort(port) {
+				if httpGroup == group.Name {
+					continue
+				}
+				if httpGroup != "" {
+					notes = append(notes, fmt.Sprintf("Port %d of process group %s isn't served: process group %s serves HTTP", port.Published, group.Name, httpGroup))
+
+					continue
+				}
+				httpGroup = group.Name
+			}
+
+			appConfig.Services = append(appConfig.Services, composePortService(group.Name, port))
+		}
+
+		if group.Volume != nil && mountVolumes {
+			appConfig.Mounts = append(appConfig.Mounts, appconfig.Mount{
+				Source:      group.Volume.Name,
+				Destination: group.Volume.Path,
+				Processes:   []string{group.Name},
+			})
+		}
+
+		if group.Replicas > 1 {
+			notes = append(notes, fmt.Sprintf("Process group %s runs %d replicas in the compose file: run 'fly scale count %s=%d' after deploying", group.Name, group.Replicas, group.Name, group.Replicas))
+		}
+		if len(group.Services) > 1 || group.Services[0] != group.Name {
+			notes = append(notes, fmt.Sprintf("Services %s run in process group %s: they reach each other on localhost, and other process groups reach them as %s", strings.Join(group.Services, ", "), group.Name, group.Name))
+		}
+	}
+
+	return notes
+}
+
+// composePortService returns the service of a port a compose service
+// publishes. Ports 80 and 443 are served over HTTP(S) by Machines stopped when
+// idle, as launch does for HTTP services. Other ports are passed through.
+func composePortService(group string, port containerconfig.ComposePort) appconfig.Service {
+	service := appconfig.Service{
+		Protocol:     port.Protocol,
+		InternalPort: port.Target,
+		Processes:    []string{group},
+	}
+
+	if !isHTTPPort(port) {
+		service.Ports = []fly.MachinePort{{Port: new(port.Published)}}
+
+		return service
+	}
+
+	service.Ports = []fly.MachinePort{
+		{Port: new(80), Handlers: []string{"http"}, ForceHTTPS: true},
+		{Port: new(443), Handlers: []string{"http", "tls"}},
+	}
+	service.AutoStopMachines = new(fly.MachineAutostopStop)
+	service.AutoStartMachines = new(true)
+	service.MinMachinesRunning = new(0)
+
+	return service
+}
+
+// isHTTPPort reports whether port is served over HTTP(S).
+func isHTTPPort(port containerconfig.ComposePort) bool {
+	return port.Protocol == "tcp" && (port.Published == 80 || port.Published == 443)
+}
+
+// validateComposeFlags fails when --split is set without --compose.
+func validateComposeFlags(ctx context.Context) error {
+	if flag.GetBool(ctx, "split") && flag.GetString(ctx, "compose") == "" {
+		return errors.New("--split requires --compose")
+	}
+
+	return nil
+}
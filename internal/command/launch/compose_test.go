@@ -0,0 +1,68 @@
+package launch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/containerconfig"
+)
+
+func TestSplitCompose(t *testing.T) {
+	appConfig := &appconfig.Config{
+		Build: &appconfig.Build{Compose: &appconfig.BuildCompose{File: "compose.yml"}},
+		HTTPService: &appconfig.HTTPService{
+			InternalPort: 8080,
+		},
+		Compute: []*appconfig.Compute{{Memory: "256mb"}},
+	}
+
+	notes := splitCompose(appConfig, []containerconfig.ComposeGroup{
+		{Name: "backend", Services: []string{"api", "worker"}, Memory: "1G", Replicas: 3},
+		{
+			Name:     "db",
+			Services: []string{"db"},
+			Ports:    []containerconfig.ComposePort{{Published: 5432, Target: 5432, Protocol: "tcp"}},
+			Volume:   &containerconfig.ComposeVolume{Name: "pg_data", Path: "/var/lib/postgresql/data"},
+			Replicas: 1,
+		},
+		{
+			Name:     "web",
+			Services: []string{"web"},
+			Ports: []containerconfig.ComposePort{
+				{Published: 80, Target: 8080, Protocol: "tcp"},
+				{Published: 443, Target: 8443, Protocol: "tcp"},
+			},
+			Replicas: 1,
+		},
+	})
+
+	assert.True(t, appConfig.ComposeSplit())
+	assert.Nil(t, appConfig.HTTPService)
+	assert.Equal(t, map[string]string{"backend": "", "db": "", "web": ""}, appConfig.Processes)
+
+	require.Len(t, appConfig.Compute, 3)
+	assert.Equal(t, []string{"backend"}, appConfig.Compute[0].Processes)
+	assert.Equal(t, "1G", appConfig.Compute[0].Memory)
+	assert.Equal(t, "256mb", appConfig.Compute[1].Memory)
+
+	require.Len(t, appConfig.Services, 2)
+	assert.Equal(t, []string{"db"}, appConfig.Services[0].Processes)
+	assert.Equal(t, 5432, *appConfig.Services[0].Ports[0].Port)
+	assert.Empty(t, appConfig.Services[0].Ports[0].Handlers)
+	assert.Equal(t, []string{"web"}, appConfig.Services[1].Processes)
+	assert.Equal(t, 8080, appConfig.Services[1].InternalPort)
+	assert.Equal(t, []string{"http", "tls"}, appConfig.Services[1].Ports[1].Handlers)
+
+	assert.Equal(t, []appconfig.Mount{{
+		Source:      "pg_data",
+		Destination: "/var/lib/postgresql/data",
+		Processes:   []string{"db"},
+	}}, appConfig.Mounts)
+
+	assert.Equal(t, []string{
+		"Process group backend runs 3 replicas in the compose file: run 'fly scale count backend=3' after deploying",
+		"Services api, worker run in process group backend: they reach each other on localhost, and other process groups reach them as backend",
+	}, notes)
+}
@@ -171,6 +171,10 @@ func (state *launchState) Launch(ctx context.Context) error {
 		}
 	}
 
+	if err := state.applyComposeFlags(ctx); err != nil {
+		return err
+	}
+
 	// Finally write application configuration to fly.toml
 	configDir, configFile := filepath.Split(state.configPath)
 	configFileOverride := flag.GetString(ctx, flagnames.AppConfigFilePath)
@@ -485,9 +485,11 @@ func determineBaseAppConfig(ctx context.Context) (*appconfig.Config, bool, error
 		// (e.g. the deployer passing --config fly.api-server.toml). Treat it as
 		// copy-config so we never prompt and never fall back to source scanning.
 		explicitConfig := flag.IsSpecified(ctx, "config")
-		copyConfig := flag.GetBool(ctx, "copy-config") || attach || explicitConfig
+		// A fly.toml rendered from a --template is the configuration of the app.
+		fromTemplate := templateSourceInfoFromContext(ctx) != nil
+		copyConfig := flag.GetBool(ctx, "copy-config") || attach || explicitConfig || fromTemplate
 
-		if !flag.IsSpecified(ctx, "copy-config") && !attach && !explicitConfig && !flag.GetYes(ctx) {
+		if !flag.IsSpecified(ctx, "copy-config") && !attach && !explicitConfig && !fromTemplate && !flag.GetYes(ctx) {
 			var err error
 			copyConfig, err = prompt.Confirm(ctx, colorize.Yellow("Would you like to use this fly.toml configuration for this app?"))
 			fmt.Fprintln(io.Out)
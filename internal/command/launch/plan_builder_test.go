@@ -15,6 +15,7 @@ import (
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/mock"
 	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/scanner"
 )
 
 func newDetermineOrgCtx(t *testing.T, orgFlag string) context.Context {
@@ -219,6 +220,19 @@ func TestDetermineBaseAppConfig(t *testing.T) {
 		assert.Equal(t, "docker.ui-server.dockerfile", cfg.Build.Dockerfile)
 	})
 
+	t.Run("config rendered from a template is adopted without prompting", func(t *testing.T) {
+		ctx := newDetermineBaseAppConfigCtx(t, false, false)
+		ctx = appconfig.WithConfig(ctx, existingCfg)
+		ctx = withTemplateSourceInfo(ctx, &scanner.SourceInfo{Family: "go-service"})
+		ios, _, _, _ := iostreams.Test()
+		ctx = iostreams.NewContext(ctx, ios)
+
+		cfg, copied, err := determineBaseAppConfig(ctx)
+		require.NoError(t, err)
+		assert.True(t, copied)
+		assert.Equal(t, "docker.ui-server.dockerfile", cfg.Build.Dockerfile)
+	})
+
 	t.Run("no flags in non-interactive mode returns error", func(t *testing.T) {
 		ctx := newDetermineBaseAppConfigCtx(t, false, false)
 		ctx = appconfig.WithConfig(ctx, existingCfg)
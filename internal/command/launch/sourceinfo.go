@@ -12,11 +12,34 @@ import (
 	"github.com/logrusorgru/aurora"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command/launch/plan"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/iostreams"
 	"github.com/superfly/flyctl/scanner"
 )
 
+// scannerPlugins returns the scanner plugins of the flyctl config file followed
+// by the ones found on PATH that the config file doesn't configure.
+func scannerPlugins(ctx context.Context) []scanner.Plugin {
+	var plugins []scanner.Plugin
+	configured := map[string]bool{}
+
+	if cfg := config.FromContextIfPresent(ctx); cfg != nil {
+		for _, p := range cfg.ScannerPlugins {
+			plugins = append(plugins, scanner.Plugin{Name: p.Name, Path: p.Path, Order: p.Order})
+			configured[p.Name] = true
+		}
+	}
+
+	for _, p := range scanner.DiscoverPlugins() {
+		if !configured[p.Name] {
+			plugins = append(plugins, p)
+		}
+	}
+
+	return plugins
+}
+
 func determineSourceInfo(ctx context.Context, appConfig *appconfig.Config, copyConfig bool, workingDir string) (*scanner.SourceInfo, *appconfig.Build, error) {
 	io := iostreams.FromContext(ctx)
 	build := &appconfig.Build{}
@@ -27,6 +50,7 @@ func determineSourceInfo(ctx context.Context, appConfig *appconfig.Config, copyC
 		ExistingPort: appConfig.InternalPort(),
 		Mode:         "launch",
 		Colorize:     io.ColorScheme(),
+		Plugins:      scannerPlugins(ctx),
 	}
 	// Detect if --copy-config and --now flags are set. If so, limited set of
 	// fly.toml file updates. Helpful for deploying PRs when the project is
@@ -35,6 +59,12 @@ func determineSourceInfo(ctx context.Context, appConfig *appconfig.Config, copyC
 		scannerConfig.Mode = "clone"
 	}
 
+	if templateInfo := templateSourceInfoFromContext(ctx); templateInfo != nil {
+		fmt.Fprintf(io.Out, "Using template %s\n", aurora.Green(templateInfo.Family))
+
+		return templateInfo, appConfig.Build, nil
+	}
+
 	if img := flag.GetString(ctx, "image"); img != "" {
 		fmt.Fprintln(io.Out, "Using image", img)
 		build.Image = img
@@ -87,6 +117,13 @@ func determineSourceInfo(ctx context.Context, appConfig *appconfig.Config, copyC
 			build.Dockerfile = dockerfilePath
 		}
 	}
+	if srcInfo != nil && srcInfo.BuildContext != "" {
+		// The Dockerfile is resolved from the build context unless fly.toml
+		// points at the one generated in the working directory.
+		build.Dockerfile = "Dockerfile"
+		build.Ignorefile = srcInfo.Ignorefile
+		build.Context = srcInfo.BuildContext
+	}
 
 	if srcInfo == nil {
 		var colorFn func(arg any) aurora.Value
@@ -0,0 +1,486 @@
+package launch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/scanner"
+)
+
+// templateManifestFileName is the file describing the inputs, secrets and
+// databases of a launch template. It isn't copied into the app.
+const templateManifestFileName = "fly-template.toml"
+
+// templateFileSuffix marks the files of a launch template rendered with the
+// inputs of the template. The suffix is dropped from the files written.
+const templateFileSuffix = ".tmpl"
+
+// launchTemplate is a directory of files, such as fly.toml and a Dockerfile,
+// a new app starts from.
+type launchTemplate struct {
+	Name        string           `toml:"name"`
+	Description string           `toml:"description"`
+	Inputs      []templateInput  `toml:"inputs"`
+	Secrets     []templateSecret `toml:"secrets"`
+
+	Postgres      bool `toml:"postgres"`
+	Redis         bool `toml:"redis"`
+	ObjectStorage bool `toml:"object_storage"`
+
+	dir string
+}
+
+// templateInput is a typed value a launch template is rendered with.
+type templateInput struct {
+	Name        string   `toml:"name"`
+	Description string   `toml:"description"`
+	Type        string   `toml:"type"`
+	Default     any      `toml:"default"`
+	Options     []string `toml:"options"`
+	Required    bool     `toml:"required"`
+}
+
+const (
+	templateInputString = "string"
+	templateInputInt    = "int"
+	templateInputBool   = "bool"
+	templateInputChoice = "choice"
+)
+
+// templateSecret is a secret set on apps launched from a template. Secrets
+// without a value or generated value are prompted for.
+type templateSecret struct {
+	Key      string `toml:"key"`
+	Help     string `toml:"help"`
+	Value    string `toml:"value"`
+	Generate bool   `toml:"generate"`
+}
+
+// templateData is what the files and secret values of a template are
+// rendered with.
+type templateData struct {
+	Inputs map[string]any
+}
+
+type templateSourceInfoKey struct{}
+
+// withTemplateSourceInfo returns a copy of ctx carrying the source info of the
+// launch template the app is launched from.
+func withTemplateSourceInfo(ctx context.Context, srcInfo *scanner.SourceInfo) context.Context {
+	return context.WithValue(ctx, templateSourceInfoKey{}, srcInfo)
+}
+
+// templateSourceInfoFromContext returns the source info of the launch template
+// the app is launched from, or nil when it isn't launched from one.
+func templateSourceInfoFromContext(ctx context.Context) *scanner.SourceInfo {
+	srcInfo, _ := ctx.Value(templateSourceInfoKey{}).(*scanner.SourceInfo)
+
+	return srcInfo
+}
+
+// setupFromLaunchTemplate renders the launch template of the --template flag
+// into the source directory and loads the fly.toml it renders.
+func setupFromLaunchTemplate(ctx context.Context) (context.Context, error) {
+	src := flag.GetString(ctx, "template")
+	if src == "" {
+		return ctx, nil
+	}
+
+	provided, err := cmdutil.ParseKVStringsToMap(flag.GetStringArray(ctx, "template-input"))
+	if err != nil {
+		return ctx, fmt.Errorf("invalid --template-input: %w", err)
+	}
+
+	dir, cleanup, err := fetchLaunchTemplate(ctx, src)
+	if err != nil {
+		return ctx, err
+	}
+	defer cleanup()
+
+	t, err := loadLaunchTemplate(dir)
+	if err != nil {
+		return ctx, err
+	}
+
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintf(io.Out, "Launching from template %s\n", t.Name)
+	if t.Description != "" {
+		fmt.Fprintln(io.Out, t.Description)
+	}
+
+	inputs, err := t.resolveInputs(ctx, provided)
+	if err != nil {
+		return ctx, err
+	}
+
+	workingDir, err := filepath.Abs(flag.GetString(ctx, "path"))
+	if err != nil {
+		return ctx, err
+	}
+	if err := t.render(ctx, workingDir, inputs); err != nil {
+		return ctx, err
+	}
+
+	srcInfo, err := t.sourceInfo(inputs)
+	if err != nil {
+		return ctx, err
+	}
+	ctx = withTemplateSourceInfo(ctx, srcInfo)
+
+	switch cfg, err := appconfig.LoadConfig(filepath.Join(workingDir, appconfig.DefaultConfigFileName)); {
+	case err == nil:
+		return appconfig.WithConfig(ctx, cfg), nil
+	case errors.Is(err, fs.ErrNotExist):
+		return ctx, nil
+	default:
+		return ctx, fmt.Errorf("failed loading the fly.toml of template %s: %w", t.Name, err)
+	}
+}
+
+// fetchLaunchTemplate returns the directory of the launch template src, which
+// is a local directory or a git repository. Templates of a catalog are picked
+// out of their repository with a #path suffix, as in
+// https://github.com/acme/templates#go-service.
+func fetchLaunchTemplate(ctx context.Context, src string) (dir string, cleanup func(), err error) {
+	cleanup = func() {}
+
+	if info, err := os.Stat(src); err == nil {
+		if !info.IsDir() {
+			return "", cleanup, fmt.Errorf("template %s isn't a directory", src)
+		}
+
+		return src, cleanup, nil
+	}
+
+	repo, subdir, _ := strings.Cut(src, "#")
+
+	tmpDir, err := os.MkdirTemp("", "fly-template-*")
+	if err != nil {
+		return "", cleanup, err
+	}
+	cleanup = func() { os.RemoveAll(tmpDir) }
+
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintf(io.Out, "Fetching template from %s\n", repo)
+
+	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--recurse-submodules", "--", repo, tmpDir)
+	cmd.Stdout = io.ErrOut
+	cmd.Stderr = io.ErrOut
+	if err := cmd.Run(); err != nil {
+		cleanup()
+
+		return "", func() {}, fmt.Errorf("failed to fetch template from %s: %w", repo, err)
+	}
+
+	dir = filepath.Join(tmpDir, filepath.FromSlash(subdir))
+	if rel, err := filepath.Rel(tmpDir, dir); err != nil || strings.HasPrefix(rel, "..") {
+		cleanup()
+
+		return "", func() {}, fmt.Errorf("template path %s is outside of %s", subdir, repo)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		cleanup()
+
+		return "", func() {}, fmt.Errorf("no template at %s in %s", subdir, repo)
+	}
+
+	return dir, cleanup, nil
+}
+
+// loadLaunchTemplate loads the launch template in dir. Templates without a
+// manifest have no inputs.
+func loadLaunchTemplate(dir string) (*launchTemplate, error) {
+	t := &launchTemplate{dir: dir}
+
+	data, err := os.ReadFile(filepath.Join(dir, templateManifestFileName))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := toml.Unmarshal(data, t); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", templateManifestFileName, err)
+		}
+	}
+
+	if t.Name == "" {
+		t.Name = filepath.Base(dir)
+	}
+
+	seen := map[string]bool{}
+	for i, input := range t.Inputs {
+		switch {
+		case input.Name == "":
+			return nil, fmt.Errorf("input %d of template %s has no name", i+1, t.Name)
+		case seen[input.Name]:
+			return nil, fmt.Errorf("template %s has several inputs named %s", t.Name, input.Name)
+		}
+		seen[input.Name] = true
+
+		switch input.Type {
+		case "":
+			t.Inputs[i].Type = templateInputString
+		case templateInputString, templateInputInt, templateInputBool:
+		case templateInputChoice:
+			if len(input.Options) == 0 {
+				return nil, fmt.Errorf("input %s of template %s is a choice without options", input.Name, t.Name)
+			}
+		default:
+			return nil, fmt.Errorf("input %s of template %s has unknown type %s, must be one of string, int, bool or choice", input.Name, t.Name, input.Type)
+		}
+	}
+
+	for _, secret := range t.Secrets {
+		if secret.Key == "" {
+			return nil, fmt.Errorf("template %s has a secret without a key", t.Name)
+		}
+	}
+
+	return t, nil
+}
+
+// resolveInputs returns the values of the inputs of the template. Values are
+// taken from provided, prompted for, or default to the default of the input
+// when prompting isn't possible.
+func (t *launchTemplate) resolveInputs(ctx context.Context, provided map[string]string) (map[string]any, error) {
+	for name := range provided {
+		if !slices.ContainsFunc(t.Inputs, func(i templateInput) bool { return i.Name == name }) {
+			return nil, fmt.Errorf("template %s has no input %s", t.Name, name)
+		}
+	}
+
+	values := make(map[string]any, len(t.Inputs))
+	for _, input := range t.Inputs {
+		def := ""
+		if input.Default != nil {
+			def = fmt.Sprint(input.Default)
+		}
+
+		raw, ok := provided[input.Name]
+		if !ok {
+			var err error
+			if raw, err = input.prompt(ctx, def); err != nil {
+				if !prompt.IsNonInteractive(err) {
+					return nil, err
+				}
+				if input.Required && def == "" {
+					return nil, fmt.Errorf("input %s of template %s is required, set it with --template-input %s=VALUE", input.Name, t.Name, input.Name)
+				}
+				raw = def
+			}
+		}
+
+		value, err := input.parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of input %s of template %s: %w", input.Name, t.Name, err)
+		}
+		values[input.Name] = value
+	}
+
+	return values, nil
+}
+
+// prompt prompts for the value of the input.
+func (i templateInput) prompt(ctx context.Context, def string) (string, error) {
+	msg := i.Name
+	if i.Description != "" {
+		msg = i.Description
+	}
+	msg += ":"
+
+	switch i.Type {
+	case templateInputInt:
+		n, _ := strconv.Atoi(def)
+		if err := prompt.Int(ctx, &n, msg, n, i.Required); err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(n), nil
+	case templateInputBool:
+		confirmed, err := prompt.Confirm(ctx, msg)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatBool(confirmed), nil
+	case templateInputChoice:
+		index := 0
+		if err := prompt.Select(ctx, &index, msg, def, i.Options...); err != nil {
+			return "", err
+		}
+
+		return i.Options[index], nil
+	default:
+		var value string
+		if err := prompt.String(ctx, &value, msg, def, i.Required); err != nil {
+			return "", err
+		}
+
+		return value, nil
+	}
+}
+
+// parse parses raw as a value of the type of the input.
+func (i templateInput) parse(raw string) (any, error) {
+	switch i.Type {
+	case templateInputInt:
+		if raw == "" {
+			return 0, nil
+		}
+
+		return strconv.Atoi(raw)
+	case templateInputBool:
+		if raw == "" {
+			return false, nil
+		}
+
+		return strconv.ParseBool(raw)
+	case templateInputChoice:
+		if !slices.Contains(i.Options, raw) {
+			return nil, fmt.Errorf("%q isn't one of %s", raw, strings.Join(i.Options, ", "))
+		}
+
+		return raw, nil
+	default:
+		if i.Required && raw == "" {
+			return nil, errors.New("a value is required")
+		}
+
+		return raw, nil
+	}
+}
+
+// render writes the files of the template into dir, rendering the ones with
+// the template suffix with inputs. Existing files are only overwritten once
+// confirmed. Templates may only hold regular files: symlinks could make them
+// copy files from outside the template.
+func (t *launchTemplate) render(ctx context.Context, dir string, inputs map[string]any) error {
+	io := iostreams.FromContext(ctx)
+	data := templateData{Inputs: inputs}
+
+	return filepath.WalkDir(t.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(t.dir, path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case d.IsDir(), rel == templateManifestFileName:
+			return nil
+		case !d.Type().IsRegular():
+			return fmt.Errorf("template file %s isn't a regular file, templates can't contain symlinks or special files", rel)
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(rel, templateFileSuffix) {
+			rel = strings.TrimSuffix(rel, templateFileSuffix)
+			if contents, err = renderTemplateString(rel, string(contents), data); err != nil {
+				return err
+			}
+		}
+
+		dst := filepath.Join(dir, rel)
+		if helpers.FileExists(dst) {
+			if !flag.GetYes(ctx) {
+				confirm, err := prompt.ConfirmOverwrite(ctx, dst)
+				if err != nil {
+					return err
+				}
+				if !confirm {
+					return nil
+				}
+			} else {
+				fmt.Fprintf(io.Out, "You specified --yes, overwriting %s\n", dst)
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+
+		return os.WriteFile(dst, contents, info.Mode().Perm())
+	})
+}
+
+// sourceInfo returns the source info of apps launched from the template, which
+// carries the secrets and databases of the template.
+func (t *launchTemplate) sourceInfo(inputs map[string]any) (*scanner.SourceInfo, error) {
+	srcInfo := &scanner.SourceInfo{
+		Family:               t.Name,
+		RedisDesired:         t.Redis,
+		ObjectStorageDesired: t.ObjectStorage,
+	}
+	if t.Postgres {
+		srcInfo.DatabaseDesired = scanner.DatabaseKindPostgres
+	}
+
+	data := templateData{Inputs: inputs}
+	for _, s := range t.Secrets {
+		secret := scanner.Secret{Key: s.Key, Help: s.Help}
+
+		switch {
+		case s.Generate:
+			secret.Generate = func() (string, error) {
+				return helpers.RandString(64)
+			}
+		case s.Value != "":
+			value, err := renderTemplateString("secret "+s.Key, s.Value, data)
+			if err != nil {
+				return nil, err
+			}
+			secret.Value = string(value)
+		}
+
+		srcInfo.Secrets = append(srcInfo.Secrets, secret)
+	}
+
+	return srcInfo, nil
+}
+
+// renderTemplateString renders text with data, failing on inputs the template
+// doesn't have.
+func renderTemplateString(name, text string, data templateData) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
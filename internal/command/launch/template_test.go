@@ -0,0 +1,173 @@
+package launch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/flag/flagctx"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/scanner"
+)
+
+const testTemplateManifest = `
+name = "go-service"
+description = "A Go HTTP service"
+postgres = true
+
+[[inputs]]
+name = "port"
+type = "int"
+default = 8080
+
+[[inputs]]
+name = "tier"
+type = "choice"
+options = ["small", "large"]
+default = "small"
+
+[[inputs]]
+name = "team"
+required = true
+
+[[secrets]]
+key = "SESSION_KEY"
+generate = true
+
+[[secrets]]
+key = "TEAM"
+value = "{{ .Inputs.team }}"
+
+[[secrets]]
+key = "API_TOKEN"
+help = "The token of the internal API"
+`
+
+func writeTestTemplate(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		templateManifestFileName: testTemplateManifest,
+		"fly.toml.tmpl":          "[http_service]\n  internal_port = {{ .Inputs.port }}\n",
+		"Dockerfile":             "FROM golang:1.26\n",
+		"config/tier.txt.tmpl":   "{{ .Inputs.tier }}\n",
+		".git/HEAD":              "ref: refs/heads/main\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+
+	return dir
+}
+
+func newTemplateCtx(t *testing.T) context.Context {
+	t.Helper()
+
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.Bool("yes", false, "")
+
+	return flagctx.NewContext(ctx, flagSet)
+}
+
+func TestLaunchTemplate(t *testing.T) {
+	ctx := newTemplateCtx(t)
+
+	tmpl, err := loadLaunchTemplate(writeTestTemplate(t))
+	require.NoError(t, err)
+	assert.Equal(t, "go-service", tmpl.Name)
+	assert.Equal(t, templateInputString, tmpl.Inputs[2].Type)
+
+	// Prompts aren't possible, so inputs not provided take their default
+	inputs, err := tmpl.resolveInputs(ctx, map[string]string{"team": "payments"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"port": 8080, "tier": "small", "team": "payments"}, inputs)
+
+	dst := t.TempDir()
+	require.NoError(t, tmpl.render(ctx, dst, inputs))
+
+	flyToml, err := os.ReadFile(filepath.Join(dst, "fly.toml"))
+	require.NoError(t, err)
+	assert.Equal(t, "[http_service]\n  internal_port = 8080\n", string(flyToml))
+
+	tier, err := os.ReadFile(filepath.Join(dst, "config", "tier.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "small\n", string(tier))
+
+	assert.FileExists(t, filepath.Join(dst, "Dockerfile"))
+	assert.NoFileExists(t, filepath.Join(dst, templateManifestFileName))
+	assert.NoDirExists(t, filepath.Join(dst, ".git"))
+
+	srcInfo, err := tmpl.sourceInfo(inputs)
+	require.NoError(t, err)
+	assert.Equal(t, "go-service", srcInfo.Family)
+	assert.Equal(t, scanner.DatabaseKindPostgres, srcInfo.DatabaseDesired)
+	require.Len(t, srcInfo.Secrets, 3)
+	assert.NotNil(t, srcInfo.Secrets[0].Generate)
+	assert.Equal(t, "payments", srcInfo.Secrets[1].Value)
+	assert.Equal(t, "The token of the internal API", srcInfo.Secrets[2].Help)
+}
+
+func TestLaunchTemplateSymlink(t *testing.T) {
+	ctx := newTemplateCtx(t)
+
+	dir := writeTestTemplate(t)
+	secret := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(secret, []byte("private key"), 0o600))
+	if err := os.Symlink(secret, filepath.Join(dir, "key")); err != nil {
+		t.Skipf("symlinks aren't supported: %v", err)
+	}
+
+	tmpl, err := loadLaunchTemplate(dir)
+	require.NoError(t, err)
+
+	dst := t.TempDir()
+	err = tmpl.render(ctx, dst, map[string]any{"port": 8080, "tier": "small", "team": "payments"})
+	assert.ErrorContains(t, err, "template file key isn't a regular file")
+	assert.NoFileExists(t, filepath.Join(dst, "key"))
+}
+
+func TestLaunchTemplateInputs(t *testing.T) {
+	ctx := newTemplateCtx(t)
+
+	tmpl, err := loadLaunchTemplate(writeTestTemplate(t))
+	require.NoError(t, err)
+
+	_, err = tmpl.resolveInputs(ctx, nil)
+	assert.ErrorContains(t, err, "input team of template go-service is required")
+
+	_, err = tmpl.resolveInputs(ctx, map[string]string{"team": "payments", "port": "eighty"})
+	assert.ErrorContains(t, err, "invalid value of input port")
+
+	_, err = tmpl.resolveInputs(ctx, map[string]string{"team": "payments", "tier": "huge"})
+	assert.ErrorContains(t, err, `"huge" isn't one of small, large`)
+
+	_, err = tmpl.resolveInputs(ctx, map[string]string{"team": "payments", "region": "ord"})
+	assert.ErrorContains(t, err, "template go-service has no input region")
+}
+
+func TestLoadLaunchTemplateInvalid(t *testing.T) {
+	for name, manifest := range map[string]string{
+		"unknown type":       "[[inputs]]\nname = \"a\"\ntype = \"float\"\n",
+		"choice w/o options": "[[inputs]]\nname = \"a\"\ntype = \"choice\"\n",
+		"duplicate input":    "[[inputs]]\nname = \"a\"\n[[inputs]]\nname = \"a\"\n",
+		"secret without key": "[[secrets]]\nvalue = \"x\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, templateManifestFileName), []byte(manifest), 0o644))
+
+			_, err := loadLaunchTemplate(dir)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -3,16 +3,22 @@ package logs
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/cmdutil/preparers"
+	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/mock"
 	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/logs"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestMachineSelectionFlags(t *testing.T) {
@@ -107,6 +113,121 @@ func TestResolveMachineID(t *testing.T) {
 	})
 }
 
+func TestLogFilter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	t.Run("builds the filter from flags", func(t *testing.T) {
+		client := &mock.FlapsClient{
+			ListActiveFunc: func(_ context.Context, _ string) ([]*fly.Machine, error) {
+				return []*fly.Machine{
+					{ID: "web-1", Config: &fly.MachineConfig{Metadata: map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: "web"}}},
+					{ID: "worker-1", Config: &fly.MachineConfig{Metadata: map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: "worker"}}},
+				}, nil
+			},
+		}
+		ctx := machineSelectionContext(t, client,
+			"--level", "warn",
+			"--grep", "timeout",
+			"--since", "1h",
+			"--until", "2026-01-02T11:30:00Z",
+			"--process-group", "web",
+			"--status-code", "5xx",
+		)
+
+		filter, err := logFilter(ctx, []string{"test-app"}, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, "warn", filter.Level)
+		assert.Equal(t, "timeout", filter.Grep.String())
+		assert.Equal(t, now.Add(-time.Hour), filter.Since)
+		assert.Equal(t, time.Date(2026, 1, 2, 11, 30, 0, 0, time.UTC), filter.Until)
+		assert.Equal(t, map[string]bool{"web-1": true}, filter.Instances)
+		assert.Equal(t, []logs.StatusCodeRange{{Min: 500, Max: 599}}, filter.StatusCodes)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"--level", "loud"},
+			{"--grep", "("},
+			{"--since", "yesterday"},
+			{"--since", "10m", "--until", "1h"},
+			{"--status-code", "7xx"},
+		} {
+			_, err := logFilter(machineSelectionContext(t, &mock.FlapsClient{}, args...), []string{"test-app"}, now)
+			assert.Error(t, err, args)
+		}
+	})
+}
+
+func TestResolveAppNames(t *testing.T) {
+	t.Run("repeated app flags", func(t *testing.T) {
+		ctx := machineSelectionContext(t, &mock.FlapsClient{}, "-a", "api", "-a", "worker", "--app", "api")
+
+		names, err := resolveAppNames(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "worker"}, names)
+	})
+
+	t.Run("globs over the apps of an org", func(t *testing.T) {
+		client := &mock.Client{
+			GetOrganizationBySlugFunc: func(_ context.Context, slug string) (*fly.Organization, error) {
+				assert.Equal(t, "acme", slug)
+
+				return &fly.Organization{ID: "org-id", Slug: slug}, nil
+			},
+			GetAppsForOrganizationFunc: func(_ context.Context, orgID string) ([]fly.App, error) {
+				assert.Equal(t, "org-id", orgID)
+
+				return []fly.App{{Name: "shop-web"}, {Name: "billing"}, {Name: "shop-api"}}, nil
+			},
+		}
+		ctx := machineSelectionContext(t, &mock.FlapsClient{}, "--org", "acme", "-a", "shop-*")
+		ctx = flyutil.NewContextWithClient(ctx, client)
+
+		names, err := resolveAppNames(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"shop-api", "shop-web"}, names)
+	})
+
+	t.Run("requires an app", func(t *testing.T) {
+		ctx := machineSelectionContext(t, &mock.FlapsClient{})
+
+		_, err := resolveAppNames(appconfig.WithName(ctx, ""))
+
+		require.ErrorIs(t, err, command.ErrRequireAppName)
+	})
+}
+
+func TestMergeOrdered(t *testing.T) {
+	entry := func(app, ts string) logs.LogEntry {
+		return logs.LogEntry{App: app, Timestamp: ts}
+	}
+	api := make(chan logs.LogEntry, 3)
+	api <- entry("api", "2026-01-02T10:00:01Z")
+	api <- entry("api", "2026-01-02T10:00:03Z")
+	close(api)
+	worker := make(chan logs.LogEntry, 3)
+	worker <- entry("worker", "2026-01-02T10:00:00Z")
+	worker <- entry("worker", "2026-01-02T10:00:02Z")
+	close(worker)
+
+	eg, ctx := errgroup.WithContext(context.Background())
+	var got []string
+	for e := range mergeOrdered(ctx, eg, time.Minute, api, worker) {
+		got = append(got, e.App+" "+e.Timestamp)
+	}
+
+	require.NoError(t, eg.Wait())
+	assert.Equal(t, []string{
+		"worker 2026-01-02T10:00:00Z",
+		"api 2026-01-02T10:00:01Z",
+		"worker 2026-01-02T10:00:02Z",
+		"api 2026-01-02T10:00:03Z",
+	}, got)
+}
+
 func machineSelectionContext(t *testing.T, client flapsutil.FlapsClient, args ...string) context.Context {
 	t.Helper()
 
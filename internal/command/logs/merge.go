@@ -0,0 +1,159 @@
+package logs
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/logs"
+)
+
+const (
+	// orderingWindow is how long entries are held back, so that the entries
+	// of other streams with earlier timestamps can be printed before them.
+	orderingWindow = time.Second
+	// maxBufferedEntries bounds the ordering buffer of busy streams. Once it's
+	// full the oldest entries are released early.
+	maxBufferedEntries = 10_000
+)
+
+type bufferedEntry struct {
+	entry   logs.LogEntry
+	ts      time.Time
+	arrived time.Time
+	seq     uint64
+}
+
+// entryHeap orders buffered entries by timestamp, then by arrival.
+type entryHeap []*bufferedEntry
+
+func (h entryHeap) Len() int { return len(h) }
+
+func (h entryHeap) Less(i, j int) bool {
+	if !h[i].ts.Equal(h[j].ts) {
+		return h[i].ts.Before(h[j].ts)
+	}
+
+	return h[i].seq < h[j].seq
+}
+
+func (h entryHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *entryHeap) Push(x any) { *h = append(*h, x.(*bufferedEntry)) }
+
+func (h *entryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return x
+}
+
+// mergeOrdered fans the streams in to a single one ordered by timestamp. Each
+// entry is held for up to window while the entries of other streams catch up,
+// and whatever is left is flushed in order once every stream is closed.
+func mergeOrdered(ctx context.Context, eg *errgroup.Group, window time.Duration, streams ...<-chan logs.LogEntry) <-chan logs.LogEntry {
+	in := make(chan logs.LogEntry)
+	var wg sync.WaitGroup
+	for _, stream := range streams {
+		wg.Go(func() {
+			for entry := range stream {
+				select {
+				case in <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(in)
+	}()
+
+	out := make(chan logs.LogEntry)
+	eg.Go(func() error {
+		defer close(out)
+
+		ticker := time.NewTicker(window / 4)
+		defer ticker.Stop()
+
+		var (
+			buffer entryHeap
+			seq    uint64
+		)
+		// release sends the buffered entries that have waited long enough,
+		// or all of them when flushing.
+		release := func(now time.Time, flush bool) error {
+			for buffer.Len() > 0 {
+				next := buffer[0]
+				if !flush && buffer.Len() <= maxBufferedEntries && now.Sub(next.arrived) < window {
+					return nil
+				}
+				heap.Pop(&buffer)
+
+				select {
+				case out <- next.entry:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			return nil
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case now := <-ticker.C:
+				if err := release(now, false); err != nil {
+					return err
+				}
+			case entry, ok := <-in:
+				if !ok {
+					return release(time.Now(), true)
+				}
+
+				now := time.Now()
+				ts, err := entry.Time()
+				if err != nil {
+					ts = now
+				}
+				seq++
+				heap.Push(&buffer, &bufferedEntry{entry: entry, ts: ts, arrived: now, seq: seq})
+
+				if err := release(now, false); err != nil {
+					return err
+				}
+			}
+		}
+	})
+
+	return out
+}
+
+// tagApp sets the app of the stream's entries, to tell the apps apart once
+// their streams are merged.
+func tagApp(ctx context.Context, appName string, stream <-chan logs.LogEntry) <-chan logs.LogEntry {
+	out := make(chan logs.LogEntry)
+	go func() {
+		defer close(out)
+
+		for entry := range stream {
+			entry.App = appName
+			select {
+			case out <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -94,6 +94,8 @@ var sharedFlags = flag.Set{
 		Hidden:      true,
 	},
 	flag.BuildContextWarnSize(),
+	flag.CacheFrom(),
+	flag.CacheTo(),
 	flag.Bool{
 		Name:        "no-build-cache",
 		Description: "Do not use the cache when building the image",
@@ -0,0 +1,278 @@
+package mcp
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	mcpGo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/task"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// Error codes of the ToolError of a failed tool.
+const (
+	ErrCodeInvalidArgument      = "invalid_argument"
+	ErrCodeUnauthenticated      = "unauthenticated"
+	ErrCodePermissionDenied     = "permission_denied"
+	ErrCodeNotFound             = "not_found"
+	ErrCodeConflict             = "conflict"
+	ErrCodeRateLimited          = "rate_limited"
+	ErrCodeUnavailable          = "unavailable"
+	ErrCodeTimeout              = "timeout"
+	ErrCodeConfirmationRequired = "confirmation_required"
+	ErrCodeFailed               = "failed"
+)
+
+// ToolError is the structured content of the result of a failed tool.
+type ToolError struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	Description string `json:"description,omitempty"`
+	Suggestion  string `json:"suggestion,omitempty"`
+	RequestID   string `json:"request_id,omitempty"`
+	Output      string `json:"output,omitempty"`
+}
+
+// toolTimeout bounds every call, so that a command that doesn't return holds
+// up the calls waiting on it for no longer.
+const toolTimeout = 15 * time.Minute
+
+// executor runs flyctl commands in-process, on a fresh command tree for every
+// call, so that they share the server's configuration instead of spawning a
+// flyctl process each. Like a flyctl process, every call runs the preparers of
+// its command, which set up the API clients it uses.
+type executor struct {
+	// base carries the server's configuration, logger and such. Tool calls are
+	// served with contexts that only carry the request.
+	base    context.Context
+	newRoot func() *cobra.Command
+
+	// timeout bounds every call, toolTimeout when zero.
+	timeout time.Duration
+
+	// mu serializes calls: commands read flags, and os.Stdin and os.Stdout are
+	// redirected globally.
+	mu sync.Mutex
+}
+
+// run runs flyctl with args as a tool, canceling it when ctx is.
+func (e *executor) run(ctx context.Context, args []string) *mcpGo.CallToolResult {
+	output, err := e.output(ctx, args)
+	if err != nil {
+		return toolErrorResult(err, strings.TrimSpace(string(output)))
+	}
+
+	return toolResult(output)
+}
+
+// output runs flyctl with args, canceling it when ctx is, and returns what it
+// printed. When it fails, that includes what it printed to stderr.
+func (e *executor) output(ctx context.Context, args []string) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	runCtx, cancel := context.WithTimeout(e.base, cmp.Or(e.timeout, toolTimeout))
+	defer cancel()
+	defer context.AfterFunc(ctx, cancel)()
+
+	io, _, stdout, stderr := iostreams.Test()
+	runCtx = iostreams.NewContext(runCtx, io)
+	runCtx = task.NewWithContext(runCtx)
+
+	root := e.newRoot()
+	root.SetArgs(args)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+
+	var err error
+	stray := captureStdout(func() {
+		_, err = root.ExecuteContextC(runCtx)
+	})
+
+	task.FromContext(runCtx).ShutdownWithTimeout(5 * time.Second)
+
+	output := append(stdout.Bytes(), stray...)
+	if err != nil {
+		output = append(output, stderr.Bytes()...)
+	}
+
+	return output, err
+}
+
+// withAccessToken adds the auth token of the request ctx carries to args.
+func withAccessToken(ctx context.Context, args []string) []string {
+	if token, ok := ctx.Value(authTokenKey).(string); ok && token != "" {
+		args = append(args, "--access-token", token)
+	}
+
+	return args
+}
+
+// captureStdout runs fn with os.Stdout redirected to a pipe, and returns what
+// was written to it, and with os.Stdin reading nothing. Commands that print to
+// os.Stdout directly would corrupt the stdio transport otherwise, which holds
+// on to the original files, and commands reading os.Stdin, like with a file
+// argument of "-", would steal its messages.
+func captureStdout(fn func()) []byte {
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		return captureOutput(fn)
+	}
+	defer null.Close()
+
+	stdin := os.Stdin
+	os.Stdin = null
+	defer func() { os.Stdin = stdin }()
+
+	return captureOutput(fn)
+}
+
+func captureOutput(fn func()) []byte {
+	r, w, err := os.Pipe()
+	if err != nil {
+		fn()
+
+		return nil
+	}
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = io.Copy(&buf, r)
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+		w.Close()
+		<-done
+		r.Close()
+	}()
+
+	fn()
+
+	return buf.Bytes()
+}
+
+// toolResult returns output as structured content when it's JSON, as commands
+// print with --json, and as text otherwise.
+func toolResult(output []byte) *mcpGo.CallToolResult {
+	text := strings.TrimSpace(string(output))
+
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil || text == "" {
+		return mcpGo.NewToolResultText(text)
+	}
+
+	// Structured content must be an object.
+	if _, ok := value.(map[string]any); !ok {
+		value = map[string]any{"result": value}
+	}
+
+	return mcpGo.NewToolResultStructured(value, text)
+}
+
+func toolErrorResult(err error, output string) *mcpGo.CallToolResult {
+	toolErr := classifyError(err)
+	toolErr.Output = output
+
+	return errorResult(toolErr)
+}
+
+// invalidArgument returns the result of a tool called with invalid arguments.
+func invalidArgument(format string, a ...any) *mcpGo.CallToolResult {
+	return errorResult(ToolError{Code: ErrCodeInvalidArgument, Message: fmt.Sprintf(format, a...)})
+}
+
+func errorResult(toolErr ToolError) *mcpGo.CallToolResult {
+	text, _ := json.Marshal(toolErr)
+
+	result := mcpGo.NewToolResultStructured(toolErr, string(text))
+	result.IsError = true
+
+	return result
+}
+
+// classifyError describes err as a ToolError, with a code clients can act upon.
+func classifyError(err error) ToolError {
+	toolErr := ToolError{
+		Code:        ErrCodeFailed,
+		Message:     err.Error(),
+		Description: flyerr.GetErrorDescription(err),
+		Suggestion:  flyerr.GetErrorSuggestion(err),
+		RequestID:   flaps.GetErrorRequestID(err),
+	}
+
+	var flapsErr *flaps.FlapsError
+	switch {
+	case errors.As(err, &flapsErr):
+		toolErr.Code = statusCode(flapsErr.ResponseStatusCode)
+	case fly.IsNotAuthenticatedError(err):
+		toolErr.Code = ErrCodeUnauthenticated
+	case prompt.IsNonInteractive(err):
+		toolErr.Code = ErrCodeConfirmationRequired
+		toolErr.Suggestion = "Confirm by setting the yes argument"
+	case errors.Is(err, context.DeadlineExceeded):
+		toolErr.Code = ErrCodeTimeout
+	case isUsageError(err):
+		toolErr.Code = ErrCodeInvalidArgument
+	}
+
+	return toolErr
+}
+
+func statusCode(status int) string {
+	switch {
+	case status == http.StatusBadRequest, status == http.StatusUnprocessableEntity:
+		return ErrCodeInvalidArgument
+	case status == http.StatusUnauthorized:
+		return ErrCodeUnauthenticated
+	case status == http.StatusForbidden:
+		return ErrCodePermissionDenied
+	case status == http.StatusNotFound:
+		return ErrCodeNotFound
+	case status == http.StatusConflict, status == http.StatusPreconditionFailed:
+		return ErrCodeConflict
+	case status == http.StatusTooManyRequests:
+		return ErrCodeRateLimited
+	case status == http.StatusRequestTimeout, status == http.StatusGatewayTimeout:
+		return ErrCodeTimeout
+	case status >= 500:
+		return ErrCodeUnavailable
+	default:
+		return ErrCodeFailed
+	}
+}
+
+// isUsageError reports whether err is cobra's, for flags or arguments that
+// don't parse or validate.
+func isUsageError(err error) bool {
+	msg := err.Error()
+
+	for _, prefix := range []string{"unknown flag", "unknown shorthand flag", "invalid argument", "accepts ", "requires at least", "requires at most", "unknown command", "flag needs an argument", "required flag"} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+
+	return strings.Contains(msg, "if any flags in the group")
+}
@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutorTimeout(t *testing.T) {
+	e := &executor{
+		base:    context.Background(),
+		timeout: 50 * time.Millisecond,
+		newRoot: func() *cobra.Command {
+			root := &cobra.Command{Use: "fly"}
+			root.AddCommand(&cobra.Command{
+				Use: "hang",
+				RunE: func(cmd *cobra.Command, _ []string) error {
+					<-cmd.Context().Done()
+
+					return cmd.Context().Err()
+				},
+			})
+
+			return root
+		},
+	}
+
+	result := e.run(context.Background(), []string{"hang"})
+	assert.True(t, result.IsError)
+	assert.Equal(t, ErrCodeTimeout, result.StructuredContent.(ToolError).Code)
+
+	// The timed out call doesn't hold up the next one.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.run(context.Background(), []string{"hang"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("executor is still busy with the timed out call")
+	}
+}
+
+func TestExecutorStdin(t *testing.T) {
+	e := &executor{
+		base:    context.Background(),
+		timeout: 5 * time.Second,
+		newRoot: func() *cobra.Command {
+			root := &cobra.Command{Use: "fly"}
+			root.AddCommand(&cobra.Command{
+				Use: "read",
+				RunE: func(cmd *cobra.Command, _ []string) error {
+					data, err := io.ReadAll(os.Stdin)
+					fmt.Fprintf(os.Stdout, "read %d bytes", len(data))
+
+					return err
+				},
+			})
+
+			return root
+		},
+	}
+
+	// Commands don't read the stdin of the server, which the stdio transport
+	// reads messages from.
+	output, err := e.output(context.Background(), []string{"read"})
+	require.NoError(t, err)
+	assert.Equal(t, "read 0 bytes", string(output))
+}
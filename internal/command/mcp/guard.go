@@ -0,0 +1,251 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	mcpGo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/command/mcp/policy"
+	"github.com/superfly/flyctl/internal/flag"
+)
+
+const (
+	defaultPolicyFile   = "mcp-policy.toml"
+	defaultAuditLogFile = "mcp-audit.log"
+)
+
+// loadPolicy returns the policy of the --policy flag, or of the default policy
+// file when it exists, and opens its audit log.
+func loadPolicy(ctx context.Context) (*policy.Policy, *policy.AuditLog, error) {
+	configDir, err := helpers.GetConfigDirectory()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pol := &policy.Policy{}
+
+	path := flag.GetString(ctx, "policy")
+	if path == "" {
+		if _, err := os.Stat(filepath.Join(configDir, defaultPolicyFile)); err == nil {
+			path = filepath.Join(configDir, defaultPolicyFile)
+		}
+	}
+	if path != "" {
+		if pol, err = policy.Load(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if flag.GetBool(ctx, "read-only") {
+		pol.ReadOnly = true
+	}
+
+	auditPath := flag.GetString(ctx, "audit-log")
+	if auditPath == "" {
+		auditPath = pol.AuditLog
+	}
+	if auditPath == "" {
+		auditPath = filepath.Join(configDir, defaultAuditLogFile)
+	}
+
+	audit, err := policy.OpenAuditLog(auditPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open the MCP audit log: %w", err)
+	}
+
+	return pol, audit, nil
+}
+
+// guard enforces pol on the calls of the tool named toolName, asking the user
+// to confirm them through the client when needed, and records them in audit.
+// Calls of tools that confirm are destructive, as they're passed --yes.
+func guard(srv *server.MCPServer, pol *policy.Policy, audit *policy.AuditLog, toolName string, confirms bool, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcpGo.CallToolRequest) (*mcpGo.CallToolResult, error) {
+		start := time.Now()
+
+		args, _ := request.Params.Arguments.(map[string]any)
+		readOnly, destructive := policy.ClassifyCall(toolName, args)
+		decision := pol.Decide(policy.Call{
+			Tool:        toolName,
+			App:         appArgument(toolName, args),
+			Org:         stringArgument(args, "org"),
+			ReadOnly:    readOnly && !confirms,
+			Destructive: destructive || confirms,
+		})
+
+		entry := policy.AuditEntry{
+			Time:      start,
+			Tool:      toolName,
+			Arguments: policy.Redact(toolName, args),
+			Decision:  decision.Action,
+			Reason:    decision.Reason,
+		}
+
+		result, err := func() (*mcpGo.CallToolResult, error) {
+			switch decision.Action {
+			case policy.Deny:
+				return errorResult(ToolError{
+					Code:    ErrCodePermissionDenied,
+					Message: fmt.Sprintf("%s is denied by %s", toolName, decision.Reason),
+				}), nil
+			case policy.Confirm:
+				confirmed, err := confirmCall(ctx, srv, toolName, entry.Arguments, decision.Reason)
+				entry.Confirmed = &confirmed
+				switch {
+				case err != nil:
+					return errorResult(ToolError{
+						Code:       ErrCodeConfirmationRequired,
+						Message:    fmt.Sprintf("%s must be confirmed (%s), but the user couldn't be asked: %v", toolName, decision.Reason, err),
+						Suggestion: "Use an MCP client that supports elicitation, or allow the tool in the MCP policy file",
+					}), nil
+				case !confirmed:
+					return errorResult(ToolError{
+						Code:    ErrCodePermissionDenied,
+						Message: fmt.Sprintf("the user declined to run %s", toolName),
+					}), nil
+				}
+			}
+
+			return handler(ctx, request)
+		}()
+
+		entry.DurationMS = time.Since(start).Milliseconds()
+		switch {
+		case err != nil:
+			entry.Error = true
+			entry.Result = policy.RedactResult(toolName, err.Error())
+		case result != nil:
+			entry.Error = result.IsError
+			entry.Result = policy.RedactResult(toolName, resultText(result))
+		}
+
+		recordAudit(audit, entry)
+
+		return result, err
+	}
+}
+
+// recordAudit records entry in audit, when there's one.
+func recordAudit(audit *policy.AuditLog, entry policy.AuditEntry) {
+	if audit == nil {
+		return
+	}
+
+	if err := audit.Record(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write the MCP audit log: %v\n", err)
+	}
+}
+
+// confirmCall asks the user, through the client, whether to run a tool.
+func confirmCall(ctx context.Context, srv *server.MCPServer, toolName string, args map[string]any, reason string) (bool, error) {
+	described, _ := json.Marshal(args)
+
+	result, err := srv.RequestElicitation(ctx, mcpGo.ElicitationRequest{
+		Params: mcpGo.ElicitationParams{
+			Message: fmt.Sprintf("Run %s with %s? It needs confirmation: %s.", toolName, described, reason),
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"confirm": map[string]any{
+						"type":        "boolean",
+						"title":       "Run " + toolName,
+						"description": "Confirm to run the tool",
+					},
+				},
+				"required": []string{"confirm"},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if result.Action != mcpGo.ElicitationResponseActionAccept {
+		return false, nil
+	}
+
+	content, ok := result.Content.(map[string]any)
+	if !ok {
+		return false, errors.New("unexpected confirmation response")
+	}
+
+	confirmed, _ := content["confirm"].(bool)
+
+	return confirmed, nil
+}
+
+// appArgument returns the app a tool call targets: its app argument, or the
+// name of the app for apps tools, given as their name or positional argument.
+// It's empty when the app comes from fly.toml, and the policy then treats the
+// app as unknown.
+func appArgument(toolName string, args map[string]any) string {
+	if app := stringArgument(args, "app"); app != "" {
+		return app
+	}
+
+	if !strings.HasPrefix(toolName, "fly-apps-") {
+		return ""
+	}
+
+	if name := stringArgument(args, "name"); name != "" {
+		return name
+	}
+
+	// Apps commands take the app as their only positional argument.
+	if fields := strings.Fields(stringArgument(args, "args")); len(fields) == 1 {
+		return fields[0]
+	}
+
+	return ""
+}
+
+func stringArgument(args map[string]any, name string) string {
+	value, _ := args[name].(string)
+
+	return value
+}
+
+func resultText(result *mcpGo.CallToolResult) string {
+	var text strings.Builder
+	for _, content := range result.Content {
+		if textContent, ok := mcpGo.AsTextContent(content); ok {
+			text.WriteString(textContent.Text)
+		}
+	}
+
+	return text.String()
+}
+
+// policyArgs returns the policy flags of the server, to pass them on to the
+// servers started for MCP clients.
+func policyArgs(ctx context.Context) []string {
+	var args []string
+
+	if path := flag.GetString(ctx, "policy"); path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		args = append(args, "--policy", path)
+	}
+
+	if path := flag.GetString(ctx, "audit-log"); path != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		args = append(args, "--audit-log", path)
+	}
+
+	if flag.GetBool(ctx, "read-only") {
+		args = append(args, "--read-only")
+	}
+
+	return args
+}
@@ -11,7 +11,9 @@ import (
 	"github.com/superfly/flyctl/internal/command"
 )
 
-func New() *cobra.Command {
+// New returns the mcp command. newRoot builds a flyctl command tree, on which
+// the MCP server runs its tools in-process.
+func New(newRoot func() *cobra.Command) *cobra.Command {
 	const (
 		short = `flyctl Model Context Protocol.`
 
@@ -24,7 +26,7 @@ func New() *cobra.Command {
 	cmd.AddCommand(
 		NewProxy(),
 		NewInspect(),
-		newServer(),
+		newServer(newRoot),
 		NewWrap(),
 
 		NewAdd(),
@@ -0,0 +1,140 @@
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAuditResult caps the size of the results kept in the audit log.
+const maxAuditResult = 4096
+
+// AuditEntry records a tool call.
+type AuditEntry struct {
+	Time      time.Time      `json:"time"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Decision  Action         `json:"decision"`
+	Reason    string         `json:"reason,omitempty"`
+	// Confirmed is set for calls the user was asked to confirm.
+	Confirmed  *bool  `json:"confirmed,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      bool   `json:"error"`
+	Result     string `json:"result,omitempty"`
+}
+
+// AuditLog appends entries to a file, as JSON lines.
+type AuditLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenAuditLog opens the audit log at path, creating it when needed.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditLog{f: f}, nil
+}
+
+// Record appends entry to the log. Arguments and results must be redacted
+// already, see Redact and RedactResult, and results are truncated.
+func (l *AuditLog) Record(entry AuditEntry) error {
+	if len(entry.Result) > maxAuditResult {
+		entry.Result = entry.Result[:maxAuditResult] + "…"
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err = l.f.Write(append(line, '\n'))
+
+	return err
+}
+
+// Close closes the log.
+func (l *AuditLog) Close() error {
+	return l.f.Close()
+}
+
+// Redact returns a copy of the arguments of a call to tool that's safe to log:
+// the values of NAME=VALUE pairs given to secrets tools, and of arguments
+// named like tokens or passwords, are replaced.
+func Redact(tool string, args map[string]any) map[string]any {
+	secretsTool := strings.Contains(tool, "secrets")
+
+	redacted := make(map[string]any, len(args))
+	for name, value := range args {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "token") || strings.Contains(lower, "password") || strings.Contains(lower, "secret") {
+			redacted[name] = "REDACTED"
+
+			continue
+		}
+
+		if secretsTool {
+			value = redactPairs(value)
+		}
+		redacted[name] = value
+	}
+
+	return redacted
+}
+
+func redactPairs(value any) any {
+	switch value := value.(type) {
+	case string:
+		if name, _, ok := strings.Cut(value, "="); ok {
+			return name + "=REDACTED"
+		}
+
+		return value
+	case []any:
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = redactPairs(item)
+		}
+
+		return items
+	default:
+		return value
+	}
+}
+
+// credentialWords name the tools whose output holds credentials, like
+// fly-tokens-create or fly-secrets-list, by a word of their name.
+var credentialWords = []string{"auth", "certs", "secrets", "ssh", "tokens", "wireguard"}
+
+var tokenRx = regexp.MustCompile(`(FlyV1 )?(fo1_|fm1[ar]_|fm2_)[a-zA-Z0-9/+_-]+=*`)
+
+// RedactResult returns the output of a call to tool that's safe to log: the
+// output of tools handling credentials isn't kept, and tokens are replaced in
+// the output of others.
+func RedactResult(tool, result string) string {
+	if result == "" {
+		return ""
+	}
+
+	words := strings.Split(tool, "-")
+	if slices.ContainsFunc(credentialWords, func(word string) bool { return slices.Contains(words, word) }) {
+		return "REDACTED"
+	}
+
+	return tokenRx.ReplaceAllString(result, "REDACTED")
+}
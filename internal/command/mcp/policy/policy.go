@@ -0,0 +1,235 @@
+// Package policy decides which tools clients of the flyctl MCP server may
+// call, and keeps an audit log of the calls.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Action is what a Policy does with a tool call.
+type Action string
+
+const (
+	// Allow runs the tool.
+	Allow Action = "allow"
+	// Confirm asks the user to confirm the tool call before running it.
+	Confirm Action = "confirm"
+	// Deny refuses to run the tool.
+	Deny Action = "deny"
+)
+
+// Policy lists the rules of tool calls. A policy file looks like:
+//
+//	read_only = false
+//	default = "allow"
+//
+//	[[rule]]
+//	action = "deny"
+//	tools = ["fly-apps-destroy"]
+//
+//	[[rule]]
+//	action = "allow"
+//	tools = ["fly-machine-*"]
+//	apps = ["staging-*"]
+//
+// Rules are checked in order, and the first one to match a call decides it.
+// Destructive tools need to be confirmed unless a rule allows them explicitly.
+// Reads of the resources of apps are decided like calls of read-only tools
+// named after the resource, like fly-resource-logs.
+type Policy struct {
+	// ReadOnly denies every tool that isn't read-only, whatever the rules.
+	ReadOnly bool `toml:"read_only"`
+	// Default is the action of calls no rule matches, Allow unless set.
+	Default Action `toml:"default"`
+	// Rules decide calls, in order.
+	Rules []Rule `toml:"rule"`
+	// AuditLog is the path of the audit log, when set.
+	AuditLog string `toml:"audit_log"`
+}
+
+// Rule matches tool calls by tool name, and by the app and organization they
+// target. Patterns are globs, like "fly-machine-*". A rule without patterns
+// for a field matches any value, including none. A rule with patterns matches
+// calls with a matching value, and deny and confirm rules also match calls
+// whose app or organization isn't known, like calls relying on fly.toml, so
+// that they can't be sidestepped.
+type Rule struct {
+	Action Action   `toml:"action"`
+	Tools  []string `toml:"tools"`
+	Apps   []string `toml:"apps"`
+	Orgs   []string `toml:"orgs"`
+}
+
+// Call describes a tool call to decide.
+type Call struct {
+	Tool string
+	App  string
+	Org  string
+	// ReadOnly tools don't change anything.
+	ReadOnly bool
+	// Destructive tools delete or stop things, and need confirmation.
+	Destructive bool
+}
+
+// Decision is the action a Policy takes for a Call, and why.
+type Decision struct {
+	Action Action
+	Reason string
+}
+
+// Load reads the policy file at path.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := toml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed parsing MCP policy %s: %w", path, err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid MCP policy %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// Validate checks that the actions and patterns of the policy are valid.
+func (p *Policy) Validate() error {
+	var errs []error
+
+	if p.Default != "" && !validAction(p.Default) {
+		errs = append(errs, fmt.Errorf("unknown default action %q", p.Default))
+	}
+
+	for i, rule := range p.Rules {
+		if !validAction(rule.Action) {
+			errs = append(errs, fmt.Errorf("rule %d: unknown action %q, expected allow, confirm or deny", i+1, rule.Action))
+		}
+
+		for _, pattern := range slices.Concat(rule.Tools, rule.Apps, rule.Orgs) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("rule %d: invalid pattern %q: %w", i+1, pattern, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validAction(action Action) bool {
+	switch action {
+	case Allow, Confirm, Deny:
+		return true
+	default:
+		return false
+	}
+}
+
+// Decide returns what to do with call.
+func (p *Policy) Decide(call Call) Decision {
+	if p.ReadOnly && !call.ReadOnly {
+		return Decision{Action: Deny, Reason: "the MCP server is read-only"}
+	}
+
+	for i, rule := range p.Rules {
+		if !rule.matches(call) {
+			continue
+		}
+
+		return Decision{Action: rule.Action, Reason: fmt.Sprintf("policy rule %d", i+1)}
+	}
+
+	action := p.Default
+	if action == "" {
+		action = Allow
+	}
+
+	if action == Allow && call.Destructive {
+		return Decision{Action: Confirm, Reason: call.Tool + " is destructive"}
+	}
+
+	return Decision{Action: action, Reason: "policy default"}
+}
+
+func (r Rule) matches(call Call) bool {
+	unknown := r.Action != Allow
+
+	return matchAny(r.Tools, call.Tool, false) && matchAny(r.Apps, call.App, unknown) && matchAny(r.Orgs, call.Org, unknown)
+}
+
+// matchAny reports whether value matches one of patterns, or whether there are
+// no patterns. Empty values match patterns when unknown is set.
+func matchAny(patterns []string, value string, unknown bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	if value == "" {
+		return unknown
+	}
+
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		ok, _ := path.Match(pattern, value)
+
+		return ok
+	})
+}
+
+// destructiveVerbs and readOnlyVerbs classify tools by the last word of their
+// name, like "destroy" for fly-apps-destroy.
+var (
+	destructiveVerbs = []string{"count", "delete", "destroy", "detach", "kill", "prune", "release", "remove", "revoke", "rm", "rollback", "stop", "suspend", "unset"}
+	readOnlyVerbs    = []string{"check", "checks", "describe", "diff", "get", "history", "info", "list", "logs", "ls", "private", "regions", "releases", "show", "sizes", "status", "version", "view"}
+)
+
+// destructiveArgs make any call destructive when they're set, like prune for
+// fly-secrets-apply.
+var destructiveArgs = []string{"force", "prune", "yes"}
+
+// Classify reports whether the tool named toolName is read-only, or destructive.
+func Classify(toolName string) (readOnly, destructive bool) {
+	words := strings.Split(toolName, "-")
+	verb := words[len(words)-1]
+
+	return slices.Contains(readOnlyVerbs, verb), slices.Contains(destructiveVerbs, verb)
+}
+
+// ClassifyCall reports whether a call of the tool named toolName with args is
+// read-only, or destructive: calls setting destructive arguments are, whatever
+// the tool.
+func ClassifyCall(toolName string, args map[string]any) (readOnly, destructive bool) {
+	readOnly, destructive = Classify(toolName)
+
+	for _, name := range destructiveArgs {
+		if isSet(args[name]) {
+			return false, true
+		}
+	}
+
+	return readOnly, destructive
+}
+
+// isSet reports whether a tool argument is set to true, as a boolean or string.
+func isSet(value any) bool {
+	switch value := value.(type) {
+	case bool:
+		return value
+	case string:
+		set, _ := strconv.ParseBool(value)
+
+		return set
+	default:
+		return false
+	}
+}
@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func call(tool, app, org string) Call {
+	readOnly, destructive := Classify(tool)
+
+	return Call{Tool: tool, App: app, Org: org, ReadOnly: readOnly, Destructive: destructive}
+}
+
+func TestDecide(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[[rule]]
+action = "deny"
+tools = ["fly-apps-destroy"]
+
+[[rule]]
+action = "allow"
+tools = ["fly-machine-*"]
+apps = ["staging-*"]
+
+[[rule]]
+action = "confirm"
+orgs = ["prod"]
+`), 0o600))
+
+	p, err := Load(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		call   Call
+		action Action
+	}{
+		{call("fly-apps-destroy", "staging-web", ""), Deny},
+		{call("fly-machine-destroy", "staging-web", ""), Allow},
+		{call("fly-machine-destroy", "prod-web", ""), Confirm},
+		{call("fly-machine-list", "prod-web", "personal"), Allow},
+		{call("fly-machine-list", "", "prod"), Confirm},
+		{call("fly-ips-release", "web", "personal"), Confirm},
+		// Calls whose app or organization isn't known don't match allow
+		// rules, but do match deny and confirm rules.
+		{call("fly-machine-destroy", "", "personal"), Confirm},
+		{call("fly-machine-list", "prod-web", ""), Confirm},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.action, p.Decide(tt.call).Action, "%+v", tt.call)
+	}
+
+	p.ReadOnly = true
+	assert.Equal(t, Deny, p.Decide(call("fly-machine-destroy", "staging-web", "")).Action)
+	assert.Equal(t, Allow, p.Decide(call("fly-apps-list", "", "personal")).Action)
+}
+
+func TestValidate(t *testing.T) {
+	p := &Policy{
+		Default: "maybe",
+		Rules: []Rule{
+			{Action: "nope"},
+			{Action: Allow, Tools: []string{"fly-["}},
+		},
+	}
+
+	err := p.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown default action "maybe"`)
+	assert.Contains(t, err.Error(), `rule 1: unknown action "nope"`)
+	assert.Contains(t, err.Error(), `rule 2: invalid pattern "fly-["`)
+}
+
+func TestClassify(t *testing.T) {
+	readOnly, destructive := Classify("fly-volumes-list")
+	assert.True(t, readOnly)
+	assert.False(t, destructive)
+
+	readOnly, destructive = Classify("fly-secrets-unset")
+	assert.False(t, readOnly)
+	assert.True(t, destructive)
+
+	readOnly, destructive = Classify("fly-machine-create")
+	assert.False(t, readOnly)
+	assert.False(t, destructive)
+
+	readOnly, destructive = Classify("fly-secrets-diff")
+	assert.True(t, readOnly)
+	assert.False(t, destructive)
+
+	for _, tool := range []string{"fly-scale-count", "fly-releases-rollback"} {
+		_, destructive = Classify(tool)
+		assert.True(t, destructive, tool)
+	}
+}
+
+func TestClassifyCall(t *testing.T) {
+	readOnly, destructive := ClassifyCall("fly-secrets-apply", map[string]any{"prune": true})
+	assert.False(t, readOnly)
+	assert.True(t, destructive)
+
+	_, destructive = ClassifyCall("fly-machine-update", map[string]any{"yes": "true"})
+	assert.True(t, destructive)
+
+	readOnly, destructive = ClassifyCall("fly-status", map[string]any{"force": true})
+	assert.False(t, readOnly)
+	assert.True(t, destructive)
+
+	readOnly, destructive = ClassifyCall("fly-secrets-apply", map[string]any{"prune": false, "force": "false"})
+	assert.False(t, readOnly)
+	assert.False(t, destructive)
+}
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "mcp.log")
+
+	log, err := OpenAuditLog(path)
+	require.NoError(t, err)
+
+	args := Redact("fly-secrets-set", map[string]any{
+		"app":          "web",
+		"keyvalues":    []any{"DB_PASSWORD=hunter2", "PLAIN"},
+		"access-token": "fo1_xyz",
+	})
+	require.NoError(t, log.Record(AuditEntry{Tool: "fly-secrets-set", Arguments: args, Decision: Allow}))
+	require.NoError(t, log.Record(AuditEntry{Tool: "fly-logs", Decision: Allow, Result: strings.Repeat("x", 2*maxAuditResult)}))
+	require.NoError(t, log.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	assert.NotContains(t, string(data), "fo1_xyz")
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var entry AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, []any{"DB_PASSWORD=REDACTED", "PLAIN"}, entry.Arguments["keyvalues"])
+	assert.Equal(t, "web", entry.Arguments["app"])
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Less(t, len(entry.Result), maxAuditResult+8)
+}
+
+func TestRedactResult(t *testing.T) {
+	assert.Equal(t, "REDACTED", RedactResult("fly-tokens-create-deploy", "FlyV1 fm2_abc"))
+	assert.Equal(t, "REDACTED", RedactResult("fly-secrets-list", "DB_PASSWORD"))
+	assert.Equal(t, "", RedactResult("fly-secrets-list", ""))
+	assert.Equal(t, `{"token":"REDACTED","app":"web"}`, RedactResult("fly-status", `{"token":"FlyV1 fm2_abc/def+g==","app":"web"}`))
+	assert.Equal(t, "ok", RedactResult("fly-status", "ok"))
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	mcpGo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// appPrompt is a canned prompt about an app, which embeds resources of the app
+// so that agents start from its current state.
+type appPrompt struct {
+	name        string
+	description string
+	// resources are the names of the resources of the app to embed.
+	resources    []string
+	instructions string
+}
+
+var appPrompts = []appPrompt{
+	{
+		name:        "diagnose-failing-deploy",
+		description: "Find out why the latest deployment of an app fails",
+		resources:   []string{"status", "machines", "logs"},
+		instructions: `The latest deployment of the Fly.io app %[1]q fails. Find out why, using its status, Machines and logs below.
+
+- Compare the image and config of the Machines with the latest releases of the app (fly-apps-releases).
+- Look at the events and health checks of Machines that are stopped, restarting or failing checks (fly-machine-status).
+- Look for crashes, out of memory kills, ports the app doesn't listen on and missing secrets in the logs. Read fly://apps/%[1]s/logs again, or subscribe to it, to see new ones.
+
+Explain the cause, citing the log lines or events that show it, and suggest a fix. Don't change anything without asking.`,
+	},
+	{
+		name:        "right-size-machines",
+		description: "Suggest Machine sizes for an app that fit its usage",
+		resources:   []string{"machines", "logs"},
+		instructions: `Suggest Machine sizes for the Fly.io app %[1]q, using its Machines and logs below.
+
+- Look for out of memory kills, swapping and slow responses in the logs, which call for more memory or CPUs.
+- Machines that are mostly idle, or that auto stop most of the time, may do with less.
+- Check the sizes available with fly-platform-vm-sizes.
+
+For every process group, give the current and suggested size, and why. Resizing restarts Machines: only do it with fly-machine-update, setting vm-size, vm-cpus or vm-memory, once asked to.`,
+	},
+}
+
+// registerPrompts adds the prompts to the server, reading the resources they
+// embed from res.
+func registerPrompts(srv *server.MCPServer, res *resources) {
+	for _, p := range appPrompts {
+		srv.AddPrompt(
+			mcpGo.NewPrompt(p.name,
+				mcpGo.WithPromptDescription(p.description),
+				mcpGo.WithArgument("app",
+					mcpGo.ArgumentDescription("Name of the app"),
+					mcpGo.RequiredArgument(),
+				),
+			),
+			func(ctx context.Context, request mcpGo.GetPromptRequest) (*mcpGo.GetPromptResult, error) {
+				app := request.Params.Arguments["app"]
+				if app == "" {
+					return nil, errors.New("missing required argument app")
+				}
+
+				messages := []mcpGo.PromptMessage{
+					mcpGo.NewPromptMessage(mcpGo.RoleUser, mcpGo.NewTextContent(fmt.Sprintf(p.instructions, app))),
+				}
+
+				for _, name := range p.resources {
+					messages = append(messages, mcpGo.NewPromptMessage(mcpGo.RoleUser, res.embed(ctx, app, name)))
+				}
+
+				return mcpGo.NewGetPromptResult(p.description, messages), nil
+			},
+		)
+	}
+}
+
+// embed returns the resource named name of app as prompt content, or why it
+// couldn't be read.
+func (r *resources) embed(ctx context.Context, app, name string) mcpGo.Content {
+	uri := appURI(app, name)
+	request := mcpGo.ReadResourceRequest{}
+	request.Params.URI = uri
+
+	var (
+		contents []mcpGo.ResourceContents
+		err      error
+	)
+	if name == "logs" {
+		contents, err = r.readLogs(ctx, request)
+	} else {
+		for _, resource := range appResources {
+			if resource.name == name {
+				contents, err = r.read(ctx, uri, resource.args(app))
+			}
+		}
+	}
+
+	if err != nil || len(contents) == 0 {
+		return mcpGo.NewTextContent(fmt.Sprintf("%s couldn't be read: %v", uri, err))
+	}
+
+	return mcpGo.NewEmbeddedResource(contents[0])
+}
@@ -0,0 +1,534 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	mcpGo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/tokens"
+	"github.com/superfly/flyctl/internal/cmdutil/preparers"
+	"github.com/superfly/flyctl/internal/command/mcp/policy"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/logs"
+)
+
+const (
+	resourcePrefix = "fly://apps"
+
+	// maxLogEntries is how many of the latest log entries of an app are kept
+	// for the clients subscribed to them.
+	maxLogEntries = 500
+
+	// logNotifyInterval is how often subscribers are notified of new logs, at
+	// most.
+	logNotifyInterval = time.Second
+)
+
+// appResource is a resource of every app, read by running a command.
+type appResource struct {
+	name        string
+	description string
+	args        func(app string) []string
+}
+
+var appResources = []appResource{
+	{
+		name:        "config",
+		description: "The configuration of the app, as deployed",
+		args:        func(app string) []string { return []string{"config", "show", "--app", app} },
+	},
+	{
+		name:        "machines",
+		description: "The Machines of the app, with their state, region and image",
+		args:        func(app string) []string { return []string{"machines", "list", "--app", app, "--json"} },
+	},
+	{
+		name:        "status",
+		description: "The status of the app: its deployment, Machines and their health checks",
+		args:        func(app string) []string { return []string{"status", "--app", app, "--json"} },
+	},
+}
+
+// appURI returns the URI of the resource named name of app.
+func appURI(app, name string) string {
+	return fmt.Sprintf("%s/%s/%s", resourcePrefix, app, name)
+}
+
+// parseAppURI returns the app and the name of the resource at uri, as in
+// fly://apps/{app}/{name}.
+func parseAppURI(uri string) (app, name string, err error) {
+	rest, ok := strings.CutPrefix(uri, resourcePrefix+"/")
+	if !ok {
+		return "", "", fmt.Errorf("unknown resource %s", uri)
+	}
+
+	app, name, ok = strings.Cut(rest, "/")
+	if !ok || app == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("unknown resource %s", uri)
+	}
+
+	return app, name, nil
+}
+
+// resourceToolName returns the name the policy and the audit log know reads of
+// the resources named name by, like fly-resource-logs, so that policy rules
+// can match them like tools.
+func resourceToolName(name string) string {
+	return "fly-resource-" + name
+}
+
+// resources serves the resources of the apps of the user, and notifies the
+// clients subscribed to the logs of an app when there are new ones. Reads and
+// subscriptions are subject to the policy, and recorded in the audit log.
+type resources struct {
+	srv    *server.MCPServer
+	runner *executor
+	pol    *policy.Policy
+	audit  *policy.AuditLog
+
+	mu       sync.Mutex
+	watchers map[watcherKey]*logWatcher
+}
+
+// watcherKey identifies the logs of an app as seen with a token, so that
+// clients with different tokens don't share them.
+type watcherKey struct {
+	app   string
+	token string
+}
+
+func newResources(srv *server.MCPServer, runner *executor, pol *policy.Policy, audit *policy.AuditLog) *resources {
+	return &resources{
+		srv:      srv,
+		runner:   runner,
+		pol:      pol,
+		audit:    audit,
+		watchers: map[watcherKey]*logWatcher{},
+	}
+}
+
+// register adds the resources to the server, and hooks to follow the
+// subscriptions of its clients.
+func (r *resources) register(hooks *server.Hooks) {
+	r.srv.AddResource(
+		mcpGo.NewResource(resourcePrefix, "apps",
+			mcpGo.WithResourceDescription("The apps of the organizations of the user"),
+			mcpGo.WithMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcpGo.ReadResourceRequest) ([]mcpGo.ResourceContents, error) {
+			return r.read(ctx, request.Params.URI, []string{"apps", "list", "--json"})
+		},
+	)
+
+	for _, resource := range appResources {
+		r.srv.AddResourceTemplate(
+			mcpGo.NewResourceTemplate(appURI("{app}", resource.name), "app "+resource.name,
+				mcpGo.WithTemplateDescription(resource.description),
+				mcpGo.WithTemplateMIMEType("application/json"),
+			),
+			func(ctx context.Context, request mcpGo.ReadResourceRequest) ([]mcpGo.ResourceContents, error) {
+				app, _, err := parseAppURI(request.Params.URI)
+				if err != nil {
+					return nil, err
+				}
+
+				return r.read(ctx, request.Params.URI, resource.args(app))
+			},
+		)
+	}
+
+	r.srv.AddResourceTemplate(
+		mcpGo.NewResourceTemplate(appURI("{app}", "logs"), "app logs",
+			mcpGo.WithTemplateDescription("The latest logs of the app. Subscribe to be notified of new ones."),
+			mcpGo.WithTemplateMIMEType("application/json"),
+		),
+		r.readLogs,
+	)
+
+	hooks.AddAfterSubscribe(func(ctx context.Context, _ any, message *mcpGo.SubscribeRequest, _ *mcpGo.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			r.subscribe(ctx, session.SessionID(), message.Params.URI)
+		}
+	})
+
+	hooks.AddAfterUnsubscribe(func(ctx context.Context, _ any, message *mcpGo.UnsubscribeRequest, _ *mcpGo.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			r.unsubscribe(session.SessionID(), message.Params.URI)
+		}
+	})
+
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		r.unsubscribe(session.SessionID(), "")
+	})
+}
+
+// decide returns what the policy does with reads of the resource at uri.
+func (r *resources) decide(uri string) (policy.Call, policy.Decision, error) {
+	app, name := "", "apps"
+	if uri != resourcePrefix {
+		var err error
+		if app, name, err = parseAppURI(uri); err != nil {
+			return policy.Call{}, policy.Decision{}, err
+		}
+	}
+
+	call := policy.Call{Tool: resourceToolName(name), App: app, ReadOnly: true}
+
+	return call, r.pol.Decide(call), nil
+}
+
+// guardRead enforces the policy on reading the resource at uri with read,
+// asking the user to confirm it through the client when needed, and records
+// the read in the audit log.
+func (r *resources) guardRead(ctx context.Context, uri string, read func() ([]mcpGo.ResourceContents, error)) ([]mcpGo.ResourceContents, error) {
+	start := time.Now()
+
+	call, decision, err := r.decide(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := policy.AuditEntry{
+		Time:      start,
+		Tool:      call.Tool,
+		Arguments: map[string]any{"uri": uri},
+		Decision:  decision.Action,
+		Reason:    decision.Reason,
+	}
+
+	contents, err := func() ([]mcpGo.ResourceContents, error) {
+		switch decision.Action {
+		case policy.Deny:
+			return nil, fmt.Errorf("reading %s is denied by %s", uri, decision.Reason)
+		case policy.Confirm:
+			confirmed, err := confirmCall(ctx, r.srv, call.Tool, entry.Arguments, decision.Reason)
+			entry.Confirmed = &confirmed
+			switch {
+			case err != nil:
+				return nil, fmt.Errorf("reading %s must be confirmed (%s), but the user couldn't be asked: %w", uri, decision.Reason, err)
+			case !confirmed:
+				return nil, fmt.Errorf("the user declined to read %s", uri)
+			}
+		}
+
+		return read()
+	}()
+
+	entry.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		entry.Error = true
+		entry.Result = policy.RedactResult(call.Tool, err.Error())
+	} else {
+		var text strings.Builder
+		for _, content := range contents {
+			if textContents, ok := content.(mcpGo.TextResourceContents); ok {
+				text.WriteString(textContents.Text)
+			}
+		}
+		entry.Result = policy.RedactResult(call.Tool, text.String())
+	}
+	recordAudit(r.audit, entry)
+
+	return contents, err
+}
+
+// read returns what the command of args prints as the contents of the
+// resource at uri.
+func (r *resources) read(ctx context.Context, uri string, args []string) ([]mcpGo.ResourceContents, error) {
+	return r.guardRead(ctx, uri, func() ([]mcpGo.ResourceContents, error) {
+		output, err := r.runner.output(ctx, withAccessToken(ctx, args))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w: %s", uri, err, bytes.TrimSpace(output))
+		}
+
+		return []mcpGo.ResourceContents{
+			mcpGo.TextResourceContents{
+				URI:      uri,
+				MIMEType: "application/json",
+				Text:     strings.TrimSpace(string(output)),
+			},
+		}, nil
+	})
+}
+
+// readLogs returns the logs the client is subscribed to, or fetches the
+// latest ones.
+func (r *resources) readLogs(ctx context.Context, request mcpGo.ReadResourceRequest) ([]mcpGo.ResourceContents, error) {
+	uri := request.Params.URI
+
+	return r.guardRead(ctx, uri, func() ([]mcpGo.ResourceContents, error) {
+		return r.logs(ctx, uri)
+	})
+}
+
+func (r *resources) logs(ctx context.Context, uri string) ([]mcpGo.ResourceContents, error) {
+	app, _, err := parseAppURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	watcher := r.watchers[watcherKey{app: app, token: requestToken(ctx)}]
+	r.mu.Unlock()
+
+	var entries []logs.LogEntry
+	if watcher != nil {
+		entries = watcher.entries()
+	} else {
+		output, err := r.runner.output(ctx, withAccessToken(ctx, []string{"logs", "--app", app, "--no-tail", "--json"}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w: %s", uri, err, bytes.TrimSpace(output))
+		}
+
+		if entries, err = decodeLogEntries(output); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", uri, err)
+		}
+	}
+
+	text, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcpGo.ResourceContents{
+		mcpGo.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(text),
+		},
+	}, nil
+}
+
+// decodeLogEntries decodes the entries fly logs --json prints, one JSON
+// object after the other.
+func decodeLogEntries(output []byte) ([]logs.LogEntry, error) {
+	entries := []logs.LogEntry{}
+
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var entry logs.LogEntry
+		switch err := dec.Decode(&entry); {
+		case errors.Is(err, io.EOF):
+			return entries, nil
+		case err != nil:
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+// subscribe follows the logs at uri for the session, starting to tail them
+// when it's the first one to. Other resources don't change on their own, so
+// subscribing to them does nothing. Logs are only followed when the policy
+// allows reading them without confirmation.
+func (r *resources) subscribe(ctx context.Context, sessionID, uri string) {
+	app, name, err := parseAppURI(uri)
+	if err != nil || name != "logs" {
+		return
+	}
+
+	call, decision, err := r.decide(uri)
+	if err != nil {
+		return
+	}
+
+	recordAudit(r.audit, policy.AuditEntry{
+		Time:      time.Now(),
+		Tool:      call.Tool,
+		Arguments: map[string]any{"uri": uri, "subscribe": true},
+		Decision:  decision.Action,
+		Reason:    decision.Reason,
+	})
+
+	if decision.Action != policy.Allow {
+		fmt.Fprintf(os.Stderr, "not following the logs of %s, %s doesn't allow it without confirmation\n", app, decision.Reason)
+
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := watcherKey{app: app, token: requestToken(ctx)}
+	watcher, ok := r.watchers[key]
+	if !ok {
+		watcherCtx, err := r.clientContext(key.token)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to follow the logs of %s: %v\n", app, err)
+
+			return
+		}
+
+		watcherCtx, cancel := context.WithCancel(watcherCtx)
+		watcher = newLogWatcher(uri, cancel)
+		r.watchers[key] = watcher
+		go watcher.run(watcherCtx, app, r.notify)
+	}
+
+	watcher.addSession(sessionID)
+}
+
+// unsubscribe stops following the logs at uri for the session, or all the
+// logs it follows when uri is empty, and stops tailing logs nobody follows.
+func (r *resources) unsubscribe(sessionID, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, watcher := range r.watchers {
+		if uri != "" && watcher.uri != uri {
+			continue
+		}
+
+		if watcher.removeSession(sessionID) == 0 {
+			watcher.stop()
+			delete(r.watchers, key)
+		}
+	}
+}
+
+// notify tells the sessions that the resource at uri changed.
+func (r *resources) notify(uri string, sessionIDs []string) {
+	for _, sessionID := range sessionIDs {
+		err := r.srv.SendNotificationToSpecificClient(sessionID, mcpGo.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to notify MCP session %s of %s: %v\n", sessionID, uri, err)
+		}
+	}
+}
+
+// clientContext returns the server's context with an API client using token,
+// or the server's token when it's empty.
+func (r *resources) clientContext(token string) (context.Context, error) {
+	ctx := r.runner.base
+	if token != "" {
+		ctx = flyutil.NewContextWithClient(ctx, flyutil.NewClientFromOptions(ctx, fly.ClientOptions{
+			Tokens: tokens.Parse(token),
+		}))
+	}
+
+	return preparers.InitClient(ctx)
+}
+
+// requestToken returns the auth token of the request ctx carries, if any.
+func requestToken(ctx context.Context) string {
+	token, _ := ctx.Value(authTokenKey).(string)
+
+	return token
+}
+
+// logWatcher tails the logs of an app, keeping the latest entries, and
+// notifies the sessions that follow them of new ones.
+type logWatcher struct {
+	uri    string
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	buffer   []logs.LogEntry
+	sessions map[string]bool
+}
+
+func newLogWatcher(uri string, cancel context.CancelFunc) *logWatcher {
+	return &logWatcher{
+		uri:      uri,
+		cancel:   cancel,
+		sessions: map[string]bool{},
+	}
+}
+
+// run tails the logs of app until ctx is canceled, calling notify at most
+// every logNotifyInterval when there are new entries.
+func (w *logWatcher) run(ctx context.Context, app string, notify func(uri string, sessionIDs []string)) {
+	entries := make(chan logs.LogEntry)
+	go func() {
+		defer close(entries)
+
+		opts := &logs.LogOptions{AppName: app}
+		if err := logs.Poll(ctx, entries, flyutil.ClientFromContext(ctx), opts); err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "failed to follow the logs of %s: %v\n", app, err)
+		}
+	}()
+
+	ticker := time.NewTicker(logNotifyInterval)
+	defer ticker.Stop()
+
+	var changed bool
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return
+			}
+
+			w.add(entry)
+			changed = true
+		case <-ticker.C:
+			if changed {
+				notify(w.uri, w.sessionIDs())
+				changed = false
+			}
+		}
+	}
+}
+
+func (w *logWatcher) stop() {
+	w.cancel()
+}
+
+// add keeps entry, dropping the oldest one past maxLogEntries.
+func (w *logWatcher) add(entry logs.LogEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buffer) == maxLogEntries {
+		w.buffer = append(w.buffer[:0], w.buffer[1:]...)
+	}
+	w.buffer = append(w.buffer, entry)
+}
+
+// entries returns the kept entries, oldest first.
+func (w *logWatcher) entries() []logs.LogEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]logs.LogEntry{}, w.buffer...)
+}
+
+func (w *logWatcher) addSession(sessionID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.sessions[sessionID] = true
+}
+
+// removeSession stops notifying the session, and returns how many are left.
+func (w *logWatcher) removeSession(sessionID string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.sessions, sessionID)
+
+	return len(w.sessions)
+}
+
+func (w *logWatcher) sessionIDs() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ids := make([]string, 0, len(w.sessions))
+	for id := range w.sessions {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mcpGo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/command/mcp/policy"
+	"github.com/superfly/flyctl/logs"
+)
+
+func TestParseAppURI(t *testing.T) {
+	app, name, err := parseAppURI(appURI("web", "logs"))
+	require.NoError(t, err)
+	assert.Equal(t, "web", app)
+	assert.Equal(t, "logs", name)
+
+	for _, uri := range []string{"fly://apps", "fly://apps/web", "fly://apps//logs", "fly://apps/web/logs/more", "file:///web/logs"} {
+		_, _, err := parseAppURI(uri)
+		assert.Error(t, err, uri)
+	}
+}
+
+func TestDecodeLogEntries(t *testing.T) {
+	entries, err := decodeLogEntries([]byte(`{
+    "level": "info",
+    "message": "listening"
+}
+{
+    "level": "error",
+    "message": "crashed"
+}
+`))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "crashed", entries[1].Message)
+
+	entries, err = decodeLogEntries(nil)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = decodeLogEntries([]byte("Error: app not found"))
+	assert.Error(t, err)
+}
+
+func TestLogWatcher(t *testing.T) {
+	w := newLogWatcher(appURI("web", "logs"), func() {})
+
+	for i := range maxLogEntries + 10 {
+		w.add(logs.LogEntry{Message: fmt.Sprint(i)})
+	}
+
+	entries := w.entries()
+	require.Len(t, entries, maxLogEntries)
+	assert.Equal(t, "10", entries[0].Message)
+	assert.Equal(t, fmt.Sprint(maxLogEntries+9), entries[maxLogEntries-1].Message)
+
+	w.addSession("a")
+	w.addSession("b")
+	assert.Equal(t, 1, w.removeSession("a"))
+	assert.Equal(t, []string{"b"}, w.sessionIDs())
+	assert.Equal(t, 0, w.removeSession("b"))
+}
+
+func TestResourcesPolicy(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := policy.OpenAuditLog(auditPath)
+	require.NoError(t, err)
+
+	pol := &policy.Policy{Rules: []policy.Rule{
+		{Action: policy.Deny, Tools: []string{"fly-resource-*"}, Apps: []string{"prod-*"}},
+	}}
+	r := newResources(nil, nil, pol, audit)
+
+	read := func() ([]mcpGo.ResourceContents, error) {
+		return []mcpGo.ResourceContents{mcpGo.TextResourceContents{URI: "uri", Text: "contents"}}, nil
+	}
+
+	contents, err := r.guardRead(context.Background(), appURI("staging-web", "status"), read)
+	require.NoError(t, err)
+	assert.Len(t, contents, 1)
+
+	_, err = r.guardRead(context.Background(), appURI("prod-web", "config"), read)
+	assert.ErrorContains(t, err, "reading fly://apps/prod-web/config is denied by policy rule 1")
+
+	// The app of the list of apps isn't known, so the rule denies it too.
+	_, err = r.guardRead(context.Background(), resourcePrefix, read)
+	assert.ErrorContains(t, err, "denied")
+
+	r.subscribe(context.Background(), "session", appURI("prod-web", "logs"))
+	assert.Empty(t, r.watchers)
+
+	require.NoError(t, audit.Close())
+	data, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"tool":"fly-resource-status","arguments":{"uri":"fly://apps/staging-web/status"},"decision":"allow"`)
+	assert.Contains(t, string(data), `"tool":"fly-resource-config","arguments":{"uri":"fly://apps/prod-web/config"},"decision":"deny"`)
+	assert.Contains(t, string(data), `"subscribe":true`)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/internal/buildinfo"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/mcp/policy"
 	mcpServer "github.com/superfly/flyctl/internal/command/mcp/server"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
@@ -41,14 +42,16 @@ type contextKey string
 
 const authTokenKey contextKey = "authToken"
 
-func newServer() *cobra.Command {
+func newServer(newRoot func() *cobra.Command) *cobra.Command {
 	const (
 		short = "[experimental] Start a flyctl MCP server"
 		long  = short + "\n"
 		usage = "server"
 	)
 
-	cmd := command.New(usage, short, long, runServer)
+	cmd := command.New(usage, short, long, func(ctx context.Context) error {
+		return runServer(ctx, newRoot)
+	})
 	cmd.Args = cobra.ExactArgs(0)
 
 	flag.Add(cmd,
@@ -85,6 +88,18 @@ func newServer() *cobra.Command {
 			Default:     "127.0.0.1",
 			Description: "Local address to bind to",
 		},
+		flag.String{
+			Name:        "policy",
+			Description: "Path to a policy file allowing or denying tools (default ~/.fly/" + defaultPolicyFile + " when it exists)",
+		},
+		flag.Bool{
+			Name:        "read-only",
+			Description: "Only allow tools that don't change anything",
+		},
+		flag.String{
+			Name:        "audit-log",
+			Description: "Path to the log of tool calls (default ~/.fly/" + defaultAuditLogFile + ")",
+		},
 	)
 
 	for client, name := range McpClients {
@@ -99,7 +114,7 @@ func newServer() *cobra.Command {
 	return cmd
 }
 
-func runServer(ctx context.Context) error {
+func runServer(ctx context.Context, newRoot func() *cobra.Command) error {
 	flyctl, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to find executable: %w", err)
@@ -119,7 +134,7 @@ func runServer(ctx context.Context) error {
 			server = "flyctl"
 		}
 
-		args := []string{"mcp", "server"}
+		args := append([]string{"mcp", "server"}, policyArgs(ctx)...)
 
 		if stream || sse {
 			args = []string{
@@ -152,6 +167,7 @@ func runServer(ctx context.Context) error {
 			// If sse or stream, start flyctl mcp server in the background
 			if stream || sse {
 				args := []string{"mcp", "server", "--port", strconv.Itoa(flag.GetInt(ctx, "port"))}
+				args = append(args, policyArgs(ctx)...)
 
 				if token := getAccessToken(ctx); token != "" {
 					args = append(args, "--access-token", token)
@@ -196,30 +212,55 @@ func runServer(ctx context.Context) error {
 		return nil
 	}
 
+	pol, audit, err := loadPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
 	// Create MCP server
+	hooks := &server.Hooks{}
 	srv := server.NewMCPServer(
 		"FlyMCP 🚀",
 		buildinfo.Info().Version.String(),
+		server.WithElicitation(),
+		server.WithResourceCapabilities(true, false),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(hooks),
 	)
 
-	// Register commands
-	for _, cmd := range COMMANDS {
+	runner := &executor{base: ctx, newRoot: newRoot}
+
+	// Register the resources of apps, and the prompts embedding them
+	res := newResources(srv, runner, pol, audit)
+	res.register(hooks)
+	registerPrompts(srv, res)
+
+	// Register the hand-written commands, then a tool for every other command
+	commands := slices.Clone(COMMANDS)
+	for _, cmd := range mcpServer.FromCommandTree(newRoot()) {
+		if !slices.ContainsFunc(commands, func(c mcpServer.FlyCommand) bool { return c.ToolName == cmd.ToolName }) {
+			commands = append(commands, cmd)
+		}
+	}
+
+	for _, cmd := range commands {
 		// Create a tool function for each command
 		tool := func(ctx context.Context, request mcpGo.CallToolRequest) (*mcpGo.CallToolResult, error) {
 			// Extract arguments from the request
 			args := make(map[string]string)
 			argMap, ok := request.Params.Arguments.(map[string]any)
 			if !ok {
-				return nil, fmt.Errorf("invalid arguments: expected map[string]any")
+				return invalidArgument("invalid arguments: expected an object"), nil
 			}
 			for argName, argValue := range argMap {
 				description, ok := cmd.ToolArgs[argName]
 				if !ok {
-					return nil, fmt.Errorf("unknown argument %s", argName)
+					return invalidArgument("unknown argument %s", argName), nil
 				}
 
 				if description.Required && argValue == nil {
-					return nil, fmt.Errorf("argument %s is required", argName)
+					return invalidArgument("argument %s is required", argName), nil
 				}
 
 				switch description.Type {
@@ -227,16 +268,16 @@ func runServer(ctx context.Context) error {
 					if strValue, ok := argValue.(string); ok {
 						args[argName] = strValue
 					} else {
-						return nil, fmt.Errorf("argument %s must be a string", argName)
+						return invalidArgument("argument %s must be a string", argName), nil
 					}
 				case "enum":
 					if strValue, ok := argValue.(string); ok {
 						if !slices.Contains(description.Enum, strValue) {
-							return nil, fmt.Errorf("argument %s must be one of %v", argName, description.Enum)
+							return invalidArgument("argument %s must be one of %v", argName, description.Enum), nil
 						}
 						args[argName] = strValue
 					} else {
-						return nil, fmt.Errorf("argument %s must be a string", argName)
+						return invalidArgument("argument %s must be a string", argName), nil
 					}
 				case "array":
 					if arrValue, ok := argValue.([]any); ok {
@@ -246,13 +287,13 @@ func runServer(ctx context.Context) error {
 								if str, ok := v.(string); ok {
 									strArr[i] = str
 								} else {
-									return nil, fmt.Errorf("argument %s must be an array of strings", argName)
+									return invalidArgument("argument %s must be an array of strings", argName), nil
 								}
 							}
 							args[argName] = strings.Join(strArr, ",")
 						}
 					} else {
-						return nil, fmt.Errorf("argument %s must be an array of strings", argName)
+						return invalidArgument("argument %s must be an array of strings", argName), nil
 					}
 				case "hash":
 					if arrValue, ok := argValue.([]any); ok {
@@ -264,28 +305,28 @@ func runServer(ctx context.Context) error {
 									str = "'" + strings.ReplaceAll(str, "'", "'\\''") + "'"
 									strArr[i] = str
 								} else {
-									return nil, fmt.Errorf("argument %s must be an array of strings", argName)
+									return invalidArgument("argument %s must be an array of strings", argName), nil
 								}
 							}
 							args[argName] = strings.Join(strArr, " ")
 						}
 					} else {
-						return nil, fmt.Errorf("argument %s must be an array of strings", argName)
+						return invalidArgument("argument %s must be an array of strings", argName), nil
 					}
 				case "number":
 					if numValue, ok := argValue.(float64); ok {
 						args[argName] = strconv.FormatFloat(numValue, 'f', -1, 64)
 					} else {
-						return nil, fmt.Errorf("argument %s must be a number", argName)
+						return invalidArgument("argument %s must be a number", argName), nil
 					}
 				case "boolean":
 					if boolValue, ok := argValue.(bool); ok {
 						args[argName] = strconv.FormatBool(boolValue)
 					} else {
-						return nil, fmt.Errorf("argument %s must be a boolean", argName)
+						return invalidArgument("argument %s must be a boolean", argName), nil
 					}
 				default:
-					return nil, fmt.Errorf("unsupported argument type %s for argument %s", description.Type, argName)
+					return invalidArgument("unsupported argument type %s for argument %s", description.Type, argName), nil
 				}
 			}
 
@@ -293,7 +334,7 @@ func runServer(ctx context.Context) error {
 			for argName, description := range cmd.ToolArgs {
 				if description.Required {
 					if _, ok := args[argName]; !ok {
-						return nil, fmt.Errorf("missing required argument %s", argName)
+						return invalidArgument("missing required argument %s", argName), nil
 					}
 				} else if description.Default != "" {
 					if _, ok := args[argName]; !ok {
@@ -305,7 +346,7 @@ func runServer(ctx context.Context) error {
 			// Call the builder function to get the command arguments
 			cmdArgs, err := cmd.Builder(args)
 			if err != nil {
-				return nil, fmt.Errorf("failed to build command: %w", err)
+				return invalidArgument("failed to build command: %v", err), nil
 			}
 
 			// Log the command (without the auth token and any secret values)
@@ -322,27 +363,17 @@ func runServer(ctx context.Context) error {
 				fmt.Fprintf(os.Stderr, "Executing flyctl command: %v\n", cmdArgs)
 			}
 
-			// If auth token is present in context, add --access-token flag
-			if token, ok := ctx.Value(authTokenKey).(string); ok && token != "" {
-				cmdArgs = append(cmdArgs, "--access-token", token)
-			}
-
-			// Execute the command
-			execCmd := exec.Command(flyctl, cmdArgs...)
-			output, err := execCmd.CombinedOutput()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error executing flyctl: %v\nOutput: %s\n", err, string(output))
-
-				return nil, fmt.Errorf("failed to execute command: %v\nOutput: %s", err, string(output))
-			}
-
-			// Return the output as a tool result
-			return mcpGo.NewToolResultText(string(output)), nil
+			// Execute the command in-process, with the auth token of the request if any
+			return runner.run(ctx, withAccessToken(ctx, cmdArgs)), nil
 		}
 
 		// Register the tool with the server
+		readOnly, destructive := policy.Classify(cmd.ToolName)
+		destructive = destructive || cmd.Confirms
 		toolOptions := []mcpGo.ToolOption{
 			mcpGo.WithDescription(cmd.ToolDescription),
+			mcpGo.WithReadOnlyHintAnnotation(readOnly),
+			mcpGo.WithDestructiveHintAnnotation(destructive),
 		}
 
 		for argName, arg := range cmd.ToolArgs {
@@ -420,7 +451,7 @@ func runServer(ctx context.Context) error {
 
 		srv.AddTool(
 			mcpGo.NewTool(cmd.ToolName, toolOptions...),
-			tool,
+			guard(srv, pol, audit, cmd.ToolName, cmd.Confirms, tool),
 		)
 	}
 
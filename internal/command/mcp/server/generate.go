@@ -0,0 +1,216 @@
+package mcpServer
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// This file derives FlyCommand definitions from the cobra command tree, so that every
+// flyctl command is available as an MCP tool without a hand-written builder. Tools are
+// named after the command path, their arguments are the command's flags, and positional
+// arguments are passed as an "args" array.
+
+// allowedCommands lists the top-level commands whose subcommands are turned into tools.
+// Others, like logs, ssh, auth, tokens or the database commands, are left out since
+// they're interactive, never return, or print credentials.
+var allowedCommands = []string{
+	"apps",
+	"certs",
+	"checks",
+	"config",
+	"deploy",
+	"dig",
+	"history",
+	"image",
+	"incidents",
+	"info",
+	"ips",
+	"machine",
+	"orgs",
+	"ping",
+	"platform",
+	"regions",
+	"releases",
+	"scale",
+	"secrets",
+	"services",
+	"status",
+	"volumes",
+}
+
+// excludedCommands lists the commands, by path without the root, under allowedCommands
+// that aren't turned into tools because they're interactive, never return or print
+// secrets.
+var excludedCommands = []string{
+	"apps open",
+	"machine proxy",
+	"secrets decrypt",
+	"secrets edit",
+	"secrets import",
+}
+
+// excludedFlags are never exposed as tool arguments: they're set by the server, or
+// make commands interactive or never return. Calls are confirmed by the server
+// rather than with --yes.
+var excludedFlags = []string{"help", "json", "access-token", "verbose", "debug", "shell", "select", "watch", "follow", "yes"}
+
+// positionalArg is the tool argument that holds positional command line arguments.
+const positionalArg = "args"
+
+// FromCommandTree returns a FlyCommand for every runnable, visible command of allowedCommands
+// under root.
+// Commands are invoked with --json when they support it.
+func FromCommandTree(root *cobra.Command) []FlyCommand {
+	var commands []FlyCommand
+
+	var walk func(cmd *cobra.Command, path []string)
+	walk = func(cmd *cobra.Command, path []string) {
+		for _, child := range cmd.Commands() {
+			if child.Hidden || child.Deprecated != "" {
+				continue
+			}
+
+			childPath := append(slices.Clone(path), child.Name())
+			if !slices.Contains(allowedCommands, childPath[0]) || slices.Contains(excludedCommands, strings.Join(childPath, " ")) {
+				continue
+			}
+
+			if child.Runnable() {
+				commands = append(commands, commandTool(child, childPath))
+			}
+
+			walk(child, childPath)
+		}
+	}
+	walk(root, nil)
+
+	return commands
+}
+
+func commandTool(cmd *cobra.Command, path []string) FlyCommand {
+	description := cmd.Short
+	if long := strings.TrimSpace(cmd.Long); long != "" && long != strings.TrimSpace(cmd.Short) {
+		description += "\n\n" + long
+	}
+
+	args := map[string]FlyArg{}
+	types := map[string]string{}
+
+	cmd.NonInheritedFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Deprecated != "" || slices.Contains(excludedFlags, f.Name) {
+			return
+		}
+
+		arg := flagArg(f)
+		args[f.Name] = arg
+		types[f.Name] = arg.Type
+	})
+
+	if usage := positionalUsage(cmd); usage != "" {
+		args[positionalArg] = FlyArg{
+			Description: "Positional arguments: " + usage,
+			Type:        "hash",
+		}
+	}
+
+	hasJSON := cmd.Flags().Lookup("json") != nil
+	confirms := cmd.Flags().Lookup("yes") != nil
+
+	return FlyCommand{
+		ToolName:        "fly-" + strings.Join(path, "-"),
+		ToolDescription: description,
+		ToolArgs:        args,
+		Confirms:        confirms,
+		Builder: func(values map[string]string) ([]string, error) {
+			cmdArgs := slices.Clone(path)
+
+			for _, name := range slices.Sorted(maps.Keys(values)) {
+				value := values[name]
+				if name == positionalArg {
+					continue
+				}
+
+				switch types[name] {
+				case "":
+					return nil, fmt.Errorf("unknown argument %s", name)
+				case "hash":
+					items, err := shlex.Split(value)
+					if err != nil {
+						return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+					}
+					for _, item := range items {
+						cmdArgs = append(cmdArgs, "--"+name+"="+item)
+					}
+				default:
+					cmdArgs = append(cmdArgs, "--"+name+"="+value)
+				}
+			}
+
+			if hasJSON {
+				cmdArgs = append(cmdArgs, "--json")
+			}
+			if confirms {
+				cmdArgs = append(cmdArgs, "--yes")
+			}
+
+			if positional, ok := values[positionalArg]; ok && positional != "" {
+				items, err := shlex.Split(positional)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse %s: %w", positionalArg, err)
+				}
+				cmdArgs = append(cmdArgs, "--")
+				cmdArgs = append(cmdArgs, items...)
+			}
+
+			return cmdArgs, nil
+		},
+	}
+}
+
+// flagArg describes a flag as a tool argument. List flags are passed as "hash"
+// arguments, so that their items may contain commas. Defaults are only described:
+// passing them would mark the flags as changed, which some commands act upon.
+func flagArg(f *pflag.Flag) FlyArg {
+	arg := FlyArg{
+		Description: f.Usage,
+		Required:    len(f.Annotations[cobra.BashCompOneRequiredFlag]) > 0,
+	}
+
+	switch f.Value.Type() {
+	case "bool":
+		arg.Type = "boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		arg.Type = "number"
+	case "stringArray", "stringSlice", "intSlice", "stringToString":
+		arg.Type = "hash"
+	default:
+		arg.Type = "string"
+	}
+
+	if arg.Type != "hash" && f.DefValue != "" && f.DefValue != "false" {
+		arg.Description += fmt.Sprintf(" (default %s)", f.DefValue)
+	}
+
+	return arg
+}
+
+// positionalUsage returns the positional arguments of the command's usage line,
+// like "<app name>" for "destroy <app name> [flags]".
+func positionalUsage(cmd *cobra.Command) string {
+	fields := strings.Fields(cmd.Use)
+	if len(fields) < 2 {
+		return ""
+	}
+
+	fields = slices.DeleteFunc(fields[1:], func(field string) bool {
+		return strings.EqualFold(field, "[flags]")
+	})
+
+	return strings.Join(fields, " ")
+}
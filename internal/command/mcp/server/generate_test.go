@@ -0,0 +1,97 @@
+package mcpServer
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTree(t *testing.T) *cobra.Command {
+	noop := func(*cobra.Command, []string) error { return nil }
+
+	root := &cobra.Command{Use: "fly"}
+	root.PersistentFlags().StringP("access-token", "t", "", "Fly API Access Token")
+
+	apps := &cobra.Command{Use: "apps", Short: "Manage apps"}
+	destroy := &cobra.Command{Use: "destroy <app name> [flags]", Short: "Destroy an app", RunE: noop}
+	destroy.Flags().Bool("yes", false, "Accept all confirmations")
+	list := &cobra.Command{Use: "list", Short: "List apps", RunE: noop}
+	list.Flags().String("org", "", "Organization slug")
+	list.Flags().Int("limit", 10, "Limit")
+	list.Flags().StringArray("label", nil, "Labels to filter by")
+	list.Flags().Bool("json", false, "JSON output")
+	list.Flags().String("secret", "", "Hidden flag")
+	require.NoError(t, list.Flags().MarkHidden("secret"))
+	apps.AddCommand(destroy, list, &cobra.Command{Use: "hidden", Hidden: true, RunE: noop})
+
+	machine := &cobra.Command{Use: "machine", Short: "Manage machines"}
+	run := &cobra.Command{Use: "run <image> [command]", Short: "Run a machine", RunE: noop}
+	run.Flags().Bool("shell", false, "Open a shell on the machine")
+	run.Flags().String("region", "", "Region")
+	machine.AddCommand(run, &cobra.Command{Use: "proxy", RunE: noop})
+
+	tokens := &cobra.Command{Use: "tokens", Short: "Manage tokens"}
+	tokens.AddCommand(&cobra.Command{Use: "create", RunE: noop})
+
+	root.AddCommand(apps, machine, tokens, &cobra.Command{Use: "mcp", RunE: noop})
+
+	return root
+}
+
+func TestFromCommandTree(t *testing.T) {
+	commands := FromCommandTree(testTree(t))
+
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.ToolName
+	}
+	assert.ElementsMatch(t, []string{"fly-apps-destroy", "fly-apps-list", "fly-machine-run"}, names)
+
+	var list, destroy, run FlyCommand
+	for _, cmd := range commands {
+		switch cmd.ToolName {
+		case "fly-apps-list":
+			list = cmd
+		case "fly-apps-destroy":
+			destroy = cmd
+		case "fly-machine-run":
+			run = cmd
+		}
+	}
+
+	// Flags that make commands interactive aren't exposed.
+	assert.Contains(t, run.ToolArgs, "region")
+	assert.NotContains(t, run.ToolArgs, "shell")
+
+	assert.Equal(t, "List apps", list.ToolDescription)
+	assert.Equal(t, "string", list.ToolArgs["org"].Type)
+	assert.Equal(t, "number", list.ToolArgs["limit"].Type)
+	assert.Contains(t, list.ToolArgs["limit"].Description, "(default 10)")
+	assert.Equal(t, "hash", list.ToolArgs["label"].Type)
+	assert.NotContains(t, list.ToolArgs, "json")
+	assert.NotContains(t, list.ToolArgs, "secret")
+	assert.NotContains(t, list.ToolArgs, "access-token")
+	assert.NotContains(t, list.ToolArgs, positionalArg)
+
+	args, err := list.Builder(map[string]string{"org": "personal", "limit": "5", "label": "'a b' c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apps", "list", "--label=a b", "--label=c", "--limit=5", "--org=personal", "--json"}, args)
+
+	_, err = list.Builder(map[string]string{"nope": "x"})
+	assert.Error(t, err)
+
+	// Calls are confirmed by the server, not with --yes.
+	assert.NotContains(t, destroy.ToolArgs, "yes")
+	assert.True(t, destroy.Confirms)
+	assert.False(t, list.Confirms)
+	assert.Equal(t, "Positional arguments: <app name>", destroy.ToolArgs[positionalArg].Description)
+
+	_, err = destroy.Builder(map[string]string{"yes": "true"})
+	assert.Error(t, err)
+
+	args, err = destroy.Builder(map[string]string{positionalArg: "my-app"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apps", "destroy", "--yes", "--", "my-app"}, args)
+}
@@ -11,10 +11,14 @@ package mcpServer
 // that can be passed to the Builder.  This function should return an error if the arguments
 // are invalid or if there is an issue building the command line arguments.
 
-// Argument values passed to the Builder are intended to be passed to exec.Command, and therefore
-// are strings. The builder is responsible for constructing a flyctl command from the arguments,
-// expressed as a slice of strings.  The builder should return an error if there is an issue
-// building the command line arguments, or if the arguments are invalid.
+// Argument values passed to the Builder are strings, as the builder is responsible for
+// constructing a flyctl command line from the arguments, expressed as a slice of strings.
+// The server runs that command line in-process, on a fresh command tree. The builder should
+// return an error if there is an issue building the command line arguments, or if the
+// arguments are invalid.
+
+// Commands without a hand-written definition get one derived from the cobra command tree,
+// see FromCommandTree.
 
 // FlyCommand represents a command for the Fly MCP server
 type FlyCommand struct {
@@ -22,6 +26,9 @@ type FlyCommand struct {
 	ToolDescription string
 	ToolArgs        map[string]FlyArg
 	Builder         func(args map[string]string) ([]string, error)
+	// Confirms is set for commands that ask for confirmation. They're passed
+	// --yes, as the server asks the user to confirm their calls instead.
+	Confirms bool
 }
 
 // FlyArg represents an argument for a Fly command
@@ -0,0 +1,208 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/uiex"
+	"github.com/superfly/flyctl/internal/uiexutil"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func newRollback() *cobra.Command {
+	const (
+		long = `Redeploy the image and config of a previous release of the application.
+The changes the rollback would make to the app's Machines are shown before
+asking for confirmation. Releases without a stored config are only rolled back
+with --use-current-config, which deploys their image with the app's current
+config.
+`
+		short = "Roll back the app to a previous release"
+		usage = "rollback <version>"
+	)
+
+	cmd := command.New(usage, short, long, runRollback,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.Strategy(),
+		flag.Detach(),
+		flag.Bool{
+			Name:        "skip-release-command",
+			Description: "Do not run the release command during the rollback",
+			Default:     false,
+		},
+		flag.Bool{
+			Name:        "auto-rollback",
+			Description: "Restore updated Machines to their current configuration if a rolling or canary rollback fails",
+			Default:     false,
+		},
+		flag.Bool{
+			Name:        "use-current-config",
+			Description: "Deploy the image of the release with the app's current config when the release has no stored config",
+			Default:     false,
+		},
+	)
+
+	return cmd
+}
+
+func runRollback(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		appName  = appconfig.NameFromContext(ctx)
+	)
+
+	version, err := parseReleaseVersion(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	release, err := fetchRelease(ctx, appName, version)
+	if err != nil {
+		return err
+	}
+	if release.ImageRef == "" {
+		return fmt.Errorf("release v%d has no image to roll back to", version)
+	}
+
+	cfg, err := releaseConfig(ctx, appName, release)
+	if err != nil {
+		return err
+	}
+
+	detach := flag.GetDetach(ctx)
+	manifest := &deploy.DeployManifest{
+		AppName:            appName,
+		Config:             cfg,
+		DeploymentImage:    release.ImageRef,
+		Strategy:           flag.GetString(ctx, "strategy"),
+		SkipSmokeChecks:    detach,
+		SkipHealthChecks:   detach,
+		SkipDNSChecks:      detach,
+		SkipReleaseCommand: flag.GetBool(ctx, "skip-release-command"),
+		AutoRollback:       flag.GetBool(ctx, "auto-rollback"),
+	}
+
+	plan, err := deploy.PlanManifest(ctx, manifest)
+	if err != nil {
+		return fmt.Errorf("failed to plan the rollback to v%d: %w", version, err)
+	}
+	deploy.RenderDeployPlan(io.Out, colorize, plan)
+	fmt.Fprintln(io.Out)
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Roll back %s to release v%d?", appName, version); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Rolling back %s to release v%d with image %s\n", colorize.Bold(appName), version, release.ImageRef)
+
+	return deploy.DeployFromManifest(ctx, manifest)
+}
+
+// parseReleaseVersion accepts release versions as printed by `fly releases`,
+// with or without the leading "v".
+func parseReleaseVersion(arg string) (int, error) {
+	version, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(arg), "v"))
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid release version %q, expected a version like v42", arg)
+	}
+
+	return version, nil
+}
+
+// releaseSlack is how many releases newer than the current one are listed when
+// looking for a release, like the ones of deployments in progress or failed.
+const releaseSlack = 20
+
+// fetchRelease returns the release of appName with version. Releases are listed
+// newest first, so enough of them are listed to reach version from the current
+// release.
+func fetchRelease(ctx context.Context, appName string, version int) (*uiex.Release, error) {
+	client := uiexutil.ClientFromContext(ctx)
+
+	current, err := client.GetCurrentRelease(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving the current release of %s: %w", appName, err)
+	}
+	if current == nil {
+		return nil, fmt.Errorf("app %s has no releases", appName)
+	}
+
+	releases, err := client.ListReleases(ctx, appName, releaseListLimit(current.Version, version))
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving app releases %s: %w", appName, err)
+	}
+
+	return findRelease(releases, version)
+}
+
+// releaseListLimit returns how many releases to list to find version when the
+// current release is currentVersion.
+func releaseListLimit(currentVersion, version int) int {
+	return max(currentVersion-version, 0) + 1 + releaseSlack
+}
+
+func findRelease(releases []uiex.Release, version int) (*uiex.Release, error) {
+	for i := range releases {
+		if releases[i].Version == version {
+			return &releases[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("release v%d not found", version)
+}
+
+// releaseConfig returns the app config release was deployed with. Releases
+// that don't have one stored are only redeployed with the app's current config
+// with --use-current-config, as that doesn't roll back the config.
+func releaseConfig(ctx context.Context, appName string, release *uiex.Release) (*appconfig.Config, error) {
+	if release.Definition == nil {
+		if !flag.GetBool(ctx, "use-current-config") {
+			return nil, fmt.Errorf("release v%d has no stored config to roll back to, use --use-current-config to deploy its image with the app's current config", release.Version)
+		}
+
+		terminal.Warnf("Release v%d has no stored config, its image will be deployed with the app's current config\n", release.Version)
+
+		return appconfig.FromRemoteApp(ctx, appName)
+	}
+
+	cfg, err := appconfig.FromDefinition(fly.DefinitionPtr(release.Definition))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the config of release v%d: %w", release.Version, err)
+	}
+	if err := cfg.SetMachinesPlatform(); err != nil {
+		return nil, fmt.Errorf("config of release v%d can't be deployed to Machines: %w", release.Version, err)
+	}
+	cfg.AppName = appName
+
+	return cfg, nil
+}
@@ -0,0 +1,42 @@
+package releases
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/uiex"
+)
+
+func TestParseReleaseVersion(t *testing.T) {
+	for arg, want := range map[string]int{"42": 42, "v42": 42, "V7": 7} {
+		got, err := parseReleaseVersion(arg)
+		require.NoError(t, err, arg)
+		assert.Equal(t, want, got, arg)
+	}
+
+	for _, arg := range []string{"", "v", "v0", "-3", "latest"} {
+		_, err := parseReleaseVersion(arg)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestReleaseListLimit(t *testing.T) {
+	assert.Equal(t, 1+releaseSlack, releaseListLimit(10, 10))
+	assert.Equal(t, 8+releaseSlack, releaseListLimit(10, 3))
+	assert.Equal(t, 1+releaseSlack, releaseListLimit(10, 12))
+}
+
+func TestFindRelease(t *testing.T) {
+	releases := []uiex.Release{
+		{Version: 3, ImageRef: "registry.fly.io/app:v3"},
+		{Version: 2, ImageRef: "registry.fly.io/app:v2"},
+	}
+
+	release, err := findRelease(releases, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "registry.fly.io/app:v2", release.ImageRef)
+
+	_, err = findRelease(releases, 1)
+	assert.ErrorContains(t, err, "release v1 not found")
+}
@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/appsecrets"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newApply() (cmd *cobra.Command) {
+	const (
+		long = `Make the app's secrets match a file of NAME=VALUE pairs, setting the secrets
+that are new or changed in a single update. Secrets set on the app but missing
+from the file are kept, unless --prune is set.
+
+Files encrypted with 'fly secrets encrypt' are decrypted, and with --resolve,
+references to secret managers like vault://path#key are resolved first.`
+		short = `Set the app's secrets to those of a file`
+		usage = "apply -f FILE [flags]"
+	)
+
+	cmd = command.New(usage, short, long, runApply, command.RequireSession, command.RequireAppName)
+
+	flag.Add(cmd,
+		sharedFlags,
+		secretsFileFlag,
+		resolveFlag,
+		identityFlag,
+		flag.Yes(),
+		flag.Bool{
+			Name:        "prune",
+			Description: "Unset the app's secrets that aren't in the file",
+		},
+	)
+
+	return cmd
+}
+
+func runApply(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+		prune   = flag.GetBool(ctx, "prune")
+	)
+
+	diff, err := loadSecretsDiff(ctx)
+	if err != nil {
+		return err
+	}
+
+	set, unset := diff.changes(prune)
+	if len(set) == 0 && len(unset) == 0 {
+		fmt.Fprintf(io.Out, "The secrets of %s already match %s\n", appName, flag.GetString(ctx, secretsFileFlag.Name))
+
+		return nil
+	}
+
+	printSecretsDiff(io.Out, io.ColorScheme(), diff, prune)
+
+	if len(unset) > 0 && !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Unset %d secrets of %s that aren't in the file?", len(unset), appName); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	app, err := flyutil.ClientFromContext(ctx).GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	if err := appsecrets.Update(ctx, flapsutil.ClientFromContext(ctx), app.Name, set, unset); err != nil {
+		return fmt.Errorf("update secrets: %w", err)
+	}
+
+	return DeploySecrets(ctx, app, DeploymentArgs{
+		Stage:    flag.GetBool(ctx, "stage"),
+		Detach:   flag.GetBool(ctx, "detach"),
+		CheckDNS: flag.GetBool(ctx, "dns-checks"),
+	})
+}
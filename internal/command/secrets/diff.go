@@ -0,0 +1,173 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/spf13/cobra"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/appsecrets"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+var secretsFileFlag = flag.String{
+	Name:        "file",
+	Shorthand:   "f",
+	Description: "File of NAME=VALUE pairs with the desired secrets, or - for stdin",
+}
+
+func newDiff() (cmd *cobra.Command) {
+	const (
+		long = `Compare the secrets of a file of NAME=VALUE pairs with the secrets set on the
+application. Values never leave this machine: they're compared with the digests
+of the app's secrets, as shown by 'fly secrets list'.
+
+Files encrypted with 'fly secrets encrypt' are decrypted, and with --resolve,
+references to secret managers like vault://path#key are resolved first.`
+		short = `Compare a file of secrets with the app's secrets`
+		usage = "diff -f FILE [flags]"
+	)
+
+	cmd = command.New(usage, short, long, runDiff, command.RequireSession, command.RequireAppName)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+		secretsFileFlag,
+		resolveFlag,
+		identityFlag,
+	)
+
+	return cmd
+}
+
+func runDiff(ctx context.Context) error {
+	diff, err := loadSecretsDiff(ctx)
+	if err != nil {
+		return err
+	}
+
+	io := iostreams.FromContext(ctx)
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, diff)
+	}
+
+	printSecretsDiff(io.Out, io.ColorScheme(), diff, false)
+
+	return nil
+}
+
+// secretsDiff lists the secrets that differ between a file and an app.
+type secretsDiff struct {
+	// Added secrets are in the file, but not set on the app.
+	Added []string `json:"added"`
+	// Changed secrets have a different value in the file than on the app.
+	Changed []string `json:"changed"`
+	// Removed secrets are set on the app, but not in the file.
+	Removed []string `json:"removed"`
+	// Unchanged secrets have the same value in the file and on the app.
+	Unchanged []string `json:"unchanged"`
+
+	local map[string]string
+}
+
+// loadSecretsDiff compares the secrets of the --file flag with the app's.
+func loadSecretsDiff(ctx context.Context) (*secretsDiff, error) {
+	path := flag.GetString(ctx, secretsFileFlag.Name)
+	if path == "" {
+		return nil, errors.New("a file of secrets must be specified with --file")
+	}
+
+	local, err := readSecretsFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	appName := appconfig.NameFromContext(ctx)
+	remote, err := appsecrets.List(ctx, flapsutil.ClientFromContext(ctx), appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing secrets of %s: %w", appName, err)
+	}
+
+	return diffSecrets(local, remote), nil
+}
+
+func diffSecrets(local map[string]string, remote []fly.AppSecret) *secretsDiff {
+	diff := &secretsDiff{local: local}
+
+	digests := make(map[string]string, len(remote))
+	for _, secret := range remote {
+		digests[secret.Name] = secret.Digest
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(local)) {
+		digest, ok := digests[name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, name)
+		case appsecrets.DigestMatches(local[name], digest):
+			diff.Unchanged = append(diff.Unchanged, name)
+		default:
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(digests)) {
+		if _, ok := local[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	return diff
+}
+
+// changes returns the secrets to set and, when pruning, unset to turn the
+// app's secrets into the file's.
+func (d *secretsDiff) changes(prune bool) (set map[string]string, unset []string) {
+	set = map[string]string{}
+	for _, name := range slices.Concat(d.Added, d.Changed) {
+		set[name] = d.local[name]
+	}
+
+	if prune {
+		unset = d.Removed
+	}
+
+	return set, unset
+}
+
+// printSecretsDiff prints the differences. Secrets that aren't in the file are
+// noted as kept unless prune is set.
+func printSecretsDiff(out io.Writer, colorize *iostreams.ColorScheme, d *secretsDiff, prune bool) {
+	printNames := func(color func(string) string, sign string, names []string, note string) {
+		for _, name := range names {
+			line := color(sign + " " + name)
+			if note != "" {
+				line += " " + colorize.Gray(note)
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+
+	printNames(colorize.Green, "+", d.Added, "")
+	printNames(colorize.Yellow, "~", d.Changed, "")
+	if prune {
+		printNames(colorize.Red, "-", d.Removed, "")
+	} else {
+		printNames(colorize.Gray, " ", d.Removed, "(not in the file, unset with --prune)")
+	}
+
+	fmt.Fprintf(out, "%d to add, %d to change, %d not in the file, %d unchanged\n",
+		len(d.Added), len(d.Changed), len(d.Removed), len(d.Unchanged))
+}
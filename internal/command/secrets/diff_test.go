@@ -0,0 +1,43 @@
+package secrets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	fly "github.com/superfly/fly-go"
+)
+
+func digestOf(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDiffSecrets(t *testing.T) {
+	local := map[string]string{
+		"NEW":       "new",
+		"CHANGED":   "after",
+		"UNCHANGED": "same",
+	}
+	remote := []fly.AppSecret{
+		{Name: "CHANGED", Digest: digestOf("before")[:16]},
+		{Name: "UNCHANGED", Digest: digestOf("same")[:16]},
+		{Name: "REMOTE_ONLY", Digest: digestOf("x")[:16]},
+	}
+
+	diff := diffSecrets(local, remote)
+	assert.Equal(t, []string{"NEW"}, diff.Added)
+	assert.Equal(t, []string{"CHANGED"}, diff.Changed)
+	assert.Equal(t, []string{"REMOTE_ONLY"}, diff.Removed)
+	assert.Equal(t, []string{"UNCHANGED"}, diff.Unchanged)
+
+	set, unset := diff.changes(false)
+	assert.Equal(t, map[string]string{"NEW": "new", "CHANGED": "after"}, set)
+	assert.Empty(t, unset)
+
+	set, unset = diff.changes(true)
+	assert.Equal(t, map[string]string{"NEW": "new", "CHANGED": "after"}, set)
+	assert.Equal(t, []string{"REMOTE_ONLY"}, unset)
+}
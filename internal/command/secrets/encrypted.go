@@ -0,0 +1,368 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/internal/appsecrets"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// identityFlag selects the age identity that decrypts encrypted files of secrets.
+var identityFlag = flag.String{
+	Name:        "identity",
+	Shorthand:   "i",
+	Description: "age identity file to decrypt secrets with, instead of $" + appsecrets.IdentityEnv + " or ~/.fly/secrets-identity.txt",
+}
+
+var recipientFlags = flag.Set{
+	flag.StringArray{
+		Name:        "recipient",
+		Shorthand:   "r",
+		Description: "Encrypt to an age recipient, like age1..., can be repeated",
+	},
+	flag.String{
+		Name:        "recipients-file",
+		Shorthand:   "R",
+		Description: "Encrypt to the age recipients listed in a file, one per line",
+	},
+}
+
+const encryptedLong = `Files of secrets are encrypted with age (https://age-encryption.org), so that
+they can be committed next to the app and decrypted with the age CLI too.
+
+They're encrypted to the recipients given with --recipient or --recipients-file,
+or else to those listed in a ` + appsecrets.RecipientsFile + ` file next to the encrypted file,
+or else to your own identity, which is created in ~/.fly/secrets-identity.txt
+the first time it's needed. They're decrypted with the identity of --identity,
+or of $` + appsecrets.IdentityEnv + `, or else with your own.`
+
+func newEncrypt() (cmd *cobra.Command) {
+	const (
+		short = `Encrypt a file of NAME=VALUE pairs`
+		long  = short + "\n\n" + encryptedLong
+		usage = "encrypt FILE [flags]"
+	)
+
+	cmd = command.New(usage, short, long, runEncrypt)
+
+	flag.Add(cmd,
+		recipientFlags,
+		identityFlag,
+		flag.String{
+			Name:        "output",
+			Shorthand:   "o",
+			Description: "File to write the encrypted secrets to, defaults to FILE.age",
+		},
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runEncrypt(ctx context.Context) error {
+	path := flag.FirstArg(ctx)
+
+	plaintext, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if appsecrets.IsEncrypted(plaintext) {
+		return fmt.Errorf("%s is already encrypted", path)
+	}
+	if _, err := appsecrets.Parse(bytes.NewReader(plaintext)); err != nil {
+		return fmt.Errorf("failed to parse secrets from %s: %w", path, err)
+	}
+
+	output := flag.GetString(ctx, "output")
+	if output == "" {
+		output = path + ".age"
+	}
+
+	recipients, err := secretsRecipients(ctx, output, false)
+	if err != nil {
+		return err
+	}
+
+	if err := encryptSecretsFile(output, plaintext, recipients); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(iostreams.FromContext(ctx).Out, "Encrypted %s to %s\n", path, output)
+
+	return nil
+}
+
+func newDecrypt() (cmd *cobra.Command) {
+	const (
+		short = `Decrypt an encrypted file of NAME=VALUE pairs`
+		long  = short + "\n\n" + encryptedLong
+		usage = "decrypt FILE [flags]"
+	)
+
+	cmd = command.New(usage, short, long, runDecrypt)
+
+	flag.Add(cmd,
+		identityFlag,
+		flag.String{
+			Name:        "output",
+			Shorthand:   "o",
+			Description: "File to write the decrypted secrets to, defaults to stdout",
+		},
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runDecrypt(ctx context.Context) error {
+	path := flag.FirstArg(ctx)
+
+	plaintext, err := decryptSecretsFile(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	if output := flag.GetString(ctx, "output"); output != "" {
+		return os.WriteFile(output, plaintext, 0o600)
+	}
+
+	_, err = iostreams.FromContext(ctx).Out.Write(plaintext)
+
+	return err
+}
+
+func newEdit() (cmd *cobra.Command) {
+	const (
+		short = `Edit an encrypted file of NAME=VALUE pairs`
+		long  = `Decrypt a file of secrets to a temporary file, open it in $EDITOR and encrypt
+it back once the editor exits. The file is created when it doesn't exist.
+
+Which recipients a file is encrypted to can't be read from it, so existing
+files are only encrypted back to the recipients of --recipient, --recipients-file
+or of a ` + appsecrets.RecipientsFile + ` file next to them, and edit refuses to run without them.
+
+` + encryptedLong
+		usage = "edit FILE [flags]"
+	)
+
+	cmd = command.New(usage, short, long, runEdit)
+
+	flag.Add(cmd,
+		recipientFlags,
+		identityFlag,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runEdit(ctx context.Context) error {
+	path := flag.FirstArg(ctx)
+
+	plaintext, err := decryptSecretsFile(ctx, path)
+	exists := !errors.Is(err, fs.ErrNotExist)
+	if err != nil && exists {
+		return err
+	}
+
+	// Find the recipients first, so that edits aren't lost when there are none.
+	recipients, err := secretsRecipients(ctx, path, exists)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "fly-secrets-*.env")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(plaintext)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := runEditor(ctx, tmp.Name()); err != nil {
+		return err
+	}
+
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, plaintext) {
+		fmt.Fprintf(iostreams.FromContext(ctx).Out, "%s is unchanged\n", path)
+
+		return nil
+	}
+	if _, err := appsecrets.Parse(bytes.NewReader(edited)); err != nil {
+		return fmt.Errorf("failed to parse the edited secrets, %s is unchanged: %w", path, err)
+	}
+
+	if err := encryptSecretsFile(path, edited, recipients); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(iostreams.FromContext(ctx).Out, "Encrypted the edited secrets to %s\n", path)
+
+	return nil
+}
+
+func runEditor(ctx context.Context, path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	args := strings.Fields(editor)
+	io := iostreams.FromContext(ctx)
+
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], path)...)
+	cmd.Stdin = io.In
+	cmd.Stdout = io.Out
+	cmd.Stderr = io.ErrOut
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed running %s: %w", editor, err)
+	}
+
+	return nil
+}
+
+// decryptSecretsFile decrypts the file at path with the identity of the
+// --identity flag.
+func decryptSecretsFile(ctx context.Context, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	plaintext, err := decryptSecrets(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return plaintext, nil
+}
+
+// decryptSecrets decrypts r with the identity of the --identity flag.
+func decryptSecrets(ctx context.Context, r io.Reader) ([]byte, error) {
+	ciphertext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !appsecrets.IsEncrypted(ciphertext) {
+		return nil, errors.New("not an age encrypted file")
+	}
+
+	identities, err := appsecrets.LoadIdentities(flag.GetString(ctx, identityFlag.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed loading identities to decrypt secrets: %w", err)
+	}
+
+	plaintext, err := appsecrets.Decrypt(ciphertext, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// encryptSecretsFile encrypts plaintext to recipients and writes it to path.
+func encryptSecretsFile(path string, plaintext []byte, recipients []age.Recipient) error {
+	ciphertext, err := appsecrets.Encrypt(plaintext, recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+
+	return os.WriteFile(path, ciphertext, 0o644)
+}
+
+// secretsRecipients returns the recipients to encrypt the file at target to:
+// those of the --recipient and --recipients-file flags, or else those of the
+// recipients file next to target, or else, unless target is already encrypted
+// to recipients that can't be known, the recipient of the user's own identity.
+func secretsRecipients(ctx context.Context, target string, existing bool) ([]age.Recipient, error) {
+	recipients, err := appsecrets.ParseRecipients(flag.GetStringArray(ctx, "recipient"))
+	if err != nil {
+		return nil, err
+	}
+
+	path := flag.GetString(ctx, "recipients-file")
+	if path == "" && len(recipients) == 0 {
+		nextToTarget := filepath.Join(filepath.Dir(target), appsecrets.RecipientsFile)
+		if _, err := os.Stat(nextToTarget); err == nil {
+			path = nextToTarget
+		}
+	}
+	if path != "" {
+		fromFile, err := appsecrets.LoadRecipients(path)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, fromFile...)
+	}
+
+	if len(recipients) > 0 {
+		return recipients, nil
+	}
+
+	if existing {
+		return nil, fmt.Errorf("the recipients %s is encrypted to can't be determined, give them with --recipient or --recipients-file, or list them in %s next to it", target, appsecrets.RecipientsFile)
+	}
+
+	identityPath := flag.GetString(ctx, identityFlag.Name)
+
+	identities, err := appsecrets.LoadIdentities(identityPath)
+	if errors.Is(err, fs.ErrNotExist) && identityPath == "" {
+		identity, path, err := appsecrets.GenerateIdentity()
+		if err != nil {
+			return nil, fmt.Errorf("failed generating an age identity: %w", err)
+		}
+
+		fmt.Fprintf(iostreams.FromContext(ctx).ErrOut, "Generated an age identity in %s, keep a copy: files encrypted to it can't be decrypted without it.\nIts recipient is %s\n", path, identity.Recipient())
+
+		return []age.Recipient{identity.Recipient()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed loading identities: %w", err)
+	}
+
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			recipients = append(recipients, x25519.Recipient())
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients to encrypt to, use --recipient or --recipients-file")
+	}
+
+	return recipients, nil
+}
@@ -0,0 +1,46 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appsecrets"
+	"github.com/superfly/flyctl/internal/flag/flagctx"
+)
+
+func TestSecretsRecipients(t *testing.T) {
+	flags := pflag.NewFlagSet("edit", pflag.ContinueOnError)
+	flags.StringArray("recipient", nil, "")
+	flags.String("recipients-file", "", "")
+	flags.String("identity", "", "")
+	ctx := flagctx.NewContext(context.Background(), flags)
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "secrets.env.age")
+
+	// The recipients of existing files aren't guessed.
+	_, err := secretsRecipients(ctx, target, true)
+	assert.ErrorContains(t, err, "can't be determined")
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, appsecrets.RecipientsFile), []byte(identity.Recipient().String()+"\n"), 0o644))
+
+	recipients, err := secretsRecipients(ctx, target, true)
+	require.NoError(t, err)
+	assert.Equal(t, []age.Recipient{identity.Recipient()}, recipients)
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	require.NoError(t, flags.Set("recipient", other.Recipient().String()))
+
+	recipients, err = secretsRecipients(ctx, target, true)
+	require.NoError(t, err)
+	assert.Equal(t, []age.Recipient{other.Recipient()}, recipients)
+}
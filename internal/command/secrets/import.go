@@ -1,13 +1,16 @@
 package secrets
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/appsecrets"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/flapsutil"
@@ -16,7 +19,21 @@ import (
 
 func newImport() (cmd *cobra.Command) {
 	const (
-		long  = `Set one or more encrypted secrets for an application. Values are read from stdin as NAME=VALUE pairs`
+		long = `Set one or more encrypted secrets for an application. Values are read from stdin as NAME=VALUE pairs.
+
+With --resolve, values may reference secrets kept in a secret manager, which
+are resolved locally before being set:
+
+  DB_PASSWORD=vault://secret/data/app#password   HashiCorp Vault (VAULT_ADDR, VAULT_TOKEN)
+  API_KEY=op://prod/api/credential               1Password, using the op CLI
+  STRIPE_KEY=aws-sm://prod/stripe#secret_key     AWS Secrets Manager
+  TLS_KEY=file://./certs/tls.key                 a local file, or file://config.json#key
+
+With --encrypted, stdin is a file encrypted with 'fly secrets encrypt', which is
+decrypted with the identity of --identity, of $FLY_SECRETS_AGE_KEY, or else of
+~/.fly/secrets-identity.txt:
+
+  fly secrets import --encrypted < secrets.env.age`
 		short = `Set secrets as NAME=VALUE pairs from stdin`
 		usage = "import [flags]"
 	)
@@ -25,6 +42,12 @@ func newImport() (cmd *cobra.Command) {
 
 	flag.Add(cmd,
 		sharedFlags,
+		resolveFlag,
+		identityFlag,
+		flag.Bool{
+			Name:        "encrypted",
+			Description: "Decrypt stdin, encrypted with 'fly secrets encrypt', before setting its secrets",
+		},
 	)
 
 	return cmd
@@ -41,7 +64,16 @@ func runImport(ctx context.Context) (err error) {
 
 	flapsClient := flapsutil.ClientFromContext(ctx)
 
-	secrets, err := parseSecrets(os.Stdin)
+	var stdin io.Reader = os.Stdin
+	if flag.GetBool(ctx, "encrypted") {
+		plaintext, err := decryptSecrets(ctx, os.Stdin)
+		if err != nil {
+			return err
+		}
+		stdin = bytes.NewReader(plaintext)
+	}
+
+	secrets, err := appsecrets.Parse(stdin)
 	if err != nil {
 		return fmt.Errorf("Failed to parse secrets from stdin: %w", err)
 	}
@@ -49,6 +81,10 @@ func runImport(ctx context.Context) (err error) {
 		return errors.New("requires at least one SECRET=VALUE pair")
 	}
 
+	if secrets, err = resolveSecrets(ctx, secrets); err != nil {
+		return err
+	}
+
 	return SetSecretsAndDeploy(ctx, flapsClient, app, secrets, DeploymentArgs{
 		Stage:    flag.GetBool(ctx, "stage"),
 		Detach:   flag.GetBool(ctx, "detach"),
@@ -1,78 +1,57 @@
 package secrets
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"strings"
-)
+	"os"
 
-const (
-	parserStateSingleline = iota
-	parserStateMultiline  = iota
+	"github.com/superfly/flyctl/internal/appsecrets"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/secretref"
 )
 
-func parseSecrets(reader io.Reader) (map[string]string, error) {
-	secrets := map[string]string{}
-	scanner := bufio.NewScanner(reader)
-	parserState := parserStateSingleline
-	parsedKey := ""
-	parsedVal := strings.Builder{}
+// resolveSecrets replaces the values of secrets that reference an external
+// secret manager with the values they reference, when --resolve is set.
+func resolveSecrets(ctx context.Context, secrets map[string]string) (map[string]string, error) {
+	if !flag.GetBool(ctx, resolveFlag.Name) {
+		return secrets, nil
+	}
+
+	resolved, err := secretref.Default().ResolveAll(ctx, secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secret references:\n%w", err)
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		switch parserState {
-		case parserStateSingleline:
-			// Skip comments and empty lines
-			if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
-				continue
-			}
+	return resolved, nil
+}
 
-			key, value, ok := strings.Cut(line, "=")
-			if !ok {
-				return nil, fmt.Errorf("Secrets must be provided as NAME=VALUE pairs (%s is invalid)", line)
-			}
-			key = strings.TrimSpace(key)
-			value = strings.TrimLeft(value, " ")
-			l, _, ok := strings.Cut(value, "#")
-			if ok && strings.Count(l, `"`)%2 == 0 {
-				value = strings.TrimRight(l, " ")
-			}
+// readSecretsFile parses the secrets of the file at path, or of stdin when
+// path is "-", and resolves their references with --resolve. Encrypted files are decrypted
+// with the identity of the --identity flag.
+func readSecretsFile(ctx context.Context, path string) (map[string]string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-			if strings.HasPrefix(value, `"""`) && strings.HasSuffix(value, `"""`) && len(value) >= 6 {
-				// Single-line triple-quoted string
-				value = value[3 : len(value)-3]
-				secrets[key] = value
-			} else if strings.HasPrefix(value, `"""`) {
-				// Switch to multiline
-				parserState = parserStateMultiline
-				parsedKey = key
-				parsedVal.WriteString(strings.TrimPrefix(value, `"""`))
-				parsedVal.WriteString("\n")
-			} else {
-				if strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
-					// Remove double quotes
-					value = value[1 : len(value)-1]
-				} else if strings.HasPrefix(value, `'`) && strings.HasSuffix(value, `'`) {
-					// Remove single quotes
-					value = value[1 : len(value)-1]
-				}
-				secrets[key] = value
-			}
-		case parserStateMultiline:
-			if before, ok := strings.CutSuffix(line, `"""`); ok {
-				// End of multiline
-				parsedVal.WriteString(before)
-				secrets[parsedKey] = parsedVal.String()
-				parsedVal.Reset()
-				parserState = parserStateSingleline
-				parsedKey = ""
-			} else {
-				parsedVal.WriteString(line + "\n")
-			}
+	if data, err = appsecrets.Plaintext(data, flag.GetString(ctx, identityFlag.Name)); err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets from %s: %w", path, err)
+	}
 
-		}
+	secrets, err := appsecrets.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secrets from %s: %w", path, err)
 	}
 
-	return secrets, nil
+	return resolveSecrets(ctx, secrets)
 }
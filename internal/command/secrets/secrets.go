@@ -30,6 +30,13 @@ var sharedFlags = flag.Set{
 	},
 }
 
+// resolveFlag opts into resolving references to external secret managers.
+// Values are set as-is otherwise, since a literal value may look like one.
+var resolveFlag = flag.Bool{
+	Name:        "resolve",
+	Description: "Resolve values that reference secret managers or local files, like vault://path#key, op://vault/item/field, aws-sm://name or file://path, and set the values they reference",
+}
+
 func New() *cobra.Command {
 	const (
 		long = `Secrets are provided to applications at runtime as ENV variables. Names are
@@ -48,6 +55,11 @@ func New() *cobra.Command {
 		newSync(),
 		newUnset(),
 		newImport(),
+		newDiff(),
+		newApply(),
+		newEncrypt(),
+		newDecrypt(),
+		newEdit(),
 		newDeploy(),
 		newKeys(),
 	)
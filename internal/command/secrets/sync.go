@@ -2,6 +2,7 @@ package secrets
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -10,12 +11,19 @@ import (
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyutil"
 )
 
 func newSync() (cmd *cobra.Command) {
 	const (
-		long  = `Sync flyctl with the latest versions of app secrets, even if they were set elsewhere`
-		short = long
+		long = `Sync flyctl with the latest versions of app secrets, even if they were set elsewhere.
+
+With --file, secrets are first set from a file of NAME=VALUE pairs. With
+--resolve, its values may reference secrets kept in a secret manager (vault://,
+op://, aws-sm:// or file://), which are resolved locally, so that the secret
+manager stays the source of truth and only the resolved values are sent to
+Fly.io.`
+		short = `Sync flyctl with the latest versions of app secrets, even if they were set elsewhere`
 		usage = "sync [flags]"
 	)
 
@@ -23,6 +31,13 @@ func newSync() (cmd *cobra.Command) {
 
 	flag.Add(cmd,
 		sharedFlags,
+		resolveFlag,
+		identityFlag,
+		flag.String{
+			Name:        "file",
+			Shorthand:   "f",
+			Description: "Set the secrets of a file of NAME=VALUE pairs",
+		},
 	)
 
 	return cmd
@@ -37,9 +52,36 @@ func runSync(ctx context.Context) (err error) {
 	appName := appconfig.NameFromContext(ctx)
 	flapsClient := flapsutil.ClientFromContext(ctx)
 
+	if path := flag.GetString(ctx, "file"); path != "" {
+		return syncFromFile(ctx, appName, path)
+	}
+
 	if err := appsecrets.Sync(ctx, flapsClient, appName); err != nil {
 		return fmt.Errorf("sync secrets: %w", err)
 	}
 
 	return nil
 }
+
+// syncFromFile sets the secrets of the file at path, which also brings the
+// app's minsecret version up to date.
+func syncFromFile(ctx context.Context, appName, path string) error {
+	secrets, err := readSecretsFile(ctx, path)
+	if err != nil {
+		return err
+	}
+	if len(secrets) < 1 {
+		return errors.New("requires at least one SECRET=VALUE pair")
+	}
+
+	app, err := flyutil.ClientFromContext(ctx).GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	return SetSecretsAndDeploy(ctx, flapsutil.ClientFromContext(ctx), app, secrets, DeploymentArgs{
+		Stage:    flag.GetBool(ctx, "stage"),
+		Detach:   flag.GetBool(ctx, "detach"),
+		CheckDNS: flag.GetBool(ctx, "dns-checks"),
+	})
+}
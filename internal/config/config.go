@@ -113,6 +113,23 @@ type Config struct {
 
 	// LastLogin denotes the timestamp of the last successful login.
 	LastLogin time.Time
+
+	// ScannerPlugins denotes the external scanners fly launch detects
+	// frameworks with, along with the built-in ones.
+	ScannerPlugins []ScannerPlugin
+}
+
+// ScannerPlugin is an external scanner configured in the configuration file.
+type ScannerPlugin struct {
+	// Name identifies the plugin in output and errors.
+	Name string `yaml:"name"`
+
+	// Path is the path of the executable or WASM module of the plugin.
+	Path string `yaml:"path"`
+
+	// Order denotes when the plugin runs relative to the built-in scanners:
+	// first, last, before:<scanner> or after:<scanner>.
+	Order string `yaml:"order"`
 }
 
 func Load(ctx context.Context, path string) (*Config, error) {
@@ -180,13 +197,14 @@ func (cfg *Config) applyFile(path string) (err error) {
 	defer cfg.mu.Unlock()
 
 	var w struct {
-		AccessToken            string    `yaml:"access_token"`
-		MetricsToken           string    `yaml:"metrics_token"`
-		SendMetrics            bool      `yaml:"send_metrics"`
-		AutoUpdate             bool      `yaml:"auto_update"`
-		SyntheticsAgent        bool      `yaml:"synthetics_agent"`
-		DisableManagedBuilders bool      `yaml:"disable_managed_builders"`
-		LastLogin              time.Time `yaml:"last_login"`
+		AccessToken            string          `yaml:"access_token"`
+		MetricsToken           string          `yaml:"metrics_token"`
+		SendMetrics            bool            `yaml:"send_metrics"`
+		AutoUpdate             bool            `yaml:"auto_update"`
+		SyntheticsAgent        bool            `yaml:"synthetics_agent"`
+		DisableManagedBuilders bool            `yaml:"disable_managed_builders"`
+		LastLogin              time.Time       `yaml:"last_login"`
+		ScannerPlugins         []ScannerPlugin `yaml:"scanner_plugins"`
 	}
 	w.SendMetrics = true
 	w.AutoUpdate = true
@@ -201,6 +219,7 @@ func (cfg *Config) applyFile(path string) (err error) {
 		cfg.SyntheticsAgent = w.SyntheticsAgent
 		cfg.DisableManagedBuilders = w.DisableManagedBuilders
 		cfg.LastLogin = w.LastLogin
+		cfg.ScannerPlugins = w.ScannerPlugins
 	}
 
 	return
@@ -19,6 +19,14 @@ func FromContext(ctx context.Context) *Config {
 	return ctx.Value(contextKey{}).(*Config)
 }
 
+// FromContextIfPresent returns the Config ctx carries, or nil in case ctx
+// carries no Config.
+func FromContextIfPresent(ctx context.Context) *Config {
+	cfg, _ := ctx.Value(contextKey{}).(*Config)
+
+	return cfg
+}
+
 func Tokens(ctx context.Context) *tokens.Tokens {
 	return FromContext(ctx).Tokens
 }
@@ -6,6 +6,7 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	fly "github.com/superfly/fly-go"
@@ -17,6 +18,7 @@ type ComposeService struct {
 	Image       string              `yaml:"image"`
 	Build       any                 `yaml:"build"`
 	Environment map[string]string   `yaml:"environment"`
+	EnvFile     any                 `yaml:"env_file"`
 	Volumes     []string            `yaml:"volumes"`
 	Ports       []string            `yaml:"ports"`
 	Command     any                 `yaml:"command"`
@@ -247,16 +249,14 @@ func composeToMachineConfig(mConfig *fly.MachineConfig, compose *ComposeFile, co
 	// Create containers for all services
 	containers := make([]*fly.ContainerConfig, 0, len(compose.Services))
 
-	// Check that only one service specifies build
-	buildServiceCount := 0
-	for _, service := range compose.Services {
-		if service.Build != nil {
-			buildServiceCount++
+	// Machines mount a single volume, which services share
+	var mountedVolume string
+	if volumes := composeVolumes(compose); len(volumes) > 0 {
+		mountedVolume = volumes[0].Name
+		for _, v := range volumes[1:] {
+			warnOnce("Machines can only mount one volume: volume '%s' is mounted, volume '%s' isn't", mountedVolume, v.Name)
 		}
 	}
-	if buildServiceCount > 1 {
-		return fmt.Errorf("only one service can specify build, found %d services with build", buildServiceCount)
-	}
 
 	// Process all services as containers
 	for serviceName, service := range compose.Services {
@@ -266,7 +266,8 @@ func composeToMachineConfig(mConfig *fly.MachineConfig, compose *ComposeFile, co
 
 		// Set image
 		if service.Build != nil {
-			// Service with build section uses "." as image
+			// Services with build section use "." as image, which deploy
+			// replaces with the image built for the service
 			container.Image = "."
 		} else if service.Image != "" {
 			container.Image = service.Image
@@ -275,10 +276,14 @@ func composeToMachineConfig(mConfig *fly.MachineConfig, compose *ComposeFile, co
 			return fmt.Errorf("service '%s' must specify either 'image' or 'build'", serviceName)
 		}
 
-		// Handle environment variables
-		if len(service.Environment) > 0 {
-			container.ExtraEnv = make(map[string]string)
-			maps.Copy(container.ExtraEnv, service.Environment)
+		// Handle environment variables, which override those of env_file
+		env, err := envFiles(composePath, serviceName, service.EnvFile)
+		if err != nil {
+			return err
+		}
+		maps.Copy(env, service.Environment)
+		if len(env) > 0 {
+			container.ExtraEnv = env
 		}
 
 		// Handle compose-specific entrypoint/command if specified
@@ -325,17 +330,27 @@ func composeToMachineConfig(mConfig *fly.MachineConfig, compose *ComposeFile, co
 		// Handle volume mounts
 		for _, vol := range service.Volumes {
 			hostPath, containerPath, _ := parseVolume(vol)
-			if hostPath != "" {
+			switch {
+			case hostPath == "":
+				warnOnce("anonymous volume %s of service '%s' isn't supported and is ignored", containerPath, serviceName)
+			case isNamedVolume(hostPath):
+				if name := flyVolumeName(hostPath); name == mountedVolume {
+					container.Mounts = append(container.Mounts, fly.ContainerMount{
+						Name: name,
+						Path: containerPath,
+					})
+				}
+			default:
 				// Make host path absolute if relative
 				if !filepath.IsAbs(hostPath) {
 					hostPath = filepath.Join(filepath.Dir(composePath), hostPath)
 				}
 
-				// Read the file content
+				// Read the file content; directories can't be bind mounted
 				content, err := os.ReadFile(hostPath)
 				if err != nil {
 					// Log warning but continue
-					fmt.Printf("Warning: Could not read volume file %s: %v\n", hostPath, err)
+					warnOnce("could not read volume file %s of service '%s', which is ignored: %v", hostPath, serviceName, err)
 
 					continue
 				}
@@ -350,8 +365,27 @@ func composeToMachineConfig(mConfig *fly.MachineConfig, compose *ComposeFile, co
 			}
 		}
 
+		secrets, err := secretFiles(compose, serviceName, service)
+		if err != nil {
+			return err
+		}
+		configs, err := configFiles(compose, composePath, serviceName, service)
+		if err != nil {
+			return err
+		}
+		files = append(files, configs...)
+		files = append(files, secrets...)
+
 		container.Files = files
 
+		for _, key := range slices.Sorted(maps.Keys(service.Extra)) {
+			// Extensions are ignored by compose as well
+			if strings.HasPrefix(key, "x-") {
+				continue
+			}
+			warnOnce("%s of service '%s' isn't supported and is ignored", key, serviceName)
+		}
+
 		// Handle health checks
 		if service.Healthcheck != nil {
 			healthcheck := convertHealthcheck(service.Healthcheck)
@@ -364,6 +398,11 @@ func composeToMachineConfig(mConfig *fly.MachineConfig, compose *ComposeFile, co
 		if deps, exists := serviceDependencies[serviceName]; exists && len(deps.Dependencies) > 0 {
 			var containerDeps []fly.ContainerDependency
 			for depName, dep := range deps.Dependencies {
+				// Services of other process groups are deployed before
+				if _, ok := compose.Services[depName]; !ok {
+					continue
+				}
+
 				var condition fly.ContainerDependencyCondition
 				switch dep.Condition {
 				case DependencyConditionStarted:
@@ -0,0 +1,504 @@
+package containerconfig
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// ComposeBuild is the build section of a compose service, with its paths made
+// absolute.
+type ComposeBuild struct {
+	Service    string
+	Context    string
+	Dockerfile string
+	Target     string
+	Args       map[string]string
+}
+
+// ComposeVolume is a named volume of a compose file mounted by its services.
+type ComposeVolume struct {
+	// Name is the name of the Fly volume the compose volume maps to.
+	Name string
+	// Path is where the first service mounting the volume mounts it.
+	Path string
+}
+
+// composeSecret is a secret or config of a compose file, as top-level
+// secrets and configs are defined alike.
+type composeSecret struct {
+	File        string `yaml:"file"`
+	Environment string `yaml:"environment"`
+	Content     string `yaml:"content"`
+	External    bool   `yaml:"external"`
+	Name        string `yaml:"name"`
+}
+
+// composeReference is a reference of a service to a secret or config.
+type composeReference struct {
+	Source string
+	Target string
+}
+
+var warned sync.Map
+
+// warnOnce warns about parts of compose files that aren't supported. Compose
+// files are parsed for every process group, so every warning is only shown
+// once.
+func warnOnce(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if _, loaded := warned.LoadOrStore(msg, true); !loaded {
+		terminal.Warnf("%s\n", msg)
+	}
+}
+
+// ComposeBuilds returns the build sections of the services of the compose file
+// at composePath, ordered by service name.
+func ComposeBuilds(composePath string) ([]ComposeBuild, error) {
+	compose, err := parseComposeFile(composePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var builds []ComposeBuild
+	for _, name := range slices.Sorted(maps.Keys(compose.Services)) {
+		service := compose.Services[name]
+		if service.Build == nil {
+			continue
+		}
+
+		build, err := parseBuild(name, service.Build, filepath.Dir(composePath))
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, build)
+	}
+
+	return builds, nil
+}
+
+// parseBuild parses the short (context only) and long syntaxes of the build
+// section of service.
+func parseBuild(service string, build any, dir string) (ComposeBuild, error) {
+	b := ComposeBuild{Service: service, Context: "."}
+
+	switch v := build.(type) {
+	case string:
+		b.Context = v
+	case map[string]any:
+		for key, value := range v {
+			switch key {
+			case "context":
+				b.Context = fmt.Sprint(value)
+			case "dockerfile":
+				b.Dockerfile = fmt.Sprint(value)
+			case "target":
+				b.Target = fmt.Sprint(value)
+			case "args":
+				args, err := parseKeyValues(value)
+				if err != nil {
+					return b, fmt.Errorf("invalid build args of service '%s': %w", service, err)
+				}
+				b.Args = args
+			default:
+				warnOnce("build.%s of service '%s' isn't supported and is ignored", key, service)
+			}
+		}
+	default:
+		return b, fmt.Errorf("invalid build section of service '%s'", service)
+	}
+
+	if !filepath.IsAbs(b.Context) {
+		b.Context = filepath.Join(dir, b.Context)
+	}
+	if b.Dockerfile != "" && !filepath.IsAbs(b.Dockerfile) {
+		b.Dockerfile = filepath.Join(b.Context, b.Dockerfile)
+	}
+
+	return b, nil
+}
+
+// parseKeyValues parses the mapping and list ("KEY=VALUE") syntaxes of
+// build args and environment variables.
+func parseKeyValues(value any) (map[string]string, error) {
+	values := map[string]string{}
+
+	switch v := value.(type) {
+	case nil:
+	case map[string]any:
+		for k, val := range v {
+			if val != nil {
+				values[k] = fmt.Sprint(val)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			k, val, ok := strings.Cut(fmt.Sprint(item), "=")
+			if !ok {
+				continue
+			}
+			values[k] = val
+		}
+	default:
+		return nil, fmt.Errorf("expected a mapping or a list")
+	}
+
+	return values, nil
+}
+
+// isNamedVolume reports whether the source of a volume of a service is a
+// named volume rather than a path on the host.
+func isNamedVolume(source string) bool {
+	return source != "" && !strings.HasPrefix(source, ".") && !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, "~")
+}
+
+// flyVolumeName returns the name of the Fly volume a compose volume maps to.
+// Fly volume names only have lowercase letters, digits and underscores.
+func flyVolumeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '_'
+		}
+	}, name)
+
+	if len(name) > 30 {
+		name = name[:30]
+	}
+
+	return name
+}
+
+// ComposeVolumes returns the named volumes the services of the compose file at
+// composePath mount, ordered by name. Machines only mount a single volume.
+func ComposeVolumes(composePath string) ([]ComposeVolume, error) {
+	compose, err := parseComposeFile(composePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return composeVolumes(compose), nil
+}
+
+func composeVolumes(compose *ComposeFile) []ComposeVolume {
+	paths := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(compose.Services)) {
+		for _, vol := range compose.Services[name].Volumes {
+			source, containerPath, _ := parseVolume(vol)
+			if !isNamedVolume(source) {
+				continue
+			}
+			if _, ok := paths[flyVolumeName(source)]; !ok {
+				paths[flyVolumeName(source)] = containerPath
+			}
+		}
+	}
+
+	var volumes []ComposeVolume
+	for _, name := range slices.Sorted(maps.Keys(paths)) {
+		volumes = append(volumes, ComposeVolume{Name: name, Path: paths[name]})
+	}
+
+	return volumes
+}
+
+// ComposeSecretName returns the name of the Fly secret holding the compose
+// secret name, which is the name of external secrets.
+func ComposeSecretName(name string, secret composeSecret) string {
+	if secret.External && secret.Name != "" {
+		return secret.Name
+	}
+
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+
+		return r
+	}, name))
+}
+
+// ComposeSecrets returns the values of the secrets of the compose file at
+// composePath, by the name of the Fly secrets they map to. Services read
+// secrets from files, so the values are base64 encoded as Fly file secrets
+// are. External secrets have to be set on the app already.
+func ComposeSecrets(composePath string) (map[string]string, error) {
+	compose, err := parseComposeFile(composePath)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := decodeSecrets(compose.Secrets, "secret")
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for name, secret := range secrets {
+		var content []byte
+		switch {
+		case secret.External:
+			continue
+		case secret.File != "":
+			file := secret.File
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(composePath), file)
+			}
+			if content, err = os.ReadFile(file); err != nil {
+				return nil, fmt.Errorf("failed to read secret '%s': %w", name, err)
+			}
+		case secret.Environment != "":
+			value, ok := os.LookupEnv(secret.Environment)
+			if !ok {
+				return nil, fmt.Errorf("secret '%s' is read from %s, which isn't set", name, secret.Environment)
+			}
+			content = []byte(value)
+		default:
+			return nil, fmt.Errorf("secret '%s' must have a file, an environment variable or be external", name)
+		}
+
+		values[ComposeSecretName(name, secret)] = base64.StdEncoding.EncodeToString(content)
+	}
+
+	return values, nil
+}
+
+// decodeSecrets decodes the top-level secrets or configs of a compose file.
+func decodeSecrets(defs map[string]any, kind string) (map[string]composeSecret, error) {
+	secrets := make(map[string]composeSecret, len(defs))
+
+	for name, def := range defs {
+		m, ok := def.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s '%s'", kind, name)
+		}
+
+		var secret composeSecret
+		for key, value := range m {
+			switch key {
+			case "file":
+				secret.File = fmt.Sprint(value)
+			case "environment":
+				secret.Environment = fmt.Sprint(value)
+			case "content":
+				secret.Content = fmt.Sprint(value)
+			case "external":
+				secret.External, _ = value.(bool)
+			case "name":
+				secret.Name = fmt.Sprint(value)
+			default:
+				warnOnce("%s.%s of %s '%s' isn't supported and is ignored", kind, key, kind, name)
+			}
+		}
+		secrets[name] = secret
+	}
+
+	return secrets, nil
+}
+
+// parseReferences parses the short (name only) and long syntaxes of the
+// secrets or configs of a service.
+func parseReferences(refs []any) []composeReference {
+	var parsed []composeReference
+
+	for _, ref := range refs {
+		switch v := ref.(type) {
+		case string:
+			parsed = append(parsed, composeReference{Source: v})
+		case map[string]any:
+			r := composeReference{}
+			if source, ok := v["source"].(string); ok {
+				r.Source = source
+			}
+			if target, ok := v["target"].(string); ok {
+				r.Target = target
+			}
+			if r.Source != "" {
+				parsed = append(parsed, r)
+			}
+		}
+	}
+
+	return parsed
+}
+
+// secretFiles returns the files of the secrets service refers to, which
+// compose mounts in /run/secrets unless told otherwise.
+func secretFiles(compose *ComposeFile, serviceName string, service ComposeService) ([]*fly.File, error) {
+	secrets, err := decodeSecrets(compose.Secrets, "secret")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*fly.File
+	for _, ref := range parseReferences(service.Secrets) {
+		secret, ok := secrets[ref.Source]
+		if !ok {
+			return nil, fmt.Errorf("service '%s' refers to undefined secret '%s'", serviceName, ref.Source)
+		}
+
+		guestPath := ref.Target
+		if guestPath == "" {
+			guestPath = ref.Source
+		}
+		if !path.IsAbs(guestPath) {
+			guestPath = path.Join("/run/secrets", guestPath)
+		}
+
+		secretName := ComposeSecretName(ref.Source, secret)
+		files = append(files, &fly.File{
+			GuestPath:  guestPath,
+			SecretName: &secretName,
+		})
+	}
+
+	return files, nil
+}
+
+// configFiles returns the files of the configs service refers to, which
+// compose mounts at the root of the file system unless told otherwise.
+func configFiles(compose *ComposeFile, composePath, serviceName string, service ComposeService) ([]*fly.File, error) {
+	configs, err := decodeSecrets(compose.Configs, "config")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*fly.File
+	for _, ref := range parseReferences(service.Configs) {
+		config, ok := configs[ref.Source]
+		if !ok {
+			return nil, fmt.Errorf("service '%s' refers to undefined config '%s'", serviceName, ref.Source)
+		}
+
+		var content []byte
+		switch {
+		case config.File != "":
+			file := config.File
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(composePath), file)
+			}
+			if content, err = os.ReadFile(file); err != nil {
+				return nil, fmt.Errorf("failed to read config '%s': %w", ref.Source, err)
+			}
+		case config.Content != "":
+			content = []byte(config.Content)
+		case config.Environment != "":
+			content = []byte(os.Getenv(config.Environment))
+		default:
+			warnOnce("config '%s' of service '%s' has no file or content and is ignored", ref.Source, serviceName)
+
+			continue
+		}
+
+		guestPath := ref.Target
+		if guestPath == "" {
+			guestPath = "/" + ref.Source
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(content)
+		files = append(files, &fly.File{
+			GuestPath: guestPath,
+			RawValue:  &encoded,
+		})
+	}
+
+	return files, nil
+}
+
+// envFiles returns the variables of the env_file of service, which is a path,
+// or a list of paths or of {path, required}.
+func envFiles(composePath, serviceName string, envFile any) (map[string]string, error) {
+	type entry struct {
+		path     string
+		required bool
+	}
+
+	var entries []entry
+	switch v := envFile.(type) {
+	case nil:
+	case string:
+		entries = append(entries, entry{v, true})
+	case []any:
+		for _, item := range v {
+			switch f := item.(type) {
+			case string:
+				entries = append(entries, entry{f, true})
+			case map[string]any:
+				e := entry{path: fmt.Sprint(f["path"]), required: true}
+				if required, ok := f["required"].(bool); ok {
+					e.required = required
+				}
+				entries = append(entries, e)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid env_file of service '%s'", serviceName)
+	}
+
+	env := map[string]string{}
+	for _, e := range entries {
+		p := e.path
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(filepath.Dir(composePath), p)
+		}
+
+		vars, err := readEnvFile(p)
+		switch {
+		case os.IsNotExist(err) && !e.required:
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("failed to read env_file of service '%s': %w", serviceName, err)
+		}
+
+		// Later files override earlier ones.
+		maps.Copy(env, vars)
+	}
+
+	return env, nil
+}
+
+// readEnvFile reads the KEY=VALUE lines of an env file, skipping comments and
+// blank lines.
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // skipcq: GO-S2307
+
+	env := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: %q isn't a KEY=VALUE pair", path, line)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[strings.TrimSpace(key)] = value
+	}
+
+	return env, scanner.Err()
+}
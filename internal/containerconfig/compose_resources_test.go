@@ -0,0 +1,278 @@
+package containerconfig
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fly "github.com/superfly/fly-go"
+)
+
+func writeComposeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	return filepath.Join(tmpDir, "compose.yml")
+}
+
+func TestComposeEnvFile(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{
+		"compose.yml": `services:
+  app:
+    image: nginx
+    env_file:
+      - .env
+      - path: missing.env
+        required: false
+    environment:
+      OVERRIDDEN: from-environment
+`,
+		".env": `# comment
+GREETING="hello world"
+OVERRIDDEN=from-env-file
+`,
+	})
+
+	mConfig := &fly.MachineConfig{}
+	if err := ParseComposeFileWithPath(mConfig, composePath); err != nil {
+		t.Fatalf("Failed to parse compose file: %v", err)
+	}
+
+	env := mConfig.Containers[0].ExtraEnv
+	if env["GREETING"] != "hello world" {
+		t.Errorf("Expected GREETING='hello world', got '%s'", env["GREETING"])
+	}
+	if env["OVERRIDDEN"] != "from-environment" {
+		t.Errorf("Expected environment to override env_file, got '%s'", env["OVERRIDDEN"])
+	}
+}
+
+func TestComposeEnvFileRequired(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{
+		"compose.yml": `services:
+  app:
+    image: nginx
+    env_file: missing.env
+`,
+	})
+
+	mConfig := &fly.MachineConfig{}
+	if err := ParseComposeFileWithPath(mConfig, composePath); err == nil {
+		t.Fatal("Expected error for missing env_file, got nil")
+	}
+}
+
+func TestComposeSecretsAndConfigs(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "key")
+
+	composePath := writeComposeFiles(t, map[string]string{
+		"compose.yml": `services:
+  app:
+    image: nginx
+    secrets:
+      - db-password
+      - source: api_key
+        target: api.key
+      - external_token
+    configs:
+      - nginx_conf
+      - source: inline
+        target: /etc/inline.txt
+secrets:
+  db-password:
+    file: ./db_password.txt
+  api_key:
+    environment: TEST_API_KEY
+  external_token:
+    external: true
+    name: TOKEN
+configs:
+  nginx_conf:
+    file: ./nginx.conf
+  inline:
+    content: inline content
+`,
+		"db_password.txt": "password",
+		"nginx.conf":      "server {}",
+	})
+
+	mConfig := &fly.MachineConfig{}
+	if err := ParseComposeFileWithPath(mConfig, composePath); err != nil {
+		t.Fatalf("Failed to parse compose file: %v", err)
+	}
+
+	files := map[string]*fly.File{}
+	for _, f := range mConfig.Containers[0].Files {
+		files[f.GuestPath] = f
+	}
+
+	secretNames := map[string]string{
+		"/run/secrets/db-password":    "DB_PASSWORD",
+		"/run/secrets/api.key":        "API_KEY",
+		"/run/secrets/external_token": "TOKEN",
+	}
+	for guestPath, name := range secretNames {
+		f, ok := files[guestPath]
+		if !ok {
+			t.Errorf("Expected file at %s", guestPath)
+
+			continue
+		}
+		if f.SecretName == nil || *f.SecretName != name {
+			t.Errorf("Expected %s to hold secret %s", guestPath, name)
+		}
+	}
+
+	configContents := map[string]string{
+		"/nginx_conf":     "server {}",
+		"/etc/inline.txt": "inline content",
+	}
+	for guestPath, content := range configContents {
+		f, ok := files[guestPath]
+		if !ok {
+			t.Errorf("Expected file at %s", guestPath)
+
+			continue
+		}
+		if f.RawValue == nil || *f.RawValue != base64.StdEncoding.EncodeToString([]byte(content)) {
+			t.Errorf("Expected %s to hold %q", guestPath, content)
+		}
+	}
+
+	secrets, err := ComposeSecrets(composePath)
+	if err != nil {
+		t.Fatalf("Failed to read compose secrets: %v", err)
+	}
+	expected := map[string]string{
+		"DB_PASSWORD": base64.StdEncoding.EncodeToString([]byte("password")),
+		"API_KEY":     base64.StdEncoding.EncodeToString([]byte("key")),
+	}
+	if len(secrets) != len(expected) {
+		t.Errorf("Expected secrets %v, got %v", expected, secrets)
+	}
+	for name, value := range expected {
+		if secrets[name] != value {
+			t.Errorf("Expected secret %s to be %q, got %q", name, value, secrets[name])
+		}
+	}
+}
+
+func TestComposeUndefinedSecret(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{
+		"compose.yml": `services:
+  app:
+    image: nginx
+    secrets:
+      - missing
+`,
+	})
+
+	mConfig := &fly.MachineConfig{}
+	if err := ParseComposeFileWithPath(mConfig, composePath); err == nil {
+		t.Fatal("Expected error for undefined secret, got nil")
+	}
+}
+
+func TestComposeNamedVolumes(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{
+		"compose.yml": `services:
+  db:
+    image: postgres
+    volumes:
+      - pg-data:/var/lib/postgresql/data
+  worker:
+    image: busybox
+    volumes:
+      - pg-data:/data:ro
+      - Zcache:/cache
+volumes:
+  pg-data:
+  Zcache:
+`,
+	})
+
+	volumes, err := ComposeVolumes(composePath)
+	if err != nil {
+		t.Fatalf("Failed to read compose volumes: %v", err)
+	}
+	expected := []ComposeVolume{
+		{Name: "pg_data", Path: "/var/lib/postgresql/data"},
+		{Name: "zcache", Path: "/cache"},
+	}
+	if len(volumes) != len(expected) {
+		t.Fatalf("Expected volumes %v, got %v", expected, volumes)
+	}
+	for i := range expected {
+		if volumes[i] != expected[i] {
+			t.Errorf("Expected volume %v, got %v", expected[i], volumes[i])
+		}
+	}
+
+	mConfig := &fly.MachineConfig{}
+	if err := ParseComposeFileWithPath(mConfig, composePath); err != nil {
+		t.Fatalf("Failed to parse compose file: %v", err)
+	}
+
+	// Only the first volume is mounted, by every service using it
+	mounts := map[string][]fly.ContainerMount{}
+	for _, container := range mConfig.Containers {
+		mounts[container.Name] = container.Mounts
+	}
+	if len(mounts["db"]) != 1 || mounts["db"][0].Name != "pg_data" || mounts["db"][0].Path != "/var/lib/postgresql/data" {
+		t.Errorf("Expected db to mount pg_data, got %v", mounts["db"])
+	}
+	if len(mounts["worker"]) != 1 || mounts["worker"][0].Name != "pg_data" || mounts["worker"][0].Path != "/data" {
+		t.Errorf("Expected worker to mount pg_data only, got %v", mounts["worker"])
+	}
+}
+
+func TestComposeBuilds(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{
+		"compose.yml": `services:
+  web:
+    build: .
+  api:
+    build:
+      context: ./api
+      dockerfile: Dockerfile.prod
+      target: release
+      args:
+        - VERSION=1.0
+  db:
+    image: postgres
+`,
+	})
+	dir := filepath.Dir(composePath)
+
+	builds, err := ComposeBuilds(composePath)
+	if err != nil {
+		t.Fatalf("Failed to read compose builds: %v", err)
+	}
+	if len(builds) != 2 {
+		t.Fatalf("Expected 2 builds, got %d", len(builds))
+	}
+
+	api := builds[0]
+	if api.Service != "api" || api.Context != filepath.Join(dir, "api") {
+		t.Errorf("Expected api to be built from %s, got %+v", filepath.Join(dir, "api"), api)
+	}
+	if api.Dockerfile != filepath.Join(dir, "api", "Dockerfile.prod") || api.Target != "release" || api.Args["VERSION"] != "1.0" {
+		t.Errorf("Unexpected api build %+v", api)
+	}
+
+	web := builds[1]
+	if web.Service != "web" || web.Context != dir || web.Dockerfile != "" {
+		t.Errorf("Expected web to be built from %s, got %+v", dir, web)
+	}
+}
@@ -0,0 +1,275 @@
+package containerconfig
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	fly "github.com/superfly/fly-go"
+)
+
+// ProcessGroupExtension is the compose extension of a service naming the
+// process group it runs in when the compose file is split. Services without
+// it run in the process group named after them.
+const ProcessGroupExtension = "x-fly-process-group"
+
+// ComposeGroups returns the services of the compose file at composePath by the
+// process group they run in when the compose file is split.
+func ComposeGroups(composePath string) (map[string][]string, error) {
+	compose, err := parseComposeFile(composePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return composeGroups(compose), nil
+}
+
+func composeGroups(compose *ComposeFile) map[string][]string {
+	groups := map[string][]string{}
+	for _, name := range slices.Sorted(maps.Keys(compose.Services)) {
+		group := processGroupName(name)
+		if g, ok := compose.Services[name].Extra[ProcessGroupExtension].(string); ok && g != "" {
+			group = processGroupName(g)
+		}
+		groups[group] = append(groups[group], name)
+	}
+
+	return groups
+}
+
+// processGroupName returns name as a process group name, which only has
+// lowercase letters, digits and dashes.
+func processGroupName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, name)
+}
+
+// ComposeGroup describes a process group of a split compose file.
+type ComposeGroup struct {
+	Name     string
+	Services []string
+	// Ports are the ports the services of the group publish.
+	Ports []ComposePort
+	// Volume is the named volume the group mounts, if any.
+	Volume *ComposeVolume
+	// Memory is the largest memory limit of the services of the group.
+	Memory string
+	// Replicas is the largest number of replicas of the services of the group.
+	Replicas int
+}
+
+// ComposePort is a port published by a service.
+type ComposePort struct {
+	Published int
+	Target    int
+	Protocol  string
+}
+
+// ComposeGroupPlans returns the process groups of the compose file at
+// composePath when it's split, ordered by name.
+func ComposeGroupPlans(composePath string) ([]ComposeGroup, error) {
+	compose, err := parseComposeFile(composePath)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := composeGroups(compose)
+
+	var plans []ComposeGroup
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		group := ComposeGroup{Name: name, Services: groups[name], Replicas: 1}
+
+		services := make(map[string]ComposeService, len(group.Services))
+		for _, service := range group.Services {
+			services[service] = compose.Services[service]
+
+			ports, err := parsePorts(compose.Services[service].Ports)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ports of service '%s': %w", service, err)
+			}
+			group.Ports = append(group.Ports, ports...)
+
+			limits := deployLimits(compose.Services[service].Deploy)
+			if memory, ok := limits["memory"].(string); ok && largerMemory(memory, group.Memory) {
+				group.Memory = memory
+			}
+			if replicas, ok := compose.Services[service].Deploy["replicas"].(int); ok && replicas > group.Replicas {
+				group.Replicas = replicas
+			}
+		}
+
+		if volumes := composeVolumes(&ComposeFile{Services: services}); len(volumes) > 0 {
+			group.Volume = &volumes[0]
+		}
+
+		plans = append(plans, group)
+	}
+
+	return plans, nil
+}
+
+// deployLimits returns the resource limits of the deploy section of a service.
+func deployLimits(deploy map[string]any) map[string]any {
+	resources, _ := deploy["resources"].(map[string]any)
+	limits, _ := resources["limits"].(map[string]any)
+
+	return limits
+}
+
+// largerMemory reports whether the memory size a is larger than b, which is
+// unset when empty.
+func largerMemory(a, b string) bool {
+	sizeA, err := units.RAMInBytes(a)
+	if err != nil {
+		return false
+	}
+	if b == "" {
+		return true
+	}
+	sizeB, _ := units.RAMInBytes(b)
+
+	return sizeA > sizeB
+}
+
+// parsePorts parses the short syntax of the ports of a service:
+// [[IP:]PUBLISHED:]TARGET[/PROTOCOL]. Ports that aren't published on a given
+// port are published on their target port.
+func parsePorts(ports []string) ([]ComposePort, error) {
+	var parsed []ComposePort
+	for _, p := range ports {
+		spec, protocol, ok := strings.Cut(p, "/")
+		if !ok {
+			protocol = "tcp"
+		}
+
+		parts := strings.Split(spec, ":")
+		target, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			return nil, fmt.Errorf("%q: port ranges aren't supported", p)
+		}
+
+		published := target
+		if len(parts) > 1 {
+			if published, err = strconv.Atoi(parts[len(parts)-2]); err != nil {
+				return nil, fmt.Errorf("%q: port ranges aren't supported", p)
+			}
+		}
+
+		parsed = append(parsed, ComposePort{Published: published, Target: target, Protocol: protocol})
+	}
+
+	return parsed, nil
+}
+
+// ComposeDeployWaves returns the process groups of the split compose file at
+// composePath in the order they're deployed in: the groups of a wave only
+// depend on the groups of the waves before it.
+func ComposeDeployWaves(composePath string) ([][]string, error) {
+	compose, err := parseComposeFile(composePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return composeDeployWaves(compose)
+}
+
+func composeDeployWaves(compose *ComposeFile) ([][]string, error) {
+	groups := composeGroups(compose)
+
+	groupOf := map[string]string{}
+	for group, services := range groups {
+		for _, service := range services {
+			groupOf[service] = group
+		}
+	}
+
+	dependsOn := map[string]map[string]bool{}
+	for group, services := range groups {
+		dependsOn[group] = map[string]bool{}
+		for _, service := range services {
+			deps, err := parseDependsOn(compose.Services[service].DependsOn)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse dependencies for service '%s': %w", service, err)
+			}
+
+			for dep := range deps.Dependencies {
+				if depGroup, ok := groupOf[dep]; ok && depGroup != group {
+					dependsOn[group][depGroup] = true
+				}
+			}
+		}
+	}
+
+	var (
+		waves    [][]string
+		deployed = map[string]bool{}
+	)
+	for len(deployed) < len(groups) {
+		var wave []string
+		for _, group := range slices.Sorted(maps.Keys(groups)) {
+			if deployed[group] {
+				continue
+			}
+
+			ready := true
+			for dep := range dependsOn[group] {
+				ready = ready && deployed[dep]
+			}
+			if ready {
+				wave = append(wave, group)
+			}
+		}
+
+		if len(wave) == 0 {
+			var cycle []string
+			for _, group := range slices.Sorted(maps.Keys(groups)) {
+				if !deployed[group] {
+					cycle = append(cycle, group)
+				}
+			}
+
+			return nil, fmt.Errorf("process groups %s depend on each other", strings.Join(cycle, ", "))
+		}
+
+		for _, group := range wave {
+			deployed[group] = true
+		}
+		waves = append(waves, wave)
+	}
+
+	return waves, nil
+}
+
+// ParseComposeFileForGroup parses the services of the compose file at
+// composePath running in the process group named group into mConfig, as
+// ParseComposeFileWithPath parses all of them.
+func ParseComposeFileForGroup(mConfig *fly.MachineConfig, composePath, group string) error {
+	compose, err := parseComposeFile(composePath)
+	if err != nil {
+		return err
+	}
+
+	services, ok := composeGroups(compose)[group]
+	if !ok {
+		return fmt.Errorf("no services of %s run in process group '%s'", composePath, group)
+	}
+
+	all := compose.Services
+	compose.Services = make(map[string]ComposeService, len(services))
+	for _, name := range services {
+		compose.Services[name] = all[name]
+	}
+
+	return composeToMachineConfig(mConfig, compose, composePath)
+}
@@ -0,0 +1,133 @@
+package containerconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	fly "github.com/superfly/fly-go"
+)
+
+const splitCompose = `services:
+  web:
+    image: nginx
+    ports:
+      - "80:8080"
+    depends_on:
+      - api
+  api:
+    image: api
+    x-fly-process-group: backend
+    depends_on:
+      db:
+        condition: service_healthy
+      worker:
+        condition: service_started
+    deploy:
+      replicas: 3
+      resources:
+        limits:
+          memory: 512M
+  worker:
+    image: worker
+    x-fly-process-group: backend
+    deploy:
+      resources:
+        limits:
+          memory: 1G
+  db:
+    image: postgres
+    ports:
+      - "5432"
+    volumes:
+      - pg_data:/var/lib/postgresql/data
+volumes:
+  pg_data:
+`
+
+func TestComposeGroupPlans(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{"compose.yml": splitCompose})
+
+	groups, err := ComposeGroupPlans(composePath)
+	if err != nil {
+		t.Fatalf("Failed to plan process groups: %v", err)
+	}
+
+	expected := []ComposeGroup{
+		{Name: "backend", Services: []string{"api", "worker"}, Memory: "1G", Replicas: 3},
+		{
+			Name:     "db",
+			Services: []string{"db"},
+			Ports:    []ComposePort{{Published: 5432, Target: 5432, Protocol: "tcp"}},
+			Volume:   &ComposeVolume{Name: "pg_data", Path: "/var/lib/postgresql/data"},
+			Replicas: 1,
+		},
+		{
+			Name:     "web",
+			Services: []string{"web"},
+			Ports:    []ComposePort{{Published: 80, Target: 8080, Protocol: "tcp"}},
+			Replicas: 1,
+		},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected process groups %+v, got %+v", expected, groups)
+	}
+}
+
+func TestComposeDeployWaves(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{"compose.yml": splitCompose})
+
+	waves, err := ComposeDeployWaves(composePath)
+	if err != nil {
+		t.Fatalf("Failed to order process groups: %v", err)
+	}
+
+	expected := [][]string{{"db"}, {"backend"}, {"web"}}
+	if !reflect.DeepEqual(waves, expected) {
+		t.Errorf("Expected waves %v, got %v", expected, waves)
+	}
+}
+
+func TestComposeDeployWavesCycle(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{"compose.yml": `services:
+  a:
+    image: a
+    depends_on: [b]
+  b:
+    image: b
+    depends_on: [a]
+`})
+
+	_, err := ComposeDeployWaves(composePath)
+	if err == nil || !strings.Contains(err.Error(), "process groups a, b depend on each other") {
+		t.Errorf("Expected a dependency cycle error, got %v", err)
+	}
+}
+
+func TestParseComposeFileForGroup(t *testing.T) {
+	composePath := writeComposeFiles(t, map[string]string{"compose.yml": splitCompose})
+
+	mConfig := &fly.MachineConfig{}
+	if err := ParseComposeFileForGroup(mConfig, composePath, "backend"); err != nil {
+		t.Fatalf("Failed to parse process group: %v", err)
+	}
+
+	if len(mConfig.Containers) != 2 {
+		t.Fatalf("Expected 2 containers, got %d", len(mConfig.Containers))
+	}
+
+	for _, container := range mConfig.Containers {
+		if container.Name != "api" {
+			continue
+		}
+
+		// db runs in another process group, which is deployed first
+		if len(container.DependsOn) != 1 || container.DependsOn[0].Name != "worker" {
+			t.Errorf("Expected api to only depend on worker, got %v", container.DependsOn)
+		}
+	}
+
+	if err := ParseComposeFileForGroup(mConfig, composePath, "api"); err == nil {
+		t.Error("Expected error for a process group without services, got nil")
+	}
+}
@@ -349,7 +349,7 @@ services:
 	}
 }
 
-func TestParseComposeFileMultipleBuildError(t *testing.T) {
+func TestParseComposeFileMultipleBuilds(t *testing.T) {
 	// Create a compose file with multiple build sections
 	tmpDir := t.TempDir()
 	composePath := filepath.Join(tmpDir, "compose.yml")
@@ -366,15 +366,20 @@ services:
 		t.Fatalf("Failed to write test compose file: %v", err)
 	}
 
-	// Parse should fail
 	mConfig := &fly.MachineConfig{}
-	err := ParseComposeFileWithPath(mConfig, composePath)
-	if err == nil {
-		t.Fatal("Expected error for multiple services with build, got nil")
+	if err := ParseComposeFileWithPath(mConfig, composePath); err != nil {
+		t.Fatalf("Failed to parse compose file: %v", err)
 	}
 
-	if !strings.Contains(err.Error(), "only one service can specify build") {
-		t.Errorf("Expected error about multiple build services, got: %v", err)
+	if len(mConfig.Containers) != 2 {
+		t.Fatalf("Expected 2 containers, got %d", len(mConfig.Containers))
+	}
+
+	// Every built service gets the image deploy builds for it
+	for _, container := range mConfig.Containers {
+		if container.Image != "." {
+			t.Errorf("Expected container %s to use image '.', got '%s'", container.Name, container.Image)
+		}
 	}
 }
 
@@ -200,6 +200,28 @@ func GetProcessGroup(ctx context.Context) string {
 	return GetString(ctx, flagnames.ProcessGroup)
 }
 
+// GetPlatforms returns the platforms of the --platform flag, if any.
+func GetPlatforms(ctx context.Context) []string {
+	var platforms []string
+	for _, platform := range strings.Split(GetString(ctx, platformName), ",") {
+		if platform = strings.TrimSpace(platform); platform != "" {
+			platforms = append(platforms, platform)
+		}
+	}
+
+	return platforms
+}
+
+// GetCacheFrom returns the caches of the --cache-from flag.
+func GetCacheFrom(ctx context.Context) []string {
+	return GetStringArray(ctx, cacheFromName)
+}
+
+// GetCacheTo returns the caches of the --cache-to flag.
+func GetCacheTo(ctx context.Context) []string {
+	return GetStringArray(ctx, cacheToName)
+}
+
 func GetBuildkitAddr(ctx context.Context) string {
 	addr := GetString(ctx, "buildkit-addr")
 	if addr == "" {
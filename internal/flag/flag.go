@@ -639,10 +639,41 @@ func CompressionLevel() Int {
 	}
 }
 
+const platformName = "platform"
+
+// Platform returns a string flag for the platforms to build images for.
+func Platform() String {
+	return String{
+		Name:        platformName,
+		Description: `Platforms to build the image for, comma separated, like "linux/amd64,linux/arm64". Several platforms produce a multi-platform image, of which Machines run the linux/amd64 variant, so it must be one of them. Defaults to "linux/amd64".`,
+	}
+}
+
+const (
+	cacheFromName = "cache-from"
+	cacheToName   = "cache-to"
+)
+
+// CacheFrom returns a string array flag for the caches image builds import.
+func CacheFrom() StringArray {
+	return StringArray{
+		Name:        cacheFromName,
+		Description: `Import the build cache from a registry reference, or from "type=registry,ref=<image>" or "type=local,src=<dir>". Can be specified multiple times. Requires BuildKit`,
+	}
+}
+
+// CacheTo returns a string array flag for the caches image builds export.
+func CacheTo() StringArray {
+	return StringArray{
+		Name:        cacheToName,
+		Description: `Export the build cache to a registry reference, or to "type=registry,ref=<image>" or "type=local,dest=<dir>", adding "mode=max" to export the layers of every stage. Can be specified multiple times. Requires BuildKit`,
+	}
+}
+
 func Strategy() String {
 	return String{
 		Name:        "strategy",
-		Description: "The strategy for replacing running instances. Options are canary, rolling, bluegreen, or immediate. The default strategy is rolling.",
+		Description: "The strategy for replacing running instances. Options are canary, rolling, bluegreen, immediate, or progressive. The default strategy is rolling.",
 	}
 }
 
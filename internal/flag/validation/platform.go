@@ -0,0 +1,41 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/flyerr"
+)
+
+// SupportedPlatforms are the platforms images can be built for.
+var SupportedPlatforms = []string{"linux/amd64", "linux/arm64"}
+
+// ValidatePlatforms checks that the platforms of the --platform flag, or of
+// the platforms of fly.toml, are supported and listed once. Machines run the
+// linux/amd64 variant of images, so it has to be one of them.
+func ValidatePlatforms(platforms []string) error {
+	for i, platform := range platforms {
+		if !slices.Contains(SupportedPlatforms, platform) {
+			return flyerr.GenericErr{
+				Err:     fmt.Sprintf("Invalid platform '%s'. Valid platforms are %s.", platform, strings.Join(SupportedPlatforms, ", ")),
+				Suggest: "Please use linux/amd64, linux/arm64 or both, comma separated.",
+			}
+		}
+
+		if slices.Contains(platforms[:i], platform) {
+			return flyerr.GenericErr{
+				Err: fmt.Sprintf("Platform '%s' is listed more than once.", platform),
+			}
+		}
+	}
+
+	if len(platforms) > 0 && !slices.Contains(platforms, "linux/amd64") {
+		return flyerr.GenericErr{
+			Err:     "The platforms don't include linux/amd64, the platform of Machines.",
+			Suggest: "Please add linux/amd64 to the platforms, other platforms are only built for use outside of Machines.",
+		}
+	}
+
+	return nil
+}
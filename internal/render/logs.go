@@ -3,6 +3,7 @@ package render
 import (
 	"bytes"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"time"
 
@@ -17,6 +18,7 @@ type LogOptions struct {
 	RemoveNewlines bool
 	HideRegion     bool
 	HideAllocID    bool
+	AppWidth       int
 }
 
 // LogOption is a func type that returns a LogOption.
@@ -43,6 +45,36 @@ func HideAllocID() LogOption {
 	}
 }
 
+// ShowApp prefixes the log output with the entry's app name, in a color of its
+// own and padded to width so the logs of several apps line up.
+func ShowApp(width int) LogOption {
+	return func(o *LogOptions) {
+		o.AppWidth = width
+	}
+}
+
+// appColors are the colors app names are printed in.
+var appColors = []aurora.Color{
+	aurora.CyanFg,
+	aurora.MagentaFg,
+	aurora.YellowFg,
+	aurora.BlueFg,
+	aurora.GreenFg,
+	aurora.BrightFg | aurora.CyanFg,
+	aurora.BrightFg | aurora.MagentaFg,
+	aurora.BrightFg | aurora.YellowFg,
+	aurora.BrightFg | aurora.BlueFg,
+	aurora.BrightFg | aurora.GreenFg,
+}
+
+// appColor picks the color of appName, which stays the same across runs.
+func appColor(appName string) aurora.Color {
+	h := fnv.New32a()
+	h.Write([]byte(appName))
+
+	return appColors[h.Sum32()%uint32(len(appColors))]
+}
+
 func LogEntry(w io.Writer, entry logs.LogEntry, opts ...LogOption) (err error) {
 	options := &LogOptions{}
 	for _, opt := range opts {
@@ -56,6 +88,11 @@ func LogEntry(w io.Writer, entry logs.LogEntry, opts ...LogOption) (err error) {
 		return
 	}
 
+	if options.AppWidth > 0 && entry.App != "" {
+		prefix := fmt.Sprintf("%-*s |", options.AppWidth, entry.App)
+		fmt.Fprintf(w, "%s ", aurora.Colorize(prefix, appColor(entry.App)))
+	}
+
 	if !options.HideAllocID {
 		if entry.Meta.Event.Provider != "" {
 			if entry.Instance != "" {
@@ -76,14 +113,18 @@ func LogEntry(w io.Writer, entry logs.LogEntry, opts ...LogOption) (err error) {
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "%s ", aurora.Faint(format.Time(ts)))
 
+	instance := any(entry.Instance)
+	if options.AppWidth > 0 && entry.App != "" {
+		instance = aurora.Colorize(entry.Instance, appColor(entry.App))
+	}
 	if entry.Meta.Event.Provider != "" {
 		if entry.Instance != "" {
-			fmt.Fprintf(&buf, "%s[%s]", entry.Meta.Event.Provider, entry.Instance)
+			fmt.Fprintf(&buf, "%s[%s]", entry.Meta.Event.Provider, instance)
 		} else {
 			fmt.Fprint(&buf, entry.Meta.Event.Provider)
 		}
 	} else if entry.Instance != "" {
-		fmt.Fprintf(&buf, "%s", entry.Instance)
+		fmt.Fprintf(&buf, "%s", instance)
 	}
 
 	fmt.Fprintf(&buf, " %s [%s]", aurora.Green(entry.Region), aurora.Colorize(entry.Level, levelColor(entry.Level)))
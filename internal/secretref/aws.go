@@ -0,0 +1,116 @@
+package secretref
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+const awsTimeout = 30 * time.Second
+
+// awsResolver reads secrets from AWS Secrets Manager, with the credentials
+// and region the AWS CLI uses. Secrets are referenced by name or ARN, and
+// keys select fields of secrets stored as JSON.
+type awsResolver struct {
+	loadConfig func(ctx context.Context) (aws.Config, error)
+	// endpoint overrides the regional Secrets Manager endpoint.
+	endpoint string
+	client   *http.Client
+}
+
+func newAWSResolver() *awsResolver {
+	return &awsResolver{
+		loadConfig: func(ctx context.Context) (aws.Config, error) {
+			return config.LoadDefaultConfig(ctx)
+		},
+		endpoint: os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER"),
+		client:   &http.Client{Timeout: awsTimeout},
+	}
+}
+
+func (r *awsResolver) Resolve(ctx context.Context, ref Ref) (string, error) {
+	cfg, err := r.loadConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed loading AWS configuration: %w", err)
+	}
+
+	region := cfg.Region
+	// ARNs look like arn:aws:secretsmanager:us-east-1:123456789012:secret:name.
+	if parts := strings.Split(ref.Path, ":"); len(parts) > 3 && parts[0] == "arn" {
+		region = parts[3]
+	}
+	if region == "" {
+		return "", errors.New("AWS_REGION must be set to read secrets from AWS Secrets Manager")
+	}
+
+	endpoint := cmp.Or(r.endpoint, aws.ToString(cfg.BaseEndpoint), "https://secretsmanager."+region+".amazonaws.com")
+
+	body, err := json.Marshal(map[string]string{"SecretId": ref.Path})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed retrieving AWS credentials: %w", err)
+	}
+
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "secretsmanager", region, time.Now()); err != nil {
+		return "", err
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &failure)
+
+		return "", fmt.Errorf("AWS Secrets Manager responded with %s: %s", res.Status, cmp.Or(failure.Message, failure.Type, "no details"))
+	}
+
+	var secret struct {
+		SecretString *string
+	}
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return "", fmt.Errorf("failed decoding AWS Secrets Manager response: %w", err)
+	}
+	if secret.SecretString == nil {
+		return "", errors.New("binary secrets aren't supported")
+	}
+
+	return selectKey([]byte(*secret.SecretString), ref.Key)
+}
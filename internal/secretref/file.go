@@ -0,0 +1,26 @@
+package secretref
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// fileResolver reads secrets from local files, without their trailing
+// newline. Keys select fields of JSON files.
+type fileResolver struct{}
+
+func (fileResolver) Resolve(_ context.Context, ref Ref) (string, error) {
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.Key == "" {
+		s := strings.TrimSuffix(string(data), "\n")
+
+		return strings.TrimSuffix(s, "\r"), nil
+	}
+
+	return selectKey(data, ref.Key)
+}
@@ -0,0 +1,56 @@
+package secretref
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// onePasswordResolver reads secrets with the 1Password CLI, which understands
+// op://vault/item/field references natively.
+type onePasswordResolver struct {
+	// run runs the op CLI, returning its output.
+	run func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+func newOnePasswordResolver() *onePasswordResolver {
+	return &onePasswordResolver{run: runOnePassword}
+}
+
+func (r *onePasswordResolver) Resolve(ctx context.Context, ref Ref) (string, error) {
+	if ref.Key != "" {
+		return "", errors.New("1password references select fields as op://vault/item/field, not with #key")
+	}
+
+	out, err := r.run(ctx, "read", "--no-newline", ref.String())
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+func runOnePassword(ctx context.Context, args ...string) ([]byte, error) {
+	path, err := exec.LookPath("op")
+	if err != nil {
+		return nil, errors.New("the 1Password CLI (op) must be installed to read op:// references")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("op %s: %s", args[0], msg)
+		}
+
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,140 @@
+// Package secretref resolves references to secrets kept in external secret
+// managers, such as vault://secret/data/app#password, to their values.
+package secretref
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Ref references a secret as scheme://path#key. The key selects a field of
+// secrets holding several of them.
+type Ref struct {
+	Scheme string
+	Path   string
+	Key    string
+}
+
+func (r Ref) String() string {
+	s := r.Scheme + "://" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+
+	return s
+}
+
+// Resolver resolves the references of a scheme.
+type Resolver interface {
+	Resolve(ctx context.Context, ref Ref) (string, error)
+}
+
+// Resolvers maps schemes to the resolvers of their references.
+type Resolvers map[string]Resolver
+
+// Default returns the resolvers of the secret managers flyctl supports:
+//
+//	file://path[#key]         a local file, or a field of a JSON file
+//	vault://path[#key]        HashiCorp Vault, using VAULT_ADDR and VAULT_TOKEN
+//	op://vault/item/field     1Password, using the op CLI
+//	aws-sm://name[#key]       AWS Secrets Manager
+func Default() Resolvers {
+	return Resolvers{
+		"file":   fileResolver{},
+		"vault":  newVaultResolver(),
+		"op":     newOnePasswordResolver(),
+		"aws-sm": newAWSResolver(),
+	}
+}
+
+// Parse parses value as a reference, if it uses the scheme of one of the
+// resolvers. Other values, including URLs like postgres://..., are literals.
+func (rs Resolvers) Parse(value string) (ref Ref, ok bool) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if !ok || rs[scheme] == nil {
+		return Ref{}, false
+	}
+
+	path, key, _ := strings.Cut(rest, "#")
+	if path == "" {
+		return Ref{}, false
+	}
+
+	return Ref{Scheme: scheme, Path: path, Key: key}, true
+}
+
+// ResolveAll returns a copy of secrets with the references replaced by the
+// values they resolve to. Each reference is resolved once, however many
+// secrets use it.
+func (rs Resolvers) ResolveAll(ctx context.Context, secrets map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(secrets))
+	cache := map[Ref]string{}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(secrets)) {
+		value := secrets[name]
+
+		ref, ok := rs.Parse(value)
+		if !ok {
+			resolved[name] = value
+
+			continue
+		}
+
+		if v, ok := cache[ref]; ok {
+			resolved[name] = v
+
+			continue
+		}
+
+		v, err := rs[ref.Scheme].Resolve(ctx, ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed resolving %s: %w", name, ref, err))
+
+			continue
+		}
+
+		cache[ref] = v
+		resolved[name] = v
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// selectKey returns the key field of the JSON object in data, or data as is
+// when key is empty. Fields that aren't strings are returned as JSON.
+func selectKey(data []byte, key string) (string, error) {
+	if key == "" {
+		return string(data), nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("selecting %q requires a JSON object: %w", key, err)
+	}
+
+	return fieldValue(fields, key)
+}
+
+func fieldValue(fields map[string]json.RawMessage, key string) (string, error) {
+	raw, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("no such key %q, expected one of %s", key, strings.Join(slices.Sorted(maps.Keys(fields)), ", "))
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+
+	return string(raw), nil
+}
@@ -0,0 +1,143 @@
+package secretref
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const vaultTimeout = 30 * time.Second
+
+// vaultResolver reads secrets from HashiCorp Vault's HTTP API, at the address
+// and with the token the vault CLI uses. Paths are API paths relative to /v1,
+// so KV version 2 secrets are referenced as vault://secret/data/app#key.
+type vaultResolver struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+
+	// secrets caches the fields of the secrets read so far, by path.
+	secrets map[string]map[string]json.RawMessage
+}
+
+func newVaultResolver() *vaultResolver {
+	return &vaultResolver{
+		addr:      os.Getenv("VAULT_ADDR"),
+		token:     os.Getenv("VAULT_TOKEN"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		client:    &http.Client{Timeout: vaultTimeout},
+	}
+}
+
+func (r *vaultResolver) Resolve(ctx context.Context, ref Ref) (string, error) {
+	fields, ok := r.secrets[ref.Path]
+	if !ok {
+		var err error
+		if fields, err = r.read(ctx, ref.Path); err != nil {
+			return "", err
+		}
+
+		if r.secrets == nil {
+			r.secrets = map[string]map[string]json.RawMessage{}
+		}
+		r.secrets[ref.Path] = fields
+	}
+
+	if ref.Key != "" {
+		return fieldValue(fields, ref.Key)
+	}
+	if len(fields) == 1 {
+		for key := range fields {
+			return fieldValue(fields, key)
+		}
+	}
+
+	return "", fmt.Errorf("secret has several keys, select one of %s with #key", strings.Join(slices.Sorted(maps.Keys(fields)), ", "))
+}
+
+func (r *vaultResolver) read(ctx context.Context, path string) (map[string]json.RawMessage, error) {
+	if r.addr == "" {
+		return nil, errors.New("VAULT_ADDR must be set to read secrets from vault")
+	}
+
+	token, err := r.readToken()
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(r.addr, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if r.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.namespace)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(body, &failure)
+
+		return nil, fmt.Errorf("vault responded with %s: %s", res.Status, cmp.Or(strings.Join(failure.Errors, "; "), "no details"))
+	}
+
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("failed decoding vault response: %w", err)
+	}
+
+	// KV version 2 nests the secret's fields next to its metadata.
+	if nested, ok := secret.Data["data"]; ok && secret.Data["metadata"] != nil {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(nested, &fields); err == nil {
+			return fields, nil
+		}
+	}
+
+	return secret.Data, nil
+}
+
+func (r *vaultResolver) readToken() (string, error) {
+	if r.token != "" {
+		return r.token, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		return "", errors.New("VAULT_TOKEN must be set, or a token saved with vault login, to read secrets from vault")
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
@@ -65,6 +65,9 @@ type Release struct {
 	User               string             `json:"user"`
 	CreatedAt          time.Time          `json:"created_at"`
 	ImageRef           string             `json:"image_ref"`
+	// Definition is the app config the release was deployed with, as sent in
+	// CreateReleaseRequest. It's nil for releases created without one.
+	Definition map[string]any `json:"definition,omitempty"`
 }
 
 type CreateReleaseRequest struct {
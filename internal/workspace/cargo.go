@@ -0,0 +1,113 @@
+package workspace
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+type cargoManifest struct {
+	Package *struct {
+		Name string `toml:"name"`
+	} `toml:"package"`
+	Workspace *struct {
+		Members []string `toml:"members"`
+		Exclude []string `toml:"exclude"`
+		Package struct {
+			RustVersion string `toml:"rust-version"`
+		} `toml:"package"`
+	} `toml:"workspace"`
+	Dependencies      map[string]any `toml:"dependencies"`
+	DevDependencies   map[string]any `toml:"dev-dependencies"`
+	BuildDependencies map[string]any `toml:"build-dependencies"`
+}
+
+// dependencies returns the names of the packages the manifest depends on by
+// path or through the workspace, which are the ones that may be members of
+// the workspace. Renamed dependencies are named by their package field.
+func (m *cargoManifest) dependencies() []string {
+	names := map[string]bool{}
+	for _, deps := range []map[string]any{m.Dependencies, m.DevDependencies, m.BuildDependencies} {
+		for name, dep := range deps {
+			table, ok := dep.(map[string]any)
+			if !ok {
+				continue
+			}
+			if _, isPath := table["path"]; !isPath && table["workspace"] != true {
+				continue
+			}
+			if pkg, ok := table["package"].(string); ok {
+				name = pkg
+			}
+			names[name] = true
+		}
+	}
+
+	return slices.Sorted(maps.Keys(names))
+}
+
+func readCargoManifest(path string) (*cargoManifest, error) {
+	data, err := readFile(path)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var m cargoManifest
+	if err := toml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &m, nil
+}
+
+// detectCargo detects the Cargo workspace of the [workspace] of Cargo.toml.
+func detectCargo(root string) (*Workspace, error) {
+	manifest, err := readCargoManifest(filepath.Join(root, "Cargo.toml"))
+	if err != nil || manifest == nil || manifest.Workspace == nil {
+		return nil, err
+	}
+
+	globs := slices.Clone(manifest.Workspace.Members)
+	for _, exclude := range manifest.Workspace.Exclude {
+		globs = append(globs, "!"+exclude)
+	}
+
+	dirs, err := expandGlobs(root, globs)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workspace{
+		Kind:      KindCargo,
+		Root:      root,
+		Version:   manifest.Workspace.Package.RustVersion,
+		RootFiles: rootFiles(root, "Cargo.toml", "Cargo.lock", "rust-toolchain", "rust-toolchain.toml", ".cargo"),
+	}
+
+	var members []*cargoManifest
+	for _, dir := range dirs {
+		member, err := readCargoManifest(filepath.Join(root, filepath.FromSlash(dir), "Cargo.toml"))
+		if err != nil {
+			return nil, err
+		}
+		if member == nil || member.Package == nil {
+			continue
+		}
+
+		members = append(members, member)
+		w.Packages = append(w.Packages, &Package{Name: member.Package.Name, Dir: dir})
+	}
+
+	for i, member := range members {
+		for _, dep := range member.dependencies() {
+			if w.Lookup(dep) != nil {
+				w.Packages[i].Dependencies = append(w.Packages[i].Dependencies, dep)
+			}
+		}
+	}
+
+	return w, nil
+}
@@ -0,0 +1,45 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ChangedFiles returns the files of the git repository dir is in that changed
+// since ref, including uncommitted changes, relative to dir.
+func ChangedFiles(ctx context.Context, dir, ref string) ([]string, error) {
+	// Refs never start with a dash, options do
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git ref %q", ref)
+	}
+
+	var files []string
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", ref, "--"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+
+		out, err := cmd.Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				return nil, fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+			}
+
+			return nil, fmt.Errorf("git %s failed: %w", args[0], err)
+		}
+
+		for _, line := range strings.Split(string(out), "\n") {
+			if line != "" {
+				files = append(files, line)
+			}
+		}
+	}
+
+	return files, nil
+}
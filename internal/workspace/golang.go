@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// detectGo detects the Go workspace of go.work.
+func detectGo(root string) (*Workspace, error) {
+	data, err := readFile(filepath.Join(root, "go.work"))
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	work, err := modfile.ParseWork("go.work", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.work: %w", err)
+	}
+
+	w := &Workspace{
+		Kind:      KindGo,
+		Root:      root,
+		RootFiles: rootFiles(root, "go.work", "go.work.sum"),
+	}
+	if work.Go != nil {
+		w.Version = work.Go.Version
+	}
+
+	var requires [][]string
+	for _, use := range work.Use {
+		dir := path.Clean(filepath.ToSlash(use.Path))
+		if path.IsAbs(dir) || strings.HasPrefix(dir, "../") {
+			continue
+		}
+
+		gomod := filepath.Join(root, filepath.FromSlash(dir), "go.mod")
+		data, err := readFile(gomod)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+
+		mod, err := modfile.ParseLax(gomod, data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", gomod, err)
+		}
+		if mod.Module == nil {
+			continue
+		}
+
+		var modRequires []string
+		for _, req := range mod.Require {
+			modRequires = append(modRequires, req.Mod.Path)
+		}
+
+		requires = append(requires, modRequires)
+		w.Packages = append(w.Packages, &Package{Name: mod.Module.Mod.Path, Dir: dir})
+	}
+
+	for i, modRequires := range requires {
+		for _, req := range modRequires {
+			if w.Lookup(req) != nil {
+				w.Packages[i].Dependencies = append(w.Packages[i].Dependencies, req)
+			}
+		}
+	}
+
+	sortPackages(w)
+
+	return w, nil
+}
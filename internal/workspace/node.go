@@ -0,0 +1,258 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodeRootFiles are the files at the root of JavaScript workspaces their
+// packages are installed and built with.
+var nodeRootFiles = []string{
+	"package.json",
+	"pnpm-lock.yaml",
+	"pnpm-workspace.yaml",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	".yarnrc.yml",
+	".yarn",
+	".npmrc",
+	".nvmrc",
+	"turbo.json",
+	"nx.json",
+	"tsconfig.json",
+	"tsconfig.base.json",
+}
+
+type packageJSON struct {
+	Name                 string            `json:"name"`
+	Workspaces           json.RawMessage   `json:"workspaces"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	Engines              map[string]string `json:"engines"`
+}
+
+// workspaces returns the package globs of the workspaces field, which is
+// either a list of globs or an object with the list in its packages field.
+func (p *packageJSON) workspaces() ([]string, error) {
+	if len(p.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	var globs []string
+	if err := json.Unmarshal(p.Workspaces, &globs); err == nil {
+		return globs, nil
+	}
+
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(p.Workspaces, &object); err != nil {
+		return nil, fmt.Errorf("invalid workspaces field: %w", err)
+	}
+
+	return object.Packages, nil
+}
+
+// dependencies returns the names of all the dependencies of the package.
+func (p *packageJSON) dependencies() []string {
+	names := map[string]bool{}
+	for _, deps := range []map[string]string{p.Dependencies, p.DevDependencies, p.PeerDependencies, p.OptionalDependencies} {
+		for name := range deps {
+			names[name] = true
+		}
+	}
+
+	return slices.Sorted(maps.Keys(names))
+}
+
+func readPackageJSON(path string) (*packageJSON, error) {
+	data, err := readFile(path)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var p packageJSON
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// detectPnpm detects the pnpm workspace of pnpm-workspace.yaml.
+func detectPnpm(root string) (*Workspace, error) {
+	data, err := readFile(filepath.Join(root, "pnpm-workspace.yaml"))
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Packages []string `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse pnpm-workspace.yaml: %w", err)
+	}
+
+	return nodeWorkspace(root, KindPnpm, manifest.Packages)
+}
+
+// detectNodeWorkspaces detects the npm or Yarn workspace of the workspaces of
+// package.json.
+func detectNodeWorkspaces(root string) (*Workspace, error) {
+	pkg, err := readPackageJSON(filepath.Join(root, "package.json"))
+	if err != nil || pkg == nil {
+		return nil, err
+	}
+
+	globs, err := pkg.workspaces()
+	if err != nil || len(globs) == 0 {
+		return nil, err
+	}
+
+	kind := KindNpm
+	if exists(filepath.Join(root, "yarn.lock")) || exists(filepath.Join(root, ".yarnrc.yml")) {
+		kind = KindYarn
+	}
+
+	return nodeWorkspace(root, kind, globs)
+}
+
+// nodeWorkspace returns the JavaScript workspace at root of the packages in
+// the directories matching globs.
+func nodeWorkspace(root string, kind Kind, globs []string) (*Workspace, error) {
+	dirs, err := expandGlobs(root, globs)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workspace{
+		Kind:      kind,
+		Root:      root,
+		Tool:      nodeTool(root),
+		RootFiles: rootFiles(root, nodeRootFiles...),
+	}
+
+	if rootPkg, err := readPackageJSON(filepath.Join(root, "package.json")); err != nil {
+		return nil, err
+	} else if rootPkg != nil {
+		w.Version = nodeVersion(rootPkg.Engines["node"])
+	}
+
+	var manifests []*packageJSON
+	for _, dir := range dirs {
+		pkg, err := readPackageJSON(filepath.Join(root, filepath.FromSlash(dir), "package.json"))
+		if err != nil {
+			return nil, err
+		}
+		if pkg == nil {
+			continue
+		}
+		if pkg.Name == "" {
+			pkg.Name = path.Base(dir)
+		}
+
+		manifests = append(manifests, pkg)
+		w.Packages = append(w.Packages, &Package{Name: pkg.Name, Dir: dir})
+	}
+
+	for i, pkg := range manifests {
+		for _, dep := range pkg.dependencies() {
+			if w.Lookup(dep) != nil {
+				w.Packages[i].Dependencies = append(w.Packages[i].Dependencies, dep)
+			}
+		}
+	}
+
+	return w, nil
+}
+
+// nodeTool returns the task runner of the JavaScript workspace at root.
+func nodeTool(root string) string {
+	switch {
+	case exists(filepath.Join(root, "turbo.json")):
+		return ToolTurbo
+	case exists(filepath.Join(root, "nx.json")):
+		return ToolNx
+	default:
+		return ""
+	}
+}
+
+// nodeVersion returns the major Node version of the node engine constraint of
+// package.json, such as 20 for ">=20.11", or an empty string.
+func nodeVersion(constraint string) string {
+	constraint = strings.TrimLeft(constraint, ">=^~v ")
+	major, _, _ := strings.Cut(constraint, ".")
+	if major == "" || strings.Trim(major, "0123456789") != "" {
+		return ""
+	}
+
+	return major
+}
+
+type projectJSON struct {
+	Name                 string   `json:"name"`
+	ImplicitDependencies []string `json:"implicitDependencies"`
+}
+
+// detectNx detects the Nx workspace of nx.json whose projects are described
+// by project.json files instead of package manager workspaces.
+func detectNx(root string) (*Workspace, error) {
+	if !exists(filepath.Join(root, "nx.json")) {
+		return nil, nil
+	}
+
+	dirs, err := expandGlobs(root, []string{"**"})
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workspace{
+		Kind:      KindNx,
+		Root:      root,
+		Tool:      ToolNx,
+		RootFiles: rootFiles(root, nodeRootFiles...),
+	}
+
+	for _, dir := range dirs {
+		data, err := readFile(filepath.Join(root, filepath.FromSlash(dir), "project.json"))
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+
+		var project projectJSON
+		if err := json.Unmarshal(data, &project); err != nil {
+			return nil, fmt.Errorf("failed to parse %s/project.json: %w", dir, err)
+		}
+		if project.Name == "" {
+			project.Name = path.Base(dir)
+		}
+
+		p := &Package{Name: project.Name, Dir: dir}
+		for _, dep := range project.ImplicitDependencies {
+			if !strings.HasPrefix(dep, "!") {
+				p.Dependencies = append(p.Dependencies, dep)
+			}
+		}
+		w.Packages = append(w.Packages, p)
+	}
+
+	if len(w.Packages) == 0 {
+		return nil, nil
+	}
+
+	return w, nil
+}
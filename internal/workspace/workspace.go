@@ -0,0 +1,295 @@
+// Package workspace detects the monorepo workspaces of pnpm, npm, Yarn, Nx,
+// Go and Cargo, and the dependencies between their packages.
+package workspace
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Kind is the tool managing the packages of a workspace.
+type Kind string
+
+const (
+	KindPnpm  Kind = "pnpm"
+	KindNpm   Kind = "npm"
+	KindYarn  Kind = "yarn"
+	KindNx    Kind = "nx"
+	KindGo    Kind = "go"
+	KindCargo Kind = "cargo"
+)
+
+// Task runners building the packages of JavaScript workspaces.
+const (
+	ToolTurbo = "turbo"
+	ToolNx    = "nx"
+)
+
+// Workspace is a monorepo of packages depending on each other.
+type Workspace struct {
+	Kind Kind
+	// Root is the absolute path of the root directory of the workspace.
+	Root string
+	// Tool is the task runner building the packages of JavaScript
+	// workspaces, if any.
+	Tool string
+	// Version is the version of the toolchain the workspace asks for, if any.
+	Version string
+	// RootFiles are the files at the root of the workspace needed to build
+	// any of its packages, relative to Root.
+	RootFiles []string
+	// Packages are the packages of the workspace, ordered by directory.
+	Packages []*Package
+}
+
+// Package is a package of a workspace.
+type Package struct {
+	Name string
+	// Dir is the directory of the package relative to the workspace root,
+	// with forward slashes.
+	Dir string
+	// Dependencies are the names of the packages of the workspace the package
+	// depends on.
+	Dependencies []string
+}
+
+// detectors detect the workspace rooted at a directory, returning nil when
+// there's none.
+var detectors = []func(root string) (*Workspace, error){
+	detectPnpm,
+	detectGo,
+	detectCargo,
+	detectNodeWorkspaces,
+	detectNx,
+}
+
+// Detect returns the workspace dir is part of, found in dir or the directories
+// above it up to the root of the git repository. It returns nil when dir isn't
+// part of a workspace.
+func Detect(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for root := dir; ; {
+		for _, detect := range detectors {
+			w, err := detect(root)
+			if err != nil {
+				return nil, err
+			}
+			if w != nil && (root == dir || w.Package(dir) != nil) {
+				return w, nil
+			}
+		}
+
+		parent := filepath.Dir(root)
+		if parent == root || exists(filepath.Join(root, ".git")) {
+			return nil, nil
+		}
+		root = parent
+	}
+}
+
+// Package returns the package dir is in, or nil when it isn't in one.
+func (w *Workspace) Package(dir string) *Package {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(w.Root, dir)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	var found *Package
+	for _, p := range w.Packages {
+		if (rel == p.Dir || strings.HasPrefix(rel, p.Dir+"/")) && (found == nil || len(p.Dir) > len(found.Dir)) {
+			found = p
+		}
+	}
+
+	return found
+}
+
+// Lookup returns the package named name, or nil when there's none.
+func (w *Workspace) Lookup(name string) *Package {
+	for _, p := range w.Packages {
+		if p.Name == name {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// Closure returns p and the packages it depends on, directly or through other
+// packages, ordered by directory.
+func (w *Workspace) Closure(p *Package) []*Package {
+	seen := map[*Package]bool{}
+
+	var visit func(p *Package)
+	visit = func(p *Package) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+
+		for _, dep := range p.Dependencies {
+			if d := w.Lookup(dep); d != nil {
+				visit(d)
+			}
+		}
+	}
+	visit(p)
+
+	closure := make([]*Package, 0, len(seen))
+	for _, q := range w.Packages {
+		if seen[q] {
+			closure = append(closure, q)
+		}
+	}
+
+	return closure
+}
+
+// Affected reports whether changes to files, relative to the workspace root,
+// change p or the packages it depends on.
+func (w *Workspace) Affected(p *Package, files []string) bool {
+	closure := w.Closure(p)
+
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		if slices.Contains(w.RootFiles, file) {
+			return true
+		}
+
+		for _, q := range closure {
+			if strings.HasPrefix(file, q.Dir+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sortPackages orders the packages of w by directory.
+func sortPackages(w *Workspace) {
+	slices.SortFunc(w.Packages, func(a, b *Package) int { return strings.Compare(a.Dir, b.Dir) })
+}
+
+// rootFiles returns those of names that exist in root.
+func rootFiles(root string, names ...string) []string {
+	var found []string
+	for _, name := range names {
+		if exists(filepath.Join(root, name)) {
+			found = append(found, name)
+		}
+	}
+
+	return found
+}
+
+// skippedDirs are never searched for packages.
+var skippedDirs = []string{".git", "node_modules", "target", "vendor", "dist"}
+
+// expandGlobs returns the directories of root matching the patterns, relative
+// to root with forward slashes, ordered. Patterns match directories with path
+// syntax, where ** matches any number of directories, and patterns starting
+// with ! exclude the directories they match.
+func expandGlobs(root string, patterns []string) ([]string, error) {
+	var include, exclude []string
+	for _, p := range patterns {
+		if p, ok := strings.CutPrefix(p, "!"); ok {
+			exclude = append(exclude, cleanGlob(p))
+		} else {
+			include = append(include, cleanGlob(p))
+		}
+	}
+
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || p == root {
+			return nil
+		}
+		if slices.Contains(skippedDirs, d.Name()) {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		matches := func(pattern string) bool { return matchGlob(pattern, rel) }
+		if slices.ContainsFunc(include, matches) && !slices.ContainsFunc(exclude, matches) {
+			dirs = append(dirs, rel)
+		}
+
+		return nil
+	})
+
+	return dirs, err
+}
+
+// cleanGlob normalizes a workspace glob pattern.
+func cleanGlob(pattern string) string {
+	return strings.TrimSuffix(path.Clean(strings.TrimPrefix(filepath.ToSlash(pattern), "./")), "/")
+}
+
+// matchGlob reports whether the slash-separated name matches pattern, where **
+// matches any number of path segments.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// readFile reads the file at path, returning nil when it doesn't exist.
+func readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	return data, err
+}
@@ -0,0 +1,265 @@
+package workspace
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, contents := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+}
+
+func packageDeps(w *Workspace) map[string][]string {
+	deps := map[string][]string{}
+	for _, p := range w.Packages {
+		deps[p.Dir+" "+p.Name] = p.Dependencies
+	}
+
+	return deps
+}
+
+func packageDirs(packages []*Package) []string {
+	var dirs []string
+	for _, p := range packages {
+		dirs = append(dirs, p.Dir)
+	}
+
+	return dirs
+}
+
+func TestDetectPnpm(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD":           "",
+		"package.json":        `{"engines": {"node": ">=20.11"}}`,
+		"pnpm-workspace.yaml": "packages:\n  - apps/*\n  - packages/**\n  - '!packages/legacy'\n",
+		"pnpm-lock.yaml":      "",
+		"turbo.json":          "{}",
+		"apps/web/package.json": `{
+			"name": "web",
+			"dependencies": {"ui": "workspace:*", "react": "^19"}
+		}`,
+		"apps/web/src/index.ts":                 "",
+		"apps/docs/package.json":                `{"name": "docs"}`,
+		"packages/ui/package.json":              `{"name": "ui", "devDependencies": {"config": "workspace:*"}}`,
+		"packages/tools/config/package.json":    `{"name": "config"}`,
+		"packages/legacy/package.json":          `{"name": "legacy"}`,
+		"apps/web/node_modules/ui/package.json": `{"name": "ui"}`,
+	})
+
+	w, err := Detect(filepath.Join(root, "apps", "web", "src"))
+	require.NoError(t, err)
+	require.NotNil(t, w)
+
+	assert.Equal(t, KindPnpm, w.Kind)
+	assert.Equal(t, root, w.Root)
+	assert.Equal(t, ToolTurbo, w.Tool)
+	assert.Equal(t, "20", w.Version)
+	assert.Equal(t, []string{"package.json", "pnpm-lock.yaml", "pnpm-workspace.yaml", "turbo.json"}, w.RootFiles)
+	assert.Equal(t, map[string][]string{
+		"apps/docs docs":               nil,
+		"apps/web web":                 {"ui"},
+		"packages/tools/config config": nil,
+		"packages/ui ui":               {"config"},
+	}, packageDeps(w))
+
+	web := w.Package(filepath.Join(root, "apps", "web", "src"))
+	require.NotNil(t, web)
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, []string{"apps/web", "packages/tools/config", "packages/ui"}, packageDirs(w.Closure(web)))
+
+	assert.True(t, w.Affected(web, []string{"packages/tools/config/index.js"}))
+	assert.True(t, w.Affected(web, []string{"pnpm-lock.yaml"}))
+	assert.False(t, w.Affected(web, []string{"apps/docs/README.md", "README.md"}))
+}
+
+func TestDetectNpmWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json":            `{"workspaces": {"packages": ["packages/*"]}}`,
+		"yarn.lock":               "",
+		"packages/a/package.json": `{"name": "@acme/a", "peerDependencies": {"@acme/b": "*"}}`,
+		"packages/b/package.json": `{"name": "@acme/b"}`,
+	})
+
+	w, err := Detect(filepath.Join(root, "packages", "a"))
+	require.NoError(t, err)
+	require.NotNil(t, w)
+
+	assert.Equal(t, KindYarn, w.Kind)
+	assert.Equal(t, "", w.Tool)
+	assert.Equal(t, map[string][]string{
+		"packages/a @acme/a": {"@acme/b"},
+		"packages/b @acme/b": nil,
+	}, packageDeps(w))
+}
+
+func TestDetectNx(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"nx.json":                  "{}",
+		"package.json":             "{}",
+		"apps/api/project.json":    `{"name": "api", "implicitDependencies": ["shared", "!docs"]}`,
+		"libs/shared/project.json": `{}`,
+	})
+
+	w, err := Detect(filepath.Join(root, "apps", "api"))
+	require.NoError(t, err)
+	require.NotNil(t, w)
+
+	assert.Equal(t, KindNx, w.Kind)
+	assert.Equal(t, ToolNx, w.Tool)
+	assert.Equal(t, map[string][]string{
+		"apps/api api":       {"shared"},
+		"libs/shared shared": nil,
+	}, packageDeps(w))
+}
+
+func TestDetectGo(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.work":                  "go 1.23.2\n\nuse (\n\t./services/api\n\t./lib\n\t../elsewhere\n)\n",
+		"go.work.sum":              "",
+		"lib/go.mod":               "module example.com/lib\n\ngo 1.23\n",
+		"services/api/go.mod":      "module example.com/api\n\ngo 1.23\n\nrequire (\n\texample.com/lib v0.0.0\n\tgithub.com/pkg/errors v0.9.1\n)\n",
+		"services/api/cmd/main.go": "package main\n",
+	})
+
+	w, err := Detect(filepath.Join(root, "services", "api", "cmd"))
+	require.NoError(t, err)
+	require.NotNil(t, w)
+
+	assert.Equal(t, KindGo, w.Kind)
+	assert.Equal(t, "1.23.2", w.Version)
+	assert.Equal(t, []string{"go.work", "go.work.sum"}, w.RootFiles)
+	assert.Equal(t, []string{"lib", "services/api"}, packageDirs(w.Packages))
+	assert.Equal(t, map[string][]string{
+		"lib example.com/lib":          nil,
+		"services/api example.com/api": {"example.com/lib"},
+	}, packageDeps(w))
+}
+
+func TestDetectCargo(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Cargo.toml": `
+[workspace]
+members = ["crates/*"]
+exclude = ["crates/scratch"]
+
+[workspace.package]
+rust-version = "1.82"
+
+[workspace.dependencies]
+core = { path = "crates/core" }
+`,
+		"Cargo.lock": "",
+		"crates/server/Cargo.toml": `
+[package]
+name = "server"
+
+[dependencies]
+serde = "1"
+core = { workspace = true }
+models = { path = "../models", package = "acme-models" }
+`,
+		"crates/core/Cargo.toml":    "[package]\nname = \"core\"\n",
+		"crates/models/Cargo.toml":  "[package]\nname = \"acme-models\"\n",
+		"crates/scratch/Cargo.toml": "[package]\nname = \"scratch\"\n",
+	})
+
+	w, err := Detect(filepath.Join(root, "crates", "server"))
+	require.NoError(t, err)
+	require.NotNil(t, w)
+
+	assert.Equal(t, KindCargo, w.Kind)
+	assert.Equal(t, "1.82", w.Version)
+	assert.Equal(t, []string{"Cargo.toml", "Cargo.lock"}, w.RootFiles)
+	assert.Equal(t, map[string][]string{
+		"crates/core core":          nil,
+		"crates/models acme-models": nil,
+		"crates/server server":      {"acme-models", "core"},
+	}, packageDeps(w))
+}
+
+func TestDetectNone(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD":                 "",
+		"pnpm-workspace.yaml":       "packages:\n  - packages/*\n  - nested\n",
+		"tools/script/package.json": `{"name": "script"}`,
+	})
+
+	// The workspace doesn't include tools/script.
+	w, err := Detect(filepath.Join(root, "tools", "script"))
+	require.NoError(t, err)
+	assert.Nil(t, w)
+
+	// Workspaces aren't searched for above the root of the git repository.
+	nested := filepath.Join(root, "nested")
+	writeFiles(t, nested, map[string]string{".git/HEAD": "", "package.json": "{}"})
+	w, err = Detect(nested)
+	require.NoError(t, err)
+	assert.Nil(t, w)
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"apps/*", "apps/web", true},
+		{"apps/*", "apps/web/src", false},
+		{"packages/**", "packages/a/b", true},
+		{"packages/**", "packages", true},
+		{"**/lib", "a/b/lib", true},
+		{"crates/c?re", "crates/core", true},
+		{"apps", "apps/web", false},
+	} {
+		assert.Equal(t, tc.want, matchGlob(tc.pattern, tc.name), "%s %s", tc.pattern, tc.name)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	writeFiles(t, root, map[string]string{"apps/web/index.js": "", "apps/docs/index.md": ""})
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	writeFiles(t, root, map[string]string{"apps/web/index.js": "changed", "apps/web/new.js": "", "apps/docs/new.md": ""})
+
+	files, err := ChangedFiles(t.Context(), filepath.Join(root, "apps", "web"), "HEAD")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"index.js", "new.js"}, files)
+
+	_, err = ChangedFiles(t.Context(), root, "no-such-ref")
+	assert.ErrorContains(t, err, "git diff failed")
+	_, err = ChangedFiles(t.Context(), root, "--output=changes.txt")
+	assert.ErrorContains(t, err, "invalid git ref")
+	assert.NoFileExists(t, filepath.Join(root, "changes.txt"))
+}
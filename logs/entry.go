@@ -1,6 +1,10 @@
 package logs
 
+import "time"
+
 type LogEntry struct {
+	// App is only set when the logs of several apps are streamed together.
+	App       string `json:"app,omitempty"`
 	Level     string `json:"level"`
 	Instance  string `json:"instance"`
 	Message   string `json:"message"`
@@ -9,6 +13,11 @@ type LogEntry struct {
 	Meta      Meta   `json:"meta"`
 }
 
+// Time parses the entry's timestamp.
+func (e LogEntry) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, e.Timestamp)
+}
+
 type Meta struct {
 	Instance string
 	Region   string
@@ -46,7 +55,12 @@ type natsLog struct {
 		Region string `json:"region"`
 	} `json:"fly"`
 	Host string `json:"host"`
-	Log  struct {
+	HTTP struct {
+		Response struct {
+			StatusCode int `json:"status_code"`
+		} `json:"response"`
+	} `json:"http"`
+	Log struct {
 		Level string `json:"level"`
 	} `json:"log"`
 	Message   string `json:"message"`
@@ -0,0 +1,153 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// levelSeverity orders the log levels --level accepts, from the least to the
+// most severe. Aliases share the severity of the level they stand for.
+var levelSeverity = map[string]int{
+	"trace":    0,
+	"debug":    1,
+	"info":     2,
+	"notice":   2,
+	"warn":     3,
+	"warning":  3,
+	"error":    4,
+	"err":      4,
+	"fatal":    5,
+	"critical": 5,
+	"crit":     5,
+	"panic":    5,
+}
+
+// IsLevel reports whether level is a log level Filter.Level accepts.
+func IsLevel(level string) bool {
+	_, ok := levelSeverity[strings.ToLower(level)]
+
+	return ok
+}
+
+// StatusCodeRange matches HTTP response status codes between Min and Max, inclusive.
+type StatusCodeRange struct {
+	Min int
+	Max int
+}
+
+// ParseStatusCodes parses status code filters such as 404, 5xx or 400-499.
+func ParseStatusCodes(values []string) ([]StatusCodeRange, error) {
+	ranges := make([]StatusCodeRange, 0, len(values))
+	for _, value := range values {
+		r, err := parseStatusCodeRange(strings.ToLower(strings.TrimSpace(value)))
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+
+	return ranges, nil
+}
+
+func parseStatusCodeRange(value string) (StatusCodeRange, error) {
+	invalid := fmt.Errorf("invalid status code %q, expected a code like 404, a class like 5xx or a range like 400-499", value)
+
+	if class, ok := strings.CutSuffix(value, "xx"); ok {
+		n, err := strconv.Atoi(class)
+		if err != nil || n < 1 || n > 5 || len(class) != 1 {
+			return StatusCodeRange{}, invalid
+		}
+
+		return StatusCodeRange{Min: n * 100, Max: n*100 + 99}, nil
+	}
+
+	from, to, isRange := strings.Cut(value, "-")
+	if !isRange {
+		to = from
+	}
+	low, err := strconv.Atoi(from)
+	if err != nil {
+		return StatusCodeRange{}, invalid
+	}
+	high, err := strconv.Atoi(to)
+	if err != nil || low < 100 || high > 599 || low > high {
+		return StatusCodeRange{}, invalid
+	}
+
+	return StatusCodeRange{Min: low, Max: high}, nil
+}
+
+// Filter selects which log entries are streamed. The zero Filter matches every entry.
+type Filter struct {
+	// Level is the minimum level of the entries to match.
+	Level string
+	// Grep is matched against the entries' messages.
+	Grep *regexp.Regexp
+	// Since and Until bound the entries' timestamps, when set.
+	Since time.Time
+	Until time.Time
+	// Instances restricts the entries to the ones of these machines, e.g. the
+	// machines of a process group. A nil map matches every machine.
+	Instances map[string]bool
+	// StatusCodes restricts the entries to HTTP responses with these status codes.
+	StatusCodes []StatusCodeRange
+}
+
+// Match reports whether entry passes every condition of the filter.
+func (f *Filter) Match(entry LogEntry) bool {
+	if f.Level != "" {
+		severity, ok := levelSeverity[strings.ToLower(entry.Level)]
+		if !ok || severity < levelSeverity[strings.ToLower(f.Level)] {
+			return false
+		}
+	}
+
+	if f.Grep != nil && !f.Grep.MatchString(entry.Message) {
+		return false
+	}
+
+	if f.Instances != nil && !f.Instances[entry.Instance] {
+		return false
+	}
+
+	if len(f.StatusCodes) > 0 && !f.matchStatusCode(entry.Meta.HTTP.Response.StatusCode) {
+		return false
+	}
+
+	if f.hasTimeWindow() {
+		ts, err := entry.Time()
+		if err != nil {
+			return false
+		}
+		if !f.Since.IsZero() && ts.Before(f.Since) {
+			return false
+		}
+		if f.after(ts) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *Filter) matchStatusCode(code int) bool {
+	for _, r := range f.StatusCodes {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *Filter) hasTimeWindow() bool {
+	return !f.Since.IsZero() || !f.Until.IsZero()
+}
+
+// after reports whether t is past the end of the filter's time window.
+func (f *Filter) after(t time.Time) bool {
+	return !f.Until.IsZero() && t.After(f.Until)
+}
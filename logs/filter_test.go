@@ -0,0 +1,67 @@
+package logs
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatusCodes(t *testing.T) {
+	ranges, err := ParseStatusCodes([]string{"404", "5xx", "400-429"})
+	require.NoError(t, err)
+	assert.Equal(t, []StatusCodeRange{{404, 404}, {500, 599}, {400, 429}}, ranges)
+
+	for _, value := range []string{"", "abc", "6xx", "50x", "99", "499-400", "200-700"} {
+		_, err := ParseStatusCodes([]string{value})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	entry := func(level, message, instance, timestamp string, status int) LogEntry {
+		e := LogEntry{Level: level, Message: message, Instance: instance, Timestamp: timestamp}
+		e.Meta.HTTP.Response.StatusCode = status
+
+		return e
+	}
+	var (
+		info   = entry("info", "GET /health 200", "m1", "2026-01-02T10:00:00.000Z", 200)
+		warn   = entry("WARN", "slow response", "m2", "2026-01-02T11:00:00.000Z", 0)
+		failed = entry("error", "GET /api 502", "m1", "2026-01-02T12:00:00.000Z", 502)
+		all    = []LogEntry{info, warn, failed}
+	)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []LogEntry
+	}{
+		{"zero filter", Filter{}, all},
+		{"level", Filter{Level: "warning"}, []LogEntry{warn, failed}},
+		{"grep", Filter{Grep: regexp.MustCompile(`^GET `)}, []LogEntry{info, failed}},
+		{"instances", Filter{Instances: map[string]bool{"m2": true}}, []LogEntry{warn}},
+		{"status codes", Filter{StatusCodes: []StatusCodeRange{{500, 599}}}, []LogEntry{failed}},
+		{"since", Filter{Since: time.Date(2026, 1, 2, 10, 30, 0, 0, time.UTC)}, []LogEntry{warn, failed}},
+		{"until", Filter{Until: time.Date(2026, 1, 2, 11, 30, 0, 0, time.UTC)}, []LogEntry{info, warn}},
+		{"combined", Filter{Level: "info", Instances: map[string]bool{"m1": true}, Grep: regexp.MustCompile(`api`)}, []LogEntry{failed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []LogEntry
+			for _, e := range all {
+				if tt.filter.Match(e) {
+					got = append(got, e)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("time window drops entries without a timestamp", func(t *testing.T) {
+		filter := Filter{Since: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+		assert.False(t, filter.Match(LogEntry{Message: "no timestamp"}))
+	})
+}
@@ -18,6 +18,8 @@ type LogOptions struct {
 	VMID       string
 	RegionCode string
 	NoTail     bool
+	// Filter is applied to the entries of every stream.
+	Filter Filter
 }
 
 type WebClient interface {
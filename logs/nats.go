@@ -95,20 +95,20 @@ func fromNats(ctx context.Context, out chan<- LogEntry, nc *nats.Conn, opts *Log
 	}
 	defer sub.Unsubscribe()
 
-	var log natsLog
 	for {
 		var msg *nats.Msg
 		if msg, err = sub.NextMsgWithContext(ctx); err != nil {
 			break
 		}
 
+		var log natsLog
 		if err = json.Unmarshal(msg.Data, &log); err != nil {
 			err = fmt.Errorf("failed parsing log: %w", err)
 
 			break
 		}
 
-		out <- LogEntry{
+		entry := LogEntry{
 			Instance:  log.Fly.App.Instance,
 			Level:     log.Log.Level,
 			Message:   log.Message,
@@ -120,6 +120,11 @@ func fromNats(ctx context.Context, out chan<- LogEntry, nc *nats.Conn, opts *Log
 				Event:    struct{ Provider string }{log.Event.Provider},
 			},
 		}
+		entry.Meta.HTTP.Response.StatusCode = log.HTTP.Response.StatusCode
+
+		if opts.Filter.Match(entry) {
+			out <- entry
+		}
 	}
 
 	return
@@ -70,6 +70,11 @@ func Poll(ctx context.Context, out chan<- LogEntry, client WebClient, opts *LogO
 
 		errorCount = 0
 		if len(entries) == 0 {
+			// Nothing newer than the end of the time window can show up anymore.
+			if opts.Filter.after(time.Now()) {
+				return nil
+			}
+
 			waitFor = backoff(minWait, maxWait)
 
 			continue
@@ -81,14 +86,23 @@ func Poll(ctx context.Context, out chan<- LogEntry, client WebClient, opts *LogO
 			nextToken = token
 		}
 
-		for _, entry := range entries {
-			out <- LogEntry{
-				Instance:  entry.Instance,
-				Level:     entry.Level,
-				Message:   entry.Message,
-				Region:    entry.Region,
-				Timestamp: entry.Timestamp,
-				Meta:      entry.Meta,
+		for _, e := range entries {
+			entry := LogEntry{
+				Instance:  e.Instance,
+				Level:     e.Level,
+				Message:   e.Message,
+				Region:    e.Region,
+				Timestamp: e.Timestamp,
+				Meta:      e.Meta,
+			}
+
+			// Entries are returned in order, so the rest are past the time window too.
+			if ts, err := entry.Time(); err == nil && opts.Filter.after(ts) {
+				return nil
+			}
+
+			if opts.Filter.Match(entry) {
+				out <- entry
 			}
 		}
 
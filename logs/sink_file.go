@@ -0,0 +1,198 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/go-units"
+)
+
+const (
+	defaultFileSinkMaxSize  = 100 * units.MB
+	defaultFileSinkMaxFiles = 5
+)
+
+// fileSink appends entries to a file as JSON lines. Once the file grows past
+// maxSize it's rotated to path.1, path.1 to path.2 and so on, keeping at most
+// maxFiles rotated files.
+type fileSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+	// rest is the end of the line of an entry that was only partly written,
+	// which is written before the next entries.
+	rest []byte
+}
+
+func newFileSink(u *url.URL) (*fileSink, error) {
+	path := u.Path
+	if u.Host != "" {
+		// file://logs/app.log is a relative path.
+		path = filepath.Join(u.Host, u.Path)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("file sink %s has no path", u)
+	}
+
+	s := &fileSink{
+		path:     path,
+		maxSize:  defaultFileSinkMaxSize,
+		maxFiles: defaultFileSinkMaxFiles,
+	}
+
+	query := u.Query()
+	if v := query.Get("max_size"); v != "" {
+		size, err := units.FromHumanSize(v)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid max_size %q for file sink", v)
+		}
+		s.maxSize = size
+	}
+	if v := query.Get("max_files"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid max_files %q for file sink", v)
+		}
+		s.maxFiles = n
+	}
+
+	return s, nil
+}
+
+func (s *fileSink) Write(_ context.Context, entries []LogEntry) error {
+	var (
+		buf  bytes.Buffer
+		ends = make([]int, 0, len(entries))
+	)
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return &permanentError{err}
+		}
+		ends = append(ends, buf.Len())
+	}
+
+	if err := s.open(); err != nil {
+		return err
+	}
+	if len(s.rest) > 0 {
+		n, err := s.file.Write(s.rest)
+		s.size += int64(n)
+		s.rest = s.rest[n:]
+		if err != nil {
+			s.Close()
+
+			return err
+		}
+	}
+	if s.size > 0 && s.size+int64(buf.Len()) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(buf.Bytes())
+	s.size += int64(n)
+	if err != nil {
+		s.Close()
+
+		written, partly := writtenEntries(ends, n)
+		if partly {
+			s.rest = bytes.Clone(buf.Bytes()[n:ends[written]])
+			written++
+		}
+		if written == 0 {
+			return err
+		}
+
+		return &partialWriteError{n: written, err: err}
+	}
+
+	return nil
+}
+
+// writtenEntries returns how many entries were written in full when n bytes of
+// the entries ending at ends were, and whether the next one was partly written.
+func writtenEntries(ends []int, n int) (written int, partly bool) {
+	for written < len(ends) && ends[written] <= n {
+		written++
+	}
+
+	start := 0
+	if written > 0 {
+		start = ends[written-1]
+	}
+
+	return written, written < len(ends) && n > start
+}
+
+func (s *fileSink) open() error {
+	if s.file != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return err
+	}
+
+	s.file, s.size = file, info.Size()
+
+	return nil
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+
+	if s.maxFiles == 0 {
+		return os.Remove(s.path)
+	}
+
+	for i := s.maxFiles - 1; i > 0; i-- {
+		err := os.Rename(s.rotatedPath(i), s.rotatedPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Rename(s.path, s.rotatedPath(1))
+}
+
+func (s *fileSink) rotatedPath(n int) string {
+	return s.path + "." + strconv.Itoa(n)
+}
+
+func (s *fileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
@@ -0,0 +1,113 @@
+package logs
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lokiSink pushes entries to Loki's push API, as one stream per combination of
+// app, region, instance, level and provider. Credentials in the URL are sent
+// with basic auth, and a tenant query parameter sets the X-Scope-OrgID header.
+type lokiSink struct {
+	endpoint string
+	header   http.Header
+	client   *http.Client
+	appName  string
+}
+
+func newLokiSink(u *url.URL, transport, appName string) (*lokiSink, error) {
+	scheme, err := httpScheme(transport)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki sink: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("loki sink %s has no host", u)
+	}
+
+	header := http.Header{}
+	if tenant := u.Query().Get("tenant"); tenant != "" {
+		header.Set("X-Scope-OrgID", tenant)
+	}
+
+	endpoint := &url.URL{
+		Scheme: scheme,
+		Host:   u.Host,
+		Path:   strings.TrimSuffix(u.Path, "/") + "/loki/api/v1/push",
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+		header.Set("Authorization", "Basic "+credentials)
+	}
+
+	return &lokiSink{
+		endpoint: endpoint.String(),
+		header:   header,
+		client:   &http.Client{Timeout: sinkHTTPTimeout},
+		appName:  appName,
+	}, nil
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+func (s *lokiSink) Write(ctx context.Context, entries []LogEntry) error {
+	return postJSON(ctx, s.client, s.endpoint, s.header, newLokiPushRequest(entries, s.appName, time.Now()))
+}
+
+func newLokiPushRequest(entries []LogEntry, appName string, now time.Time) *lokiPushRequest {
+	streams := map[string]*lokiStream{}
+	for _, entry := range entries {
+		labels := map[string]string{
+			"app":      cmp.Or(entry.App, appName),
+			"region":   entry.Region,
+			"instance": entry.Instance,
+			"level":    strings.ToLower(entry.Level),
+			"provider": entry.Meta.Event.Provider,
+		}
+		maps.DeleteFunc(labels, func(_, v string) bool {
+			return v == ""
+		})
+
+		key := fmt.Sprint(labels)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+		}
+
+		ts, err := entry.Time()
+		if err != nil {
+			ts = now
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(ts.UnixNano(), 10), entry.Message})
+	}
+
+	req := &lokiPushRequest{}
+	for _, key := range slices.Sorted(maps.Keys(streams)) {
+		req.Streams = append(req.Streams, streams[key])
+	}
+
+	return req
+}
+
+func (s *lokiSink) Close() error {
+	s.client.CloseIdleConnections()
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package logs
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/internal/buildinfo"
+)
+
+// otlpSeverityNumber maps log levels to OpenTelemetry severity numbers.
+var otlpSeverityNumber = map[string]int{
+	"trace":    1,
+	"debug":    5,
+	"info":     9,
+	"notice":   10,
+	"warn":     13,
+	"warning":  13,
+	"error":    17,
+	"err":      17,
+	"fatal":    21,
+	"critical": 21,
+	"crit":     21,
+	"panic":    24,
+}
+
+// otlpSink exports entries to an OTLP/HTTP collector using the JSON encoding.
+// Headers are read from OTEL_EXPORTER_OTLP_HEADERS, like other OpenTelemetry
+// exporters do.
+type otlpSink struct {
+	endpoint string
+	header   http.Header
+	client   *http.Client
+	appName  string
+}
+
+func newOTLPSink(u *url.URL, transport, appName string) (*otlpSink, error) {
+	scheme, err := httpScheme(transport)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otlp sink: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("otlp sink %s has no host", u)
+	}
+
+	header, err := parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &url.URL{
+		Scheme: scheme,
+		Host:   u.Host,
+		Path:   strings.TrimSuffix(u.Path, "/") + "/v1/logs",
+	}
+
+	return &otlpSink{
+		endpoint: endpoint.String(),
+		header:   header,
+		client:   &http.Client{Timeout: sinkHTTPTimeout},
+		appName:  appName,
+	}, nil
+}
+
+// parseOTLPHeaders parses headers in the key1=value1,key2=value2 format of
+// OTEL_EXPORTER_OTLP_HEADERS, with URL encoded values.
+func parseOTLPHeaders(value string) (http.Header, error) {
+	header := http.Header{}
+	for pair := range strings.SplitSeq(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS entry %q, expected key=value", pair)
+		}
+		v, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS value for %s: %w", k, err)
+		}
+		header.Set(strings.TrimSpace(k), v)
+	}
+
+	return header, nil
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber,omitempty"`
+	SeverityText         string         `json:"severityText,omitempty"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+func (s *otlpSink) Write(ctx context.Context, entries []LogEntry) error {
+	return postJSON(ctx, s.client, s.endpoint, s.header, newOTLPLogsRequest(entries, s.appName, time.Now()))
+}
+
+func newOTLPLogsRequest(entries []LogEntry, appName string, now time.Time) *otlpLogsRequest {
+	attr := func(key, value string) otlpKeyValue {
+		return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: value}}
+	}
+
+	// Every app is a resource of its own.
+	var (
+		req       = &otlpLogsRequest{}
+		resources = map[string]*otlpScopeLogs{}
+	)
+	for _, entry := range entries {
+		app := cmp.Or(entry.App, appName)
+		scope, ok := resources[app]
+		if !ok {
+			req.ResourceLogs = append(req.ResourceLogs, otlpResourceLogs{
+				Resource: otlpResource{
+					Attributes: []otlpKeyValue{attr("service.name", app)},
+				},
+				ScopeLogs: []otlpScopeLogs{{
+					Scope: otlpScope{Name: "flyctl", Version: buildinfo.Version().String()},
+				}},
+			})
+			scope = &req.ResourceLogs[len(req.ResourceLogs)-1].ScopeLogs[0]
+			resources[app] = scope
+		}
+
+		ts, err := entry.Time()
+		if err != nil {
+			ts = now
+		}
+
+		record := otlpLogRecord{
+			TimeUnixNano:         strconv.FormatInt(ts.UnixNano(), 10),
+			ObservedTimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+			SeverityNumber:       otlpSeverityNumber[strings.ToLower(entry.Level)],
+			SeverityText:         entry.Level,
+			Body:                 otlpAnyValue{StringValue: entry.Message},
+		}
+		if entry.Region != "" {
+			record.Attributes = append(record.Attributes, attr("cloud.region", entry.Region))
+		}
+		if entry.Instance != "" {
+			record.Attributes = append(record.Attributes, attr("fly.machine.id", entry.Instance))
+		}
+		if entry.Meta.Event.Provider != "" {
+			record.Attributes = append(record.Attributes, attr("fly.event.provider", entry.Meta.Event.Provider))
+		}
+		if code := entry.Meta.HTTP.Response.StatusCode; code != 0 {
+			record.Attributes = append(record.Attributes, attr("http.response.status_code", strconv.Itoa(code)))
+		}
+		scope.LogRecords = append(scope.LogRecords, record)
+	}
+
+	return req
+}
+
+func (s *otlpSink) Close() error {
+	s.client.CloseIdleConnections()
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package logs
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	syslogFacilityUser = 1
+	syslogDialTimeout  = 10 * time.Second
+)
+
+// syslogSeverity maps log levels to RFC 5424 severities.
+var syslogSeverity = map[string]int{
+	"panic":    0,
+	"fatal":    2,
+	"critical": 2,
+	"crit":     2,
+	"error":    3,
+	"err":      3,
+	"warn":     4,
+	"warning":  4,
+	"notice":   5,
+	"info":     6,
+	"debug":    7,
+	"trace":    7,
+}
+
+// syslogSink sends entries as RFC 5424 messages. Over TCP and TLS, messages
+// are framed with octet counting as described in RFC 6587.
+type syslogSink struct {
+	network string
+	address string
+	tls     bool
+	appName string
+
+	conn net.Conn
+}
+
+func newSyslogSink(u *url.URL, transport, appName string) (*syslogSink, error) {
+	s := &syslogSink{appName: appName}
+
+	switch transport {
+	case "", "udp":
+		s.network = "udp"
+	case "tcp":
+		s.network = "tcp"
+	case "tls":
+		s.network, s.tls = "tcp", true
+	default:
+		return nil, fmt.Errorf("unsupported syslog transport %q, expected udp, tcp or tls", transport)
+	}
+
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("syslog sink %s has no host", u)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "514"
+		if s.tls {
+			port = "6514"
+		}
+	}
+	s.address = net.JoinHostPort(u.Hostname(), port)
+
+	return s, nil
+}
+
+func (s *syslogSink) Write(ctx context.Context, entries []LogEntry) error {
+	if err := s.dial(ctx); err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(deadline)
+	} else {
+		s.conn.SetWriteDeadline(time.Time{})
+	}
+
+	for i, entry := range entries {
+		msg := formatSyslogMessage(entry, s.appName)
+		if s.network == "tcp" {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+
+		if _, err := s.conn.Write([]byte(msg)); err != nil {
+			// Reconnect on the next write. Receivers drop the message that
+			// was cut short with the connection, so it's sent again.
+			s.Close()
+
+			return &partialWriteError{n: i, err: fmt.Errorf("failed to send logs to syslog at %s: %w", s.address, err)}
+		}
+	}
+
+	return nil
+}
+
+func (s *syslogSink) dial(ctx context.Context) (err error) {
+	if s.conn != nil {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: syslogDialTimeout}
+	if s.tls {
+		tlsDialer := &tls.Dialer{NetDialer: dialer}
+		s.conn, err = tlsDialer.DialContext(ctx, s.network, s.address)
+	} else {
+		s.conn, err = dialer.DialContext(ctx, s.network, s.address)
+	}
+	if err != nil {
+		s.conn = nil
+
+		return fmt.Errorf("failed to connect to syslog at %s: %w", s.address, err)
+	}
+
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// formatSyslogMessage formats entry as an RFC 5424 message, using the machine
+// ID as the hostname and the region as the message ID.
+func formatSyslogMessage(entry LogEntry, appName string) string {
+	severity, ok := syslogSeverity[strings.ToLower(entry.Level)]
+	if !ok {
+		severity = syslogSeverity["info"]
+	}
+
+	timestamp := "-"
+	if ts, err := entry.Time(); err == nil {
+		timestamp = ts.UTC().Format(time.RFC3339Nano)
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s",
+		syslogFacilityUser*8+severity,
+		timestamp,
+		syslogHeaderField(entry.Instance, 255),
+		syslogHeaderField(cmp.Or(entry.App, appName), 48),
+		syslogHeaderField(entry.Region, 32),
+		entry.Message,
+	)
+}
+
+// syslogHeaderField returns value as a valid RFC 5424 header field: printable
+// ASCII without spaces, at most maxLen long, or "-" when empty.
+func syslogHeaderField(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+
+		return r
+	}, value)
+
+	switch {
+	case value == "":
+		return "-"
+	case len(value) > maxLen:
+		return value[:maxLen]
+	default:
+		return value
+	}
+}
@@ -0,0 +1,423 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEntries = []LogEntry{
+	{Level: "info", Instance: "m1", Region: "iad", Message: "hello", Timestamp: "2026-01-02T10:00:00.5Z"},
+	{Level: "error", Instance: "m2", Region: "syd", Message: "boom", Timestamp: "2026-01-02T10:00:01Z"},
+}
+
+func TestNewSink(t *testing.T) {
+	for _, rawURL := range []string{
+		"file:///tmp/app.log",
+		"syslog://localhost",
+		"syslog+tcp://localhost:601",
+		"syslog+tls://localhost",
+		"loki+http://localhost:3100",
+		"otlp+https://collector.example.com",
+	} {
+		sink, err := NewSink(rawURL, "app")
+		require.NoError(t, err, rawURL)
+		require.NoError(t, sink.Close())
+	}
+
+	for _, rawURL := range []string{
+		"stdout://",
+		"syslog+quic://localhost",
+		"loki+ftp://localhost",
+		"loki+http://",
+		"file:///tmp/app.log?max_size=lots",
+	} {
+		_, err := NewSink(rawURL, "app")
+		assert.Error(t, err, rawURL)
+	}
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	sink, err := NewSink("file://"+path+"?max_size=200&max_files=2", "app")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	ctx := context.Background()
+	for range 4 {
+		require.NoError(t, sink.Write(ctx, testEntries[:1]))
+	}
+
+	var lines int
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		data, err := os.ReadFile(p)
+		require.NoError(t, err, p)
+		for line := range strings.Lines(string(data)) {
+			var entry LogEntry
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			assert.Equal(t, "hello", entry.Message)
+			lines++
+		}
+	}
+	assert.Equal(t, 3, lines, "the oldest rotated file should have been dropped")
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestSyslogSink(t *testing.T) {
+	t.Run("tcp with octet counting", func(t *testing.T) {
+		addr, messages := syslogTCPServer(t)
+
+		sink, err := NewSink("syslog+tcp://"+addr, "my-app")
+		require.NoError(t, err)
+		defer sink.Close()
+
+		require.NoError(t, sink.Write(context.Background(), testEntries))
+
+		assertSyslogMessage(t, messages, "<14>1 2026-01-02T10:00:00.5Z m1 my-app - iad - hello")
+		assertSyslogMessage(t, messages, "<11>1 2026-01-02T10:00:01Z m2 my-app - syd - boom")
+	})
+
+	t.Run("udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		sink, err := NewSink("syslog://"+conn.LocalAddr().String(), "my-app")
+		require.NoError(t, err)
+		defer sink.Close()
+
+		require.NoError(t, sink.Write(context.Background(), testEntries[1:]))
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		data := make([]byte, 4096)
+		n, _, err := conn.ReadFrom(data)
+		require.NoError(t, err)
+		assert.Equal(t, "<11>1 2026-01-02T10:00:01Z m2 my-app - syd - boom", string(data[:n]))
+	})
+
+	t.Run("reconnects after the connection breaks", func(t *testing.T) {
+		addr, messages := syslogTCPServer(t)
+
+		s, err := NewSink("syslog+tcp://"+addr, "my-app")
+		require.NoError(t, err)
+		sink := s.(*syslogSink)
+		defer sink.Close()
+
+		require.NoError(t, sink.Write(context.Background(), testEntries[:1]))
+		assertSyslogMessage(t, messages, "<14>1 2026-01-02T10:00:00.5Z m1 my-app - iad - hello")
+
+		// Writes fail once the connection is gone, and the next one dials again.
+		sink.conn.Close()
+		assert.Error(t, sink.Write(context.Background(), testEntries[1:]))
+		require.NoError(t, sink.Write(context.Background(), testEntries[1:]))
+		assertSyslogMessage(t, messages, "<11>1 2026-01-02T10:00:01Z m2 my-app - syd - boom")
+	})
+}
+
+// syslogTCPServer accepts connections and reads RFC 6587 octet counted
+// messages from them.
+func syslogTCPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+
+				r := bufio.NewReader(conn)
+				for {
+					length, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, err := strconv.Atoi(strings.TrimSpace(length))
+					if err != nil {
+						return
+					}
+					msg := make([]byte, n)
+					if _, err := io.ReadFull(r, msg); err != nil {
+						return
+					}
+					messages <- string(msg)
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String(), messages
+}
+
+func assertSyslogMessage(t *testing.T, messages <-chan string, want string) {
+	t.Helper()
+
+	select {
+	case got := <-messages:
+		assert.Equal(t, want, got)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%q wasn't received", want)
+	}
+}
+
+func TestLokiSink(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got lokiPushRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		assert.Equal(t, "tenant-1", r.Header.Get("X-Scope-OrgID"))
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "token", password)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := NewSink("loki+http://user:token@"+strings.TrimPrefix(server.URL, "http://")+"?tenant=tenant-1", "my-app")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(context.Background(), testEntries))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, got.Streams, 2)
+	assert.Equal(t, map[string]string{"app": "my-app", "instance": "m1", "level": "info", "region": "iad"}, got.Streams[0].Stream)
+	assert.Equal(t, [][2]string{{"1767348000500000000", "hello"}}, got.Streams[0].Values)
+	assert.Equal(t, map[string]string{"app": "my-app", "instance": "m2", "level": "error", "region": "syd"}, got.Streams[1].Stream)
+	assert.Equal(t, [][2]string{{"1767348001000000000", "boom"}}, got.Streams[1].Values)
+}
+
+func TestOTLPSink(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-api-key=secret%20key")
+
+	var (
+		mu  sync.Mutex
+		got otlpLogsRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "secret key", r.Header.Get("x-api-key"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	sink, err := NewSink("otlp+http://"+strings.TrimPrefix(server.URL, "http://"), "my-app")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Write(context.Background(), testEntries))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, got.ResourceLogs, 1)
+	assert.Equal(t, []otlpKeyValue{{"service.name", otlpAnyValue{"my-app"}}}, got.ResourceLogs[0].Resource.Attributes)
+	records := got.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 2)
+	assert.Equal(t, "1767348000500000000", records[0].TimeUnixNano)
+	assert.Equal(t, 9, records[0].SeverityNumber)
+	assert.Equal(t, "hello", records[0].Body.StringValue)
+	assert.Equal(t, 17, records[1].SeverityNumber)
+	assert.Contains(t, records[1].Attributes, otlpKeyValue{"fly.machine.id", otlpAnyValue{"m2"}})
+}
+
+func TestPostJSONErrors(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	err := postJSON(context.Background(), server.Client(), server.URL, nil, map[string]string{})
+	require.Error(t, err)
+	assert.False(t, errors.As(err, new(*permanentError)), "5xx responses should be retried")
+
+	status = http.StatusBadRequest
+	err = postJSON(context.Background(), server.Client(), server.URL, nil, map[string]string{})
+	assert.True(t, errors.As(err, new(*permanentError)), "4xx responses shouldn't be retried")
+}
+
+// recordingSink fails its first failures writes, then records what it's sent.
+type recordingSink struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	batches  [][]LogEntry
+	closed   bool
+}
+
+func (s *recordingSink) Write(_ context.Context, entries []LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+
+		return s.err
+	}
+	s.batches = append(s.batches, append([]LogEntry(nil), entries...))
+
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	return nil
+}
+
+func (s *recordingSink) entries() (n int) {
+	for _, batch := range s.batches {
+		n += len(batch)
+	}
+
+	return n
+}
+
+// partialSink delivers the first entry of the batches it's sent, failing when
+// there are more, like a sink losing its connection.
+type partialSink struct {
+	entries []LogEntry
+}
+
+func (s *partialSink) Write(_ context.Context, entries []LogEntry) error {
+	s.entries = append(s.entries, entries[0])
+	if len(entries) > 1 {
+		return &partialWriteError{n: 1, err: errors.New("connection reset")}
+	}
+
+	return nil
+}
+
+func (s *partialSink) Close() error {
+	return nil
+}
+
+func TestWriteBatchRetriesUndelivered(t *testing.T) {
+	sink := &partialSink{}
+	batch := []LogEntry{testEntries[0], testEntries[1], testEntries[0]}
+
+	writeBatch(context.Background(), sink, batch, SinkOptions{MaxBackoff: time.Millisecond, OnError: func(error) {}})
+	assert.Equal(t, batch, sink.entries)
+}
+
+func TestWrittenEntries(t *testing.T) {
+	ends := []int{10, 20, 30}
+
+	for _, tc := range []struct {
+		n       int
+		written int
+		partly  bool
+	}{
+		{0, 0, false},
+		{5, 0, true},
+		{10, 1, false},
+		{25, 2, true},
+		{30, 3, false},
+	} {
+		written, partly := writtenEntries(ends, tc.n)
+		assert.Equal(t, tc.written, written, "n=%d", tc.n)
+		assert.Equal(t, tc.partly, partly, "n=%d", tc.n)
+	}
+}
+
+func TestForwardToSinks(t *testing.T) {
+	t.Run("batches entries and retries failed writes", func(t *testing.T) {
+		var (
+			flaky  = &recordingSink{failures: 2, err: errors.New("connection refused")}
+			steady = &recordingSink{}
+			errs   []error
+		)
+
+		in := make(chan LogEntry)
+		go func() {
+			defer close(in)
+			for range 5 {
+				in <- testEntries[0]
+			}
+		}()
+
+		err := ForwardToSinks(context.Background(), in, []Sink{flaky, steady}, SinkOptions{
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+			OnError:       func(err error) { errs = append(errs, err) },
+		})
+		require.NoError(t, err)
+
+		for _, sink := range []*recordingSink{flaky, steady} {
+			assert.True(t, sink.closed)
+			assert.Equal(t, 5, sink.entries())
+			assert.Len(t, sink.batches, 3)
+		}
+		assert.Len(t, errs, 2)
+	})
+
+	t.Run("drops batches that fail permanently", func(t *testing.T) {
+		sink := &recordingSink{failures: 1, err: &permanentError{errors.New("bad request")}}
+
+		in := make(chan LogEntry, 2)
+		in <- testEntries[0]
+		in <- testEntries[1]
+		close(in)
+
+		err := ForwardToSinks(context.Background(), in, []Sink{sink}, SinkOptions{BatchSize: 1, FlushInterval: time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, [][]LogEntry{testEntries[1:]}, sink.batches)
+	})
+
+	t.Run("flushes on an interval", func(t *testing.T) {
+		sink := &recordingSink{}
+		in := make(chan LogEntry)
+		done := make(chan error)
+		go func() {
+			done <- ForwardToSinks(context.Background(), in, []Sink{sink}, SinkOptions{FlushInterval: 10 * time.Millisecond})
+		}()
+
+		in <- testEntries[0]
+		assert.Eventually(t, func() bool {
+			sink.mu.Lock()
+			defer sink.mu.Unlock()
+
+			return sink.entries() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		close(in)
+		require.NoError(t, <-done)
+	})
+}
@@ -0,0 +1,388 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// Scanner plugins are external scanners, executables or WASM modules, that
+// detect frameworks the built-in scanners don't know about.
+//
+// A plugin is run with the source directory as its only argument and a
+// PluginRequest as JSON on stdin. It writes a PluginSourceInfo as JSON to
+// stdout when it detects the source, or nothing (or null) when it doesn't. A
+// configured plugin exiting with a non-zero status fails the scan, while one
+// discovered on PATH is skipped with a warning.
+//
+// WASM modules are run by a WASI runtime with the wasmtime command line
+// interface, wasmtime unless FLY_SCANNER_WASM_RUNTIME is set, with the source
+// directory mounted at /source.
+
+const (
+	// PluginProtocolVersion is the version of the scanner plugin protocol.
+	PluginProtocolVersion = 1
+
+	// PluginExecutablePrefix prefixes the names of the executables on PATH
+	// run as scanner plugins.
+	PluginExecutablePrefix = "fly-scanner-"
+
+	// PluginOrderFirst runs a plugin before the built-in scanners.
+	PluginOrderFirst = "first"
+	// PluginOrderLast runs a plugin after the built-in scanners.
+	PluginOrderLast = "last"
+
+	wasmRuntimeEnvKey    = "FLY_SCANNER_WASM_RUNTIME"
+	wasmSourceDir        = "/source"
+	pluginScanTimeout    = time.Minute
+	pluginProtocolEnvKey = "FLY_SCANNER_PROTOCOL_VERSION"
+)
+
+// Plugin is an external scanner.
+type Plugin struct {
+	Name string
+	// Path is the path of the executable or WASM module of the plugin.
+	Path string
+	// Order is when the plugin runs relative to the built-in scanners:
+	// first (the default), last, before:<scanner> or after:<scanner>.
+	Order string
+	// Discovered plugins were found on PATH rather than configured, and only
+	// warn when they fail, so that a broken one doesn't fail every launch.
+	Discovered bool
+}
+
+// PluginRequest is what plugins are asked to scan.
+type PluginRequest struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	SourceDir       string `json:"source_dir"`
+	Mode            string `json:"mode"`
+	ExistingPort    int    `json:"existing_port,omitempty"`
+}
+
+// PluginSourceInfo is the source info a plugin detects, the part of
+// SourceInfo that can be described in JSON. Paths are relative to the source
+// directory.
+type PluginSourceInfo struct {
+	Family           string            `json:"family"`
+	Version          string            `json:"version,omitempty"`
+	DockerfilePath   string            `json:"dockerfile_path,omitempty"`
+	BuildArgs        map[string]string `json:"build_args,omitempty"`
+	Builder          string            `json:"builder,omitempty"`
+	Buildpacks       []string          `json:"buildpacks,omitempty"`
+	ReleaseCmd       string            `json:"release_cmd,omitempty"`
+	SeedCmd          string            `json:"seed_cmd,omitempty"`
+	DockerCommand    string            `json:"docker_command,omitempty"`
+	DockerEntrypoint string            `json:"docker_entrypoint,omitempty"`
+	KillSignal       string            `json:"kill_signal,omitempty"`
+	SwapSizeMB       int               `json:"swap_size_mb,omitempty"`
+	Port             int               `json:"port,omitempty"`
+	Env              map[string]string `json:"env,omitempty"`
+	Processes        map[string]string `json:"processes,omitempty"`
+	Statics          []Static          `json:"statics,omitempty"`
+	Volumes          []Volume          `json:"volumes,omitempty"`
+	Secrets          []PluginSecret    `json:"secrets,omitempty"`
+	Files            []PluginFile      `json:"files,omitempty"`
+	Notice           string            `json:"notice,omitempty"`
+	DeployDocs       string            `json:"deploy_docs,omitempty"`
+	SkipDeploy       bool              `json:"skip_deploy,omitempty"`
+	SkipDatabase     bool              `json:"skip_database,omitempty"`
+	// Database is the database the app wants: postgres, mysql or sqlite.
+	Database       string         `json:"database,omitempty"`
+	Redis          bool           `json:"redis,omitempty"`
+	ObjectStorage  bool           `json:"object_storage,omitempty"`
+	HttpCheckPath  string         `json:"http_check_path,omitempty"`
+	ConsoleCommand string         `json:"console_command,omitempty"`
+	Concurrency    map[string]int `json:"concurrency,omitempty"`
+}
+
+// PluginSecret is a secret of the app a plugin detects. Secrets without a
+// value that aren't generated are prompted for.
+type PluginSecret struct {
+	Key      string `json:"key"`
+	Help     string `json:"help,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Generate bool   `json:"generate,omitempty"`
+}
+
+// PluginFile is a file written into the source directory.
+type PluginFile struct {
+	Path     string `json:"path"`
+	Contents string `json:"contents"`
+}
+
+type namedScanner struct {
+	name string
+	scan sourceScanner
+}
+
+// orderScanners inserts the plugins into the built-in scanners in the order
+// they're configured with. Plugins in the same position keep their order.
+func orderScanners(builtins []namedScanner, plugins []Plugin) ([]namedScanner, error) {
+	var (
+		first, last   []namedScanner
+		before, after = map[string][]namedScanner{}, map[string][]namedScanner{}
+	)
+
+	for _, p := range plugins {
+		s := namedScanner{name: p.Name, scan: p.scan}
+
+		position, target, _ := strings.Cut(p.Order, ":")
+		if (position == "before" || position == "after") && !slices.ContainsFunc(builtins, func(b namedScanner) bool { return b.name == target }) {
+			return nil, fmt.Errorf("scanner plugin %s is ordered %s unknown scanner %q", p.Name, position, target)
+		}
+
+		switch position {
+		case "", PluginOrderFirst:
+			first = append(first, s)
+		case PluginOrderLast:
+			last = append(last, s)
+		case "before":
+			before[target] = append(before[target], s)
+		case "after":
+			after[target] = append(after[target], s)
+		default:
+			return nil, fmt.Errorf("scanner plugin %s has invalid order %q, must be first, last, before:<scanner> or after:<scanner>", p.Name, p.Order)
+		}
+	}
+
+	ordered := first
+	for _, b := range builtins {
+		ordered = append(ordered, before[b.name]...)
+		ordered = append(ordered, b)
+		ordered = append(ordered, after[b.name]...)
+	}
+
+	return append(ordered, last...), nil
+}
+
+// DiscoverPlugins returns the executables on PATH named with the plugin
+// executable prefix as plugins run before the built-in scanners, ordered by
+// name. Executables earlier on PATH shadow later ones of the same name.
+func DiscoverPlugins() []Plugin {
+	seen := map[string]bool{}
+	var plugins []Plugin
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), PluginExecutablePrefix)
+			if !ok || entry.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				if name, ok = strings.CutSuffix(name, ".exe"); !ok {
+					continue
+				}
+			} else if info, err := entry.Info(); err != nil || info.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			plugins = append(plugins, Plugin{
+				Name:       name,
+				Path:       filepath.Join(dir, entry.Name()),
+				Order:      PluginOrderFirst,
+				Discovered: true,
+			})
+		}
+	}
+
+	slices.SortFunc(plugins, func(a, b Plugin) int { return strings.Compare(a.Name, b.Name) })
+
+	return plugins
+}
+
+// scan runs the plugin on sourceDir. Failures of discovered plugins are only
+// warned about.
+func (p Plugin) scan(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
+	si, err := p.run(sourceDir, config)
+	if err != nil && p.Discovered {
+		terminal.Warnf("Skipping %s: %v\n", p.Path, err)
+
+		return nil, nil
+	}
+
+	return si, err
+}
+
+func (p Plugin) run(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginScanTimeout)
+	defer cancel()
+
+	absDir, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	request := PluginRequest{
+		ProtocolVersion: PluginProtocolVersion,
+		SourceDir:       absDir,
+		Mode:            config.Mode,
+		ExistingPort:    config.ExistingPort,
+	}
+
+	var cmd *exec.Cmd
+	if strings.EqualFold(filepath.Ext(p.Path), ".wasm") {
+		request.SourceDir = wasmSourceDir
+		cmd = exec.CommandContext(ctx, wasmRuntime(), "run", "--dir", absDir+"::"+wasmSourceDir, p.Path, wasmSourceDir)
+	} else {
+		cmd = exec.CommandContext(ctx, p.Path, absDir)
+		cmd.Dir = absDir
+	}
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%d", pluginProtocolEnvKey, PluginProtocolVersion))
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("scanner plugin %s timed out after %s", p.Name, pluginScanTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("scanner plugin %s failed: %w: %s", p.Name, err, msg)
+		}
+
+		return nil, fmt.Errorf("scanner plugin %s failed: %w", p.Name, err)
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	if len(output) == 0 || bytes.Equal(output, []byte("null")) {
+		return nil, nil
+	}
+
+	var info PluginSourceInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("scanner plugin %s returned invalid source info: %w", p.Name, err)
+	}
+
+	si, err := info.sourceInfo(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("scanner plugin %s returned invalid source info: %w", p.Name, err)
+	}
+
+	return si, nil
+}
+
+// wasmRuntime returns the WASI runtime WASM plugins are run with.
+func wasmRuntime() string {
+	if r := os.Getenv(wasmRuntimeEnvKey); r != "" {
+		return r
+	}
+
+	return "wasmtime"
+}
+
+// sourceInfo returns the source info the plugin detected in sourceDir.
+func (info PluginSourceInfo) sourceInfo(sourceDir string) (*SourceInfo, error) {
+	if info.Family == "" {
+		return nil, errors.New("family is required")
+	}
+
+	si := &SourceInfo{
+		Family:               info.Family,
+		Version:              info.Version,
+		BuildArgs:            info.BuildArgs,
+		Builder:              info.Builder,
+		Buildpacks:           info.Buildpacks,
+		ReleaseCmd:           info.ReleaseCmd,
+		SeedCmd:              info.SeedCmd,
+		DockerCommand:        info.DockerCommand,
+		DockerEntrypoint:     info.DockerEntrypoint,
+		KillSignal:           info.KillSignal,
+		SwapSizeMB:           info.SwapSizeMB,
+		Port:                 info.Port,
+		Env:                  info.Env,
+		Processes:            info.Processes,
+		Statics:              info.Statics,
+		Volumes:              info.Volumes,
+		Notice:               info.Notice,
+		DeployDocs:           info.DeployDocs,
+		SkipDeploy:           info.SkipDeploy,
+		SkipDatabase:         info.SkipDatabase,
+		RedisDesired:         info.Redis,
+		ObjectStorageDesired: info.ObjectStorage,
+		HttpCheckPath:        info.HttpCheckPath,
+		ConsoleCommand:       info.ConsoleCommand,
+		Concurrency:          info.Concurrency,
+	}
+
+	switch info.Database {
+	case "":
+	case "postgres":
+		si.DatabaseDesired = DatabaseKindPostgres
+	case "mysql":
+		si.DatabaseDesired = DatabaseKindMySQL
+	case "sqlite":
+		si.DatabaseDesired = DatabaseKindSqlite
+	default:
+		return nil, fmt.Errorf("unknown database %q, must be postgres, mysql or sqlite", info.Database)
+	}
+
+	if info.DockerfilePath != "" {
+		path, err := sourcePath(sourceDir, info.DockerfilePath)
+		if err != nil {
+			return nil, err
+		}
+		si.DockerfilePath = path
+	}
+
+	for _, f := range info.Files {
+		if _, err := sourcePath(sourceDir, f.Path); err != nil {
+			return nil, err
+		}
+		si.Files = append(si.Files, SourceFile{Path: filepath.FromSlash(f.Path), Contents: []byte(f.Contents)})
+	}
+
+	for _, s := range info.Secrets {
+		if s.Key == "" {
+			return nil, errors.New("secrets require a key")
+		}
+
+		secret := Secret{Key: s.Key, Help: s.Help, Value: s.Value}
+		if s.Generate {
+			secret.Generate = func() (string, error) {
+				return helpers.RandString(64)
+			}
+		}
+		si.Secrets = append(si.Secrets, secret)
+	}
+
+	return si, nil
+}
+
+// sourcePath returns the path within sourceDir a plugin refers to with path,
+// which has to be relative and stay within sourceDir.
+func sourcePath(sourceDir, path string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("path %q isn't within the source directory", path)
+	}
+
+	return filepath.Join(sourceDir, filepath.FromSlash(path)), nil
+}
@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("scanner plugin tests use shell scripts")
+	}
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755))
+
+	return path
+}
+
+func scannerNames(scanners []namedScanner) []string {
+	var names []string
+	for _, s := range scanners {
+		names = append(names, s.name)
+	}
+
+	return names
+}
+
+func TestOrderScanners(t *testing.T) {
+	builtins := []namedScanner{{name: "rails"}, {name: "dockerfile"}, {name: "go"}}
+
+	scanners, err := orderScanners(builtins, []Plugin{
+		{Name: "monorepo"},
+		{Name: "fallback", Order: "last"},
+		{Name: "acme", Order: "before:dockerfile"},
+		{Name: "acme-go", Order: "after:go"},
+		{Name: "internal", Order: "first"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"monorepo", "internal", "rails", "acme", "dockerfile", "go", "acme-go", "fallback"}, scannerNames(scanners))
+
+	_, err = orderScanners(builtins, []Plugin{{Name: "acme", Order: "before:cobol"}})
+	assert.ErrorContains(t, err, `ordered before unknown scanner "cobol"`)
+
+	_, err = orderScanners(builtins, []Plugin{{Name: "acme", Order: "middle"}})
+	assert.ErrorContains(t, err, `invalid order "middle"`)
+}
+
+func TestScanPlugin(t *testing.T) {
+	t.Setenv("OPT_OUT_GITHUB_ACTIONS", "1")
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "acme.yaml"), nil, 0o644))
+
+	pluginDir := t.TempDir()
+	skip := writePlugin(t, pluginDir, "skip", "cat >/dev/null\n")
+	acme := writePlugin(t, pluginDir, "acme", `
+request=$(cat)
+case "$request" in
+  *'"protocol_version":1'*) ;;
+  *) exit 1 ;;
+esac
+[ -f "$1/acme.yaml" ] || exit 0
+cat <<EOF
+{
+  "family": "Acme",
+  "dockerfile_path": "build/Dockerfile",
+  "port": 4000,
+  "database": "postgres",
+  "secrets": [{"key": "ACME_KEY", "generate": true}],
+  "files": [{"path": ".dockerignore", "contents": "node_modules\n"}]
+}
+EOF
+`)
+
+	si, err := Scan(sourceDir, &ScannerConfig{
+		Mode:    "launch",
+		Plugins: []Plugin{{Name: "skip", Path: skip}, {Name: "acme", Path: acme}},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, si)
+	assert.Equal(t, "Acme", si.Family)
+	assert.Equal(t, filepath.Join(sourceDir, "build", "Dockerfile"), si.DockerfilePath)
+	assert.Equal(t, 4000, si.Port)
+	assert.Equal(t, DatabaseKindPostgres, si.DatabaseDesired)
+	require.Len(t, si.Secrets, 1)
+	assert.NotNil(t, si.Secrets[0].Generate)
+	assert.Equal(t, []SourceFile{{Path: ".dockerignore", Contents: []byte("node_modules\n")}}, si.Files)
+}
+
+func TestScanPluginErrors(t *testing.T) {
+	sourceDir := t.TempDir()
+	pluginDir := t.TempDir()
+
+	for name, tc := range map[string]struct {
+		script  string
+		wantErr string
+	}{
+		"failure":        {script: "echo 'config missing' >&2\nexit 3\n", wantErr: "scanner plugin broken failed: exit status 3: config missing"},
+		"invalid json":   {script: "echo '{'\n", wantErr: "returned invalid source info"},
+		"no family":      {script: "echo '{\"port\": 8080}'\n", wantErr: "family is required"},
+		"escaping files": {script: "echo '{\"family\": \"x\", \"files\": [{\"path\": \"../x\"}]}'\n", wantErr: "isn't within the source directory"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writePlugin(t, pluginDir, name, tc.script)
+
+			_, err := Plugin{Name: "broken", Path: path}.scan(sourceDir, &ScannerConfig{})
+			assert.ErrorContains(t, err, tc.wantErr)
+
+			// Plugins found on PATH don't fail the scan.
+			si, err := Plugin{Name: "broken", Path: path, Discovered: true}.scan(sourceDir, &ScannerConfig{})
+			assert.NoError(t, err)
+			assert.Nil(t, si)
+		})
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+
+	writePlugin(t, first, PluginExecutablePrefix+"acme", "")
+	writePlugin(t, second, PluginExecutablePrefix+"acme", "")
+	writePlugin(t, second, PluginExecutablePrefix+"bazel", "")
+	require.NoError(t, os.WriteFile(filepath.Join(second, PluginExecutablePrefix+"notes"), nil, 0o644))
+
+	t.Setenv("PATH", first+string(filepath.ListSeparator)+second)
+
+	assert.Equal(t, []Plugin{
+		{Name: "acme", Path: filepath.Join(first, PluginExecutablePrefix+"acme"), Order: PluginOrderFirst, Discovered: true},
+		{Name: "bazel", Path: filepath.Join(second, PluginExecutablePrefix+"bazel"), Order: PluginOrderFirst, Discovered: true},
+	}, DiscoverPlugins())
+}
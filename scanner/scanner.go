@@ -59,6 +59,11 @@ type SourceInfo struct {
 	SwapSizeMB       int
 	Buildpacks       []string
 	Secrets          []Secret
+	// BuildContext is the directory the image is built from relative to the
+	// source directory, and Ignorefile the ignore file of the build, when they
+	// aren't the defaults.
+	BuildContext string
+	Ignorefile   string
 
 	Files                           []SourceFile
 	Port                            int
@@ -105,6 +110,7 @@ type ScannerConfig struct {
 	ExistingPort    int
 	Colorize        *iostreams.ColorScheme
 	SkipHealthcheck bool // Skip healthcheck goroutine (primarily for tests)
+	Plugins         []Plugin
 }
 
 type GitHubActionsStruct struct {
@@ -114,35 +120,13 @@ type GitHubActionsStruct struct {
 }
 
 func Scan(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
-	scanners := []sourceScanner{
-		configureDjango,
-		configureLaravel,
-		configurePhoenix,
-		configureRails,
-		configureRedwood,
-		configureJsFramework,
-		/* frameworks scanners are placed before generic scanners,
-		   since they might mix languages or have a Dockerfile that
-			 doesn't work with Fly */
-		configureDockerfile,
-		configureBridgetown,
-		configureLucky,
-		configureRuby,
-		configureGo,
-		configureElixir,
-		configureFlask,
-		configurePython,
-		configureDeno,
-		configureNuxt,
-		configureNextJs,
-		configureNode,
-		configureStatic,
-		configureDotnet,
-		configureRust,
+	scanners, err := orderScanners(builtinScanners(), config.Plugins)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, scanner := range scanners {
-		si, err := scanner(sourceDir, config)
+		si, err := scanner.scan(sourceDir, config)
 		if err != nil {
 			return nil, err
 		}
@@ -159,6 +143,42 @@ func Scan(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
 	return nil, nil
 }
 
+// builtinScanners returns the built-in scanners in the order they run in,
+// named for plugins to be ordered relative to them.
+func builtinScanners() []namedScanner {
+	return []namedScanner{
+		{"django", configureDjango},
+		{"laravel", configureLaravel},
+		{"phoenix", configurePhoenix},
+		{"rails", configureRails},
+		{"redwood", configureRedwood},
+		/* packages of workspaces that no framework scanner configures are
+		   built from the root of the workspace, whatever language they use.
+		   The generic Node scanner builds packages on their own, which fails
+		   for packages depending on other packages of their workspace */
+		{"workspace", configureWorkspace},
+		{"js-framework", configureJsFramework},
+		/* frameworks scanners are placed before generic scanners,
+		   since they might mix languages or have a Dockerfile that
+			 doesn't work with Fly */
+		{"dockerfile", configureDockerfile},
+		{"bridgetown", configureBridgetown},
+		{"lucky", configureLucky},
+		{"ruby", configureRuby},
+		{"go", configureGo},
+		{"elixir", configureElixir},
+		{"flask", configureFlask},
+		{"python", configurePython},
+		{"deno", configureDeno},
+		{"nuxt", configureNuxt},
+		{"nextjs", configureNextJs},
+		{"node", configureNode},
+		{"static", configureStatic},
+		{"dotnet", configureDotnet},
+		{"rust", configureRust},
+	}
+}
+
 type sourceScanner func(sourceDir string, config *ScannerConfig) (*SourceInfo, error)
 
 // templates recursively returns files from the templates directory within the named directory
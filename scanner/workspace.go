@@ -0,0 +1,112 @@
+package scanner
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/superfly/flyctl/internal/command/launch/plan"
+	"github.com/superfly/flyctl/internal/workspace"
+)
+
+// WorkspaceIgnorefile is the ignore file generated next to the Dockerfile of
+// packages of workspaces, which are built from the root of the workspace.
+const WorkspaceIgnorefile = "Dockerfile.dockerignore"
+
+var workspaceFamilies = map[workspace.Kind]string{
+	workspace.KindPnpm:  "pnpm Workspace",
+	workspace.KindNpm:   "npm Workspace",
+	workspace.KindYarn:  "Yarn Workspace",
+	workspace.KindNx:    "Nx Workspace",
+	workspace.KindGo:    "Go Workspace",
+	workspace.KindCargo: "Cargo Workspace",
+}
+
+// configureWorkspace configures packages of pnpm, npm, Yarn, Nx, Go and Cargo
+// workspaces without a Dockerfile of their own, building them from the root
+// of the workspace with only the packages they depend on.
+func configureWorkspace(sourceDir string, _ *ScannerConfig) (*SourceInfo, error) {
+	if checksPass(sourceDir, fileExists("Dockerfile")) {
+		return nil, nil
+	}
+
+	w, err := workspace.Detect(sourceDir)
+	if err != nil || w == nil {
+		return nil, err
+	}
+
+	pkg := w.Package(sourceDir)
+	if pkg == nil {
+		return nil, nil
+	}
+
+	absSourceDir, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+	buildContext, err := filepath.Rel(absSourceDir, w.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	closure := w.Closure(pkg)
+	include := slices.Clone(w.RootFiles)
+	var excluded []string
+	for _, p := range w.Packages {
+		switch {
+		case slices.Contains(closure, p):
+			include = append(include, p.Dir)
+		case w.Kind == workspace.KindCargo:
+			// Cargo refuses to build workspaces with missing members.
+			include = append(include, path.Join(p.Dir, "Cargo.toml"))
+			excluded = append(excluded, p.Dir)
+		default:
+			excluded = append(excluded, p.Dir)
+		}
+	}
+
+	vars := map[string]any{
+		"name":     pkg.Name,
+		"dir":      pkg.Dir,
+		"kind":     string(w.Kind),
+		"tool":     w.Tool,
+		"version":  w.Version,
+		"include":  include,
+		"excluded": excluded,
+	}
+
+	s := &SourceInfo{
+		Family:       workspaceFamilies[w.Kind],
+		Version:      w.Version,
+		Port:         8080,
+		Env:          map[string]string{"PORT": "8080"},
+		BuildContext: filepath.ToSlash(buildContext),
+		Ignorefile:   WorkspaceIgnorefile,
+	}
+
+	switch w.Kind {
+	case workspace.KindGo:
+		if w.Version == "" {
+			vars["version"] = "1"
+		}
+		s.Files = templatesExecute("templates/workspace-go", vars)
+		s.Runtime = plan.RuntimeStruct{Language: "go", Version: w.Version}
+	case workspace.KindCargo:
+		if w.Version == "" {
+			vars["version"] = "1"
+		}
+		s.Files = templatesExecute("templates/workspace-cargo", vars)
+		s.Runtime = plan.RuntimeStruct{Language: "rust", Version: w.Version}
+		s.SkipDatabase = true
+	default:
+		if w.Version == "" {
+			vars["version"] = "22"
+		}
+		s.Files = templatesExecute("templates/workspace-node", vars)
+		s.Runtime = plan.RuntimeStruct{Language: "node", Version: w.Version}
+		s.Port = 3000
+		s.Env = map[string]string{"PORT": "3000"}
+	}
+
+	return s, nil
+}
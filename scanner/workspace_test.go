@@ -0,0 +1,56 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureWorkspace(t *testing.T) {
+	root := t.TempDir()
+	for name, contents := range map[string]string{
+		".git/HEAD":                  "",
+		"go.work":                    "go 1.23.2\n\nuse (\n\t./services/api\n\t./services/worker\n\t./lib\n)\n",
+		"lib/go.mod":                 "module example.com/lib\n\ngo 1.23\n",
+		"services/api/go.mod":        "module example.com/api\n\ngo 1.23\n\nrequire example.com/lib v0.0.0\n",
+		"services/api/main.go":       "package main\n",
+		"services/worker/go.mod":     "module example.com/worker\n\ngo 1.23\n",
+		"services/worker/main.go":    "package main\n",
+		"services/worker/Dockerfile": "FROM scratch\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+
+	si, err := configureWorkspace(filepath.Join(root, "services", "api"), &ScannerConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, si)
+
+	assert.Equal(t, "Go Workspace", si.Family)
+	assert.Equal(t, "1.23.2", si.Version)
+	assert.Equal(t, "../..", si.BuildContext)
+	assert.Equal(t, WorkspaceIgnorefile, si.Ignorefile)
+
+	files := map[string]string{}
+	for _, f := range si.Files {
+		files[f.Path] = string(f.Contents)
+	}
+	assert.Contains(t, files["Dockerfile"], "RUN go work edit -dropuse=./services/worker\n")
+	assert.Contains(t, files["Dockerfile"], "RUN go build -v -o /run-app ./services/api\n")
+	assert.Contains(t, files[WorkspaceIgnorefile], "*\n!go.work\n!lib\n!services/api\n")
+	assert.NotContains(t, files[WorkspaceIgnorefile], "services/worker")
+
+	// Packages with a Dockerfile of their own build with it.
+	si, err = configureWorkspace(filepath.Join(root, "services", "worker"), &ScannerConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, si)
+
+	// The root of the workspace isn't a package.
+	si, err = configureWorkspace(root, &ScannerConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, si)
+}